@@ -0,0 +1,589 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/processor"
+	"go-task-queue-system/infrastructure/queue"
+	"go-task-queue-system/usecase"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultConfigFile is read when it exists and no other file is given.
+const defaultConfigFile = "config.json"
+
+// Config is the server configuration. It is built from the defaults, then
+// a JSON config file, then TQ_* environment variables, then command-line
+// flags, each layer overriding the one before.
+type Config struct {
+	Server    ServerConfig   `json:"server"`
+	Queue     QueueConfig    `json:"queue"`
+	Workers   WorkersConfig  `json:"workers"`
+	Storage   StorageConfig  `json:"storage"`
+	Auth      AuthConfig     `json:"auth"`
+	Tenants   TenantsConfig  `json:"tenants"`
+	Commands  CommandsConfig `json:"commands"`
+	Tracing   TracingConfig  `json:"tracing"`
+	LogLevel  string         `json:"log_level"`
+	LogFormat string         `json:"log_format"`
+
+	// Uniqueness holds the uniqueness rules of task types, by type.
+	Uniqueness map[string]UniquenessConfig `json:"uniqueness"`
+}
+
+type ServerConfig struct {
+	Addr            string   `json:"addr"`
+	ReadTimeout     Duration `json:"read_timeout"`
+	WriteTimeout    Duration `json:"write_timeout"`
+	IdleTimeout     Duration `json:"idle_timeout"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+}
+
+type QueueConfig struct {
+	Capacity         int            `json:"capacity"`
+	FairShareBy      string         `json:"fair_share_by"`
+	FairShareWeights map[string]int `json:"fair_share_weights"`
+
+	// DispatchInterval is how often pending tasks that aren't in the queue
+	// yet, e.g. because it was full, are dispatched to it.
+	DispatchInterval Duration `json:"dispatch_interval"`
+
+	// ClaimTTL is how long a task claimed by another server's dispatcher
+	// is taken to be in that server's queue; after that it is dispatched
+	// again.
+	ClaimTTL Duration `json:"claim_ttl"`
+}
+
+type WorkersConfig struct {
+	Count int `json:"count"`
+
+	// Timeout is the time limit for tasks whose type has no entry in
+	// TypeTimeouts and that weren't submitted with one. No task may ask
+	// for more than MaxTimeout.
+	Timeout      Duration            `json:"timeout"`
+	TypeTimeouts map[string]Duration `json:"type_timeouts"`
+	MaxTimeout   Duration            `json:"max_timeout"`
+
+	RemoteTaskTypes   []string `json:"remote_task_types"`
+	LeaseDuration     Duration `json:"lease_duration"`
+	LeaseReapInterval Duration `json:"lease_reap_interval"`
+
+	// ExpiryCheckInterval is how often pending tasks are checked for
+	// deadlines that have passed.
+	ExpiryCheckInterval Duration `json:"expiry_check_interval"`
+
+	// FollowUpInterval is how often finished tasks are swept for follow-ups
+	// that weren't started when the task finished, e.g. because the
+	// tenant's quota was used up.
+	FollowUpInterval Duration `json:"follow_up_interval"`
+}
+
+// StorageConfig holds the data directories. ImageSourceDir is the only
+// directory image tasks may read local files from.
+type StorageConfig struct {
+	ArtifactDir     string `json:"artifact_dir"`
+	ImageOutputDir  string `json:"image_output_dir"`
+	ImageSourceDir  string `json:"image_source_dir"`
+	ReportOutputDir string `json:"report_output_dir"`
+	CommandWorkDir  string `json:"command_work_dir"`
+}
+
+type AuthConfig struct {
+	// APIKeysFile must exist and load, or the server refuses to start.
+	APIKeysFile string `json:"api_keys_file"`
+
+	// Disabled turns authentication off, leaving the API open to anyone who
+	// can reach the port. It has to be set explicitly.
+	Disabled bool `json:"disabled"`
+
+	// LinkSigningKey signs artifact download links. When empty a random key
+	// is generated, so links don't survive a restart.
+	LinkSigningKey string `json:"link_signing_key,omitempty"`
+}
+
+type TenantsConfig struct {
+	DefaultQuota usecase.TenantQuota            `json:"default_quota"`
+	Quotas       map[string]usecase.TenantQuota `json:"quotas"`
+}
+
+type CommandsConfig struct {
+	// Allowed maps the names "command" tasks use to executables.
+	Allowed            map[string]string `json:"allowed"`
+	RetryableExitCodes []int             `json:"retryable_exit_codes"`
+
+	// AllowedEnv lists the environment variables a task may set.
+	AllowedEnv []string `json:"allowed_env"`
+}
+
+// UniquenessConfig is a task type's uniqueness rule: tasks whose payloads
+// agree on Fields, submitted within Window of each other, are duplicates,
+// handled as OnDuplicate (reject, merge or replace) says.
+type UniquenessConfig struct {
+	Fields      []string `json:"fields"`
+	Window      Duration `json:"window"`
+	OnDuplicate string   `json:"on_duplicate"`
+}
+
+type TracingConfig struct {
+	ServiceName string `json:"service_name"`
+
+	// File receives finished spans as JSON lines when set.
+	File string `json:"file"`
+
+	// OTLPEndpoint is an OpenTelemetry collector's OTLP/HTTP address, such
+	// as http://localhost:4318, to send spans to when set.
+	OTLPEndpoint string `json:"otlp_endpoint"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     Duration(10 * time.Second),
+			WriteTimeout:    Duration(10 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Queue: QueueConfig{
+			Capacity:         100,
+			FairShareBy:      "tenant",
+			FairShareWeights: map[string]int{},
+			DispatchInterval: Duration(500 * time.Millisecond),
+			ClaimTTL:         Duration(10 * time.Minute),
+		},
+		Workers: WorkersConfig{
+			Count:             5,
+			Timeout:           Duration(30 * time.Second),
+			TypeTimeouts:      map[string]Duration{},
+			MaxTimeout:        Duration(time.Hour),
+			RemoteTaskTypes:   []string{},
+			LeaseDuration:     Duration(30 * time.Second),
+			LeaseReapInterval: Duration(5 * time.Second),
+
+			ExpiryCheckInterval: Duration(5 * time.Second),
+			FollowUpInterval:    Duration(30 * time.Second),
+		},
+		Storage: StorageConfig{
+			ArtifactDir:     "data/artifacts",
+			ImageOutputDir:  "data/images",
+			ImageSourceDir:  "data/image_sources",
+			ReportOutputDir: "data/reports",
+			CommandWorkDir:  "data/commands",
+		},
+		Auth: AuthConfig{
+			APIKeysFile: "api_keys.json",
+		},
+		Tenants: TenantsConfig{
+			DefaultQuota: usecase.TenantQuota{
+				MaxPendingTasks:      1000,
+				SubmissionsPerMinute: 600,
+			},
+			Quotas: map[string]usecase.TenantQuota{},
+		},
+		Commands: CommandsConfig{
+			Allowed: map[string]string{
+				"echo": "/bin/echo",
+				"date": "/bin/date",
+			},
+			RetryableExitCodes: []int{75}, // EX_TEMPFAIL
+			AllowedEnv:         []string{},
+		},
+		Tracing: TracingConfig{
+			ServiceName: "task-queue-server",
+		},
+		LogLevel:  "info",
+		LogFormat: "text",
+	}
+}
+
+// setting is a scalar option that can be set from the environment and the
+// command line. Maps such as quotas can only be set in the config file.
+type setting struct {
+	flag  string
+	usage string
+	set   func(cfg *Config, value string) error
+}
+
+// boolFlags are settings that can be given on the command line without a
+// value, like -auth-disabled.
+var boolFlags = map[string]bool{"auth-disabled": true}
+
+func (s setting) env() string {
+	return "TQ_" + strings.ToUpper(strings.ReplaceAll(s.flag, "-", "_"))
+}
+
+var settings = []setting{
+	{"addr", "listen address", func(c *Config, v string) error { c.Server.Addr = v; return nil }},
+	{"read-timeout", "HTTP read timeout", durationSetting(func(c *Config) *Duration { return &c.Server.ReadTimeout })},
+	{"write-timeout", "HTTP write timeout", durationSetting(func(c *Config) *Duration { return &c.Server.WriteTimeout })},
+	{"idle-timeout", "HTTP idle timeout", durationSetting(func(c *Config) *Duration { return &c.Server.IdleTimeout })},
+	{"shutdown-timeout", "how long to wait for requests and tasks on shutdown", durationSetting(func(c *Config) *Duration { return &c.Server.ShutdownTimeout })},
+	{"queue-capacity", "maximum number of queued tasks", intSetting(func(c *Config) *int { return &c.Queue.Capacity })},
+	{"fair-share-by", "group tasks for fair scheduling by tenant, type or submitted_by", func(c *Config, v string) error { c.Queue.FairShareBy = v; return nil }},
+	{"workers", "number of in-process workers", intSetting(func(c *Config) *int { return &c.Workers.Count })},
+	{"worker-timeout", "default time limit per task", durationSetting(func(c *Config) *Duration { return &c.Workers.Timeout })},
+	{"max-timeout", "longest time limit a task may ask for", durationSetting(func(c *Config) *Duration { return &c.Workers.MaxTimeout })},
+	{"remote-task-types", "comma-separated task types left to remote workers", func(c *Config, v string) error { c.Workers.RemoteTaskTypes = splitList(v); return nil }},
+	{"lease-duration", "default lease for remote workers", durationSetting(func(c *Config) *Duration { return &c.Workers.LeaseDuration })},
+	{"artifact-dir", "directory for task artifacts", func(c *Config, v string) error { c.Storage.ArtifactDir = v; return nil }},
+	{"image-source-dir", "directory image tasks may read local files from", func(c *Config, v string) error { c.Storage.ImageSourceDir = v; return nil }},
+	{"command-work-dir", "root directory for command tasks", func(c *Config, v string) error { c.Storage.CommandWorkDir = v; return nil }},
+	{"api-keys-file", "API key file", func(c *Config, v string) error { c.Auth.APIKeysFile = v; return nil }},
+	{"auth-disabled", "run without API key authentication", boolSetting(func(c *Config) *bool { return &c.Auth.Disabled })},
+	{"link-signing-key", "secret for signed artifact links", func(c *Config, v string) error { c.Auth.LinkSigningKey = v; return nil }},
+	{"trace-file", "file to write trace spans to as JSON lines", func(c *Config, v string) error { c.Tracing.File = v; return nil }},
+	{"otlp-endpoint", "OTLP/HTTP collector to send trace spans to, e.g. http://localhost:4318", func(c *Config, v string) error { c.Tracing.OTLPEndpoint = v; return nil }},
+	{"log-level", "debug, info, warn or error", func(c *Config, v string) error { c.LogLevel = v; return nil }},
+	{"log-format", "text or json", func(c *Config, v string) error { c.LogFormat = v; return nil }},
+}
+
+// LoadConfig builds the configuration from the config file, environment
+// and command-line arguments. printConfig reports whether --print-config
+// was given.
+func LoadConfig(args []string) (cfg *Config, configFile string, printConfig bool, err error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&configFile, "config", os.Getenv("TQ_CONFIG"), "JSON config file (env TQ_CONFIG, default "+defaultConfigFile+" if present)")
+	fs.BoolVar(&printConfig, "print-config", false, "print the effective configuration and exit")
+
+	type flagValue struct{ setting, value string }
+	var flagValues []flagValue
+	for _, s := range settings {
+		name := s.flag
+		register := fs.Func
+		if boolFlags[name] {
+			register = fs.BoolFunc
+		}
+		register(name, s.usage+" (env "+s.env()+")", func(value string) error {
+			flagValues = append(flagValues, flagValue{name, value})
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, "", false, err
+	}
+	if fs.NArg() > 0 {
+		return nil, "", false, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	cfg, configFile, err = loadConfigFile(configFile)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env()); ok {
+			if err := s.set(cfg, value); err != nil {
+				return nil, "", false, fmt.Errorf("%s: %w", s.env(), err)
+			}
+		}
+	}
+
+	for _, fv := range flagValues {
+		for _, s := range settings {
+			if s.flag == fv.setting {
+				if err := s.set(cfg, fv.value); err != nil {
+					return nil, "", false, fmt.Errorf("-%s: %w", s.flag, err)
+				}
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, "", false, err
+	}
+
+	return cfg, configFile, printConfig, nil
+}
+
+// loadConfigFile reads the defaults overlaid with path, or with the default
+// config file if path is empty and that file exists. It returns the file
+// it read, if any.
+func loadConfigFile(path string) (*Config, string, error) {
+	cfg := DefaultConfig()
+
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); err != nil {
+			return cfg, "", nil
+		}
+		path = defaultConfigFile
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, "", fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return cfg, path, nil
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(c.Queue.Capacity > 0, "queue.capacity must be positive")
+	check(c.Queue.DispatchInterval > 0, "queue.dispatch_interval must be positive")
+	check(c.Queue.ClaimTTL > 0, "queue.claim_ttl must be positive")
+	_, err := queue.GroupKeyFuncByName(c.Queue.FairShareBy)
+	check(err == nil, "queue.fair_share_by must be tenant, type or submitted_by")
+	for group, weight := range c.Queue.FairShareWeights {
+		check(weight > 0, "queue.fair_share_weights[%s] must be positive", group)
+	}
+
+	check(c.Workers.Count >= 0, "workers.count must not be negative")
+	check(c.Workers.Timeout > 0, "workers.timeout must be positive")
+	check(c.Workers.MaxTimeout >= c.Workers.Timeout, "workers.max_timeout must not be less than workers.timeout")
+	for name, timeout := range c.Workers.TypeTimeouts {
+		check(domain.TaskType(name).IsValid(), "workers.type_timeouts: unknown task type %q", name)
+		check(timeout > 0 && timeout <= c.Workers.MaxTimeout, "workers.type_timeouts[%s] must be positive and at most workers.max_timeout", name)
+	}
+	check(c.Workers.LeaseDuration > 0, "workers.lease_duration must be positive")
+	check(c.Workers.LeaseReapInterval > 0, "workers.lease_reap_interval must be positive")
+	check(c.Workers.ExpiryCheckInterval > 0, "workers.expiry_check_interval must be positive")
+	check(c.Workers.FollowUpInterval > 0, "workers.follow_up_interval must be positive")
+	for _, name := range c.Workers.RemoteTaskTypes {
+		check(domain.TaskType(name).IsValid(), "workers.remote_task_types: unknown task type %q", name)
+	}
+
+	check(c.Storage.ArtifactDir != "", "storage.artifact_dir is required")
+	check(c.Storage.ImageOutputDir != "", "storage.image_output_dir is required")
+	check(c.Storage.ImageSourceDir != "", "storage.image_source_dir is required")
+	check(c.Storage.ReportOutputDir != "", "storage.report_output_dir is required")
+	check(c.Storage.CommandWorkDir != "", "storage.command_work_dir is required")
+
+	check(c.Auth.Disabled || c.Auth.APIKeysFile != "", "auth.api_keys_file is required unless auth.disabled is set")
+	check(c.Auth.LinkSigningKey == "" || len(c.Auth.LinkSigningKey) >= 32, "auth.link_signing_key must be at least 32 characters")
+
+	check(c.Tenants.DefaultQuota.MaxPendingTasks >= 0 && c.Tenants.DefaultQuota.SubmissionsPerMinute >= 0,
+		"tenants.default_quota must not be negative")
+	for tenant, quota := range c.Tenants.Quotas {
+		check(domain.ValidateTenant(tenant) == nil, "tenants.quotas: invalid tenant name %q", tenant)
+		check(quota.MaxPendingTasks >= 0 && quota.SubmissionsPerMinute >= 0, "tenants.quotas[%s] must not be negative", tenant)
+	}
+
+	for name, path := range c.Commands.Allowed {
+		check(strings.HasPrefix(path, "/"), "commands.allowed[%s] must be an absolute path", name)
+	}
+	for _, name := range c.Commands.AllowedEnv {
+		check(!processor.IsUnsafeEnv(name), "commands.allowed_env: %s can't be allowed", name)
+	}
+
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+	if c.Tracing.OTLPEndpoint != "" {
+		endpoint, err := url.Parse(c.Tracing.OTLPEndpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"tracing.otlp_endpoint must be an http or https URL")
+	}
+
+	for name, rule := range c.Uniqueness {
+		check(domain.TaskType(name).IsValid(), "uniqueness: unknown task type %q", name)
+		check(len(rule.Fields) > 0, "uniqueness[%s].fields must name at least one payload field", name)
+		check(rule.Window > 0, "uniqueness[%s].window must be positive", name)
+		check(domain.DuplicatePolicy(rule.OnDuplicate).IsValid(), "uniqueness[%s].on_duplicate must be reject, merge or replace", name)
+	}
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.LogLevel)) == nil, "log_level must be debug, info, warn or error")
+	check(c.LogFormat == "text" || c.LogFormat == "json", "log_format must be text or json")
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// RestartRequired lists the sections of the configuration that differ from
+// applied, the configuration in effect, in settings that are only applied
+// at startup. Log level, task timeouts, fair-share weights, tenant quotas
+// and uniqueness rules are applied on reload.
+func (c *Config) RestartRequired(applied *Config) []string {
+	current, previous := c.withoutReloadable(), applied.withoutReloadable()
+
+	sections := []struct {
+		name        string
+		now, before interface{}
+	}{
+		{"server", current.Server, previous.Server},
+		{"queue", current.Queue, previous.Queue},
+		{"workers", current.Workers, previous.Workers},
+		{"storage", current.Storage, previous.Storage},
+		{"auth", current.Auth, previous.Auth},
+		{"commands", current.Commands, previous.Commands},
+		{"tracing", current.Tracing, previous.Tracing},
+		{"log_format", current.LogFormat, previous.LogFormat},
+	}
+
+	var changed []string
+	for _, section := range sections {
+		now, _ := json.Marshal(section.now)
+		before, _ := json.Marshal(section.before)
+		if string(now) != string(before) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
+
+// WithReloaded returns the configuration in effect after reloaded was
+// applied: its reloadable settings, and c's for the rest.
+func (c *Config) WithReloaded(reloaded *Config) *Config {
+	applied := *c
+	applied.LogLevel = reloaded.LogLevel
+	applied.Workers.Timeout = reloaded.Workers.Timeout
+	applied.Workers.TypeTimeouts = reloaded.Workers.TypeTimeouts
+	applied.Workers.MaxTimeout = reloaded.Workers.MaxTimeout
+	applied.Queue.FairShareWeights = reloaded.Queue.FairShareWeights
+	applied.Tenants = reloaded.Tenants
+	applied.Uniqueness = reloaded.Uniqueness
+	return &applied
+}
+
+func (c *Config) withoutReloadable() Config {
+	stripped := *c
+	stripped.LogLevel = ""
+	stripped.Workers.Timeout = 0
+	stripped.Workers.TypeTimeouts = nil
+	stripped.Workers.MaxTimeout = 0
+	stripped.Queue.FairShareWeights = nil
+	stripped.Tenants = TenantsConfig{}
+	return stripped
+}
+
+// Redacted returns a copy that is safe to print.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if redacted.Auth.LinkSigningKey != "" {
+		redacted.Auth.LinkSigningKey = "REDACTED"
+	}
+	return &redacted
+}
+
+func (c *Config) RemoteTaskTypes() []domain.TaskType {
+	taskTypes := make([]domain.TaskType, len(c.Workers.RemoteTaskTypes))
+	for i, name := range c.Workers.RemoteTaskTypes {
+		taskTypes[i] = domain.TaskType(name)
+	}
+	return taskTypes
+}
+
+func (c *Config) TimeoutPolicy() usecase.TimeoutPolicy {
+	perType := make(map[domain.TaskType]time.Duration, len(c.Workers.TypeTimeouts))
+	for name, timeout := range c.Workers.TypeTimeouts {
+		perType[domain.TaskType(name)] = time.Duration(timeout)
+	}
+	return usecase.TimeoutPolicy{
+		Default: time.Duration(c.Workers.Timeout),
+		PerType: perType,
+		Max:     time.Duration(c.Workers.MaxTimeout),
+	}
+}
+
+func (c *Config) UniquenessRules() map[domain.TaskType]domain.UniquenessRule {
+	rules := make(map[domain.TaskType]domain.UniquenessRule, len(c.Uniqueness))
+	for name, rule := range c.Uniqueness {
+		rules[domain.TaskType(name)] = domain.UniquenessRule{
+			Fields:      rule.Fields,
+			Window:      time.Duration(rule.Window),
+			OnDuplicate: domain.DuplicatePolicy(rule.OnDuplicate),
+		}
+	}
+	return rules
+}
+
+func (c *Config) Level() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.LogLevel))
+	return level
+}
+
+// Duration is a time.Duration written as a string such as "30s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func durationSetting(field func(*Config) *Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = Duration(parsed)
+		return nil
+	}
+}
+
+func intSetting(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		*field(c) = parsed
+		return nil
+	}
+}
+
+func boolSetting(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+		*field(c) = parsed
+		return nil
+	}
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
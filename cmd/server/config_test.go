@@ -0,0 +1,199 @@
+package main
+
+import (
+	"go-task-queue-system/usecase"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"server": {"addr": ":9000"},
+		"queue": {"capacity": 50},
+		"workers": {"count": 2, "timeout": "1m"}
+	}`)
+	t.Setenv("TQ_QUEUE_CAPACITY", "75")
+	t.Setenv("TQ_WORKERS", "3")
+
+	cfg, configFile, _, err := LoadConfig([]string{"-config", path, "-workers", "4"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if configFile != path {
+		t.Errorf("config file = %q, want %q", configFile, path)
+	}
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"default", cfg.Server.ShutdownTimeout, Duration(30 * time.Second)},
+		{"file over default", cfg.Server.Addr, ":9000"},
+		{"file over default", cfg.Workers.Timeout, Duration(time.Minute)},
+		{"env over file", cfg.Queue.Capacity, 75},
+		{"flag over env", cfg.Workers.Count, 4},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigAuthDisabled(t *testing.T) {
+	path := writeConfigFile(t, `{}`)
+
+	cfg, _, _, err := LoadConfig([]string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.Disabled {
+		t.Error("authentication is disabled by default")
+	}
+
+	cfg, _, _, err = LoadConfig([]string{"-config", path, "-auth-disabled"})
+	if err != nil {
+		t.Fatalf("LoadConfig(-auth-disabled) error = %v", err)
+	}
+	if !cfg.Auth.Disabled {
+		t.Error("-auth-disabled without a value didn't disable authentication")
+	}
+
+	t.Setenv("TQ_AUTH_DISABLED", "maybe")
+	if _, _, _, err := LoadConfig([]string{"-config", path}); err == nil || !strings.Contains(err.Error(), "TQ_AUTH_DISABLED") {
+		t.Errorf("LoadConfig() with TQ_AUTH_DISABLED=maybe error = %v", err)
+	}
+}
+
+func TestLoadConfigRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		args []string
+		want string
+	}{
+		{"unknown field", `{"server": {"port": 80}}`, nil, "unknown field"},
+		{"bad duration flag", `{}`, []string{"-worker-timeout", "soon"}, "-worker-timeout"},
+		{"stray argument", `{}`, []string{"extra"}, "unexpected arguments"},
+		{"invalid value", `{"queue": {"capacity": 0}}`, nil, "queue.capacity must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-config", writeConfigFile(t, tt.file)}, tt.args...)
+			_, _, _, err := LoadConfig(args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   string
+	}{
+		{"fair share key", func(c *Config) { c.Queue.FairShareBy = "priority" }, "queue.fair_share_by"},
+		{"weight", func(c *Config) { c.Queue.FairShareWeights = map[string]int{"acme": 0} }, "fair_share_weights[acme]"},
+		{"remote task type", func(c *Config) { c.Workers.RemoteTaskTypes = []string{"fax"} }, `unknown task type "fax"`},
+		{"key file", func(c *Config) { c.Auth.APIKeysFile = "" }, "auth.api_keys_file is required"},
+		{"signing key", func(c *Config) { c.Auth.LinkSigningKey = "short" }, "link_signing_key"},
+		{"quota", func(c *Config) { c.Tenants.Quotas = map[string]usecase.TenantQuota{"acme": {MaxPendingTasks: -1}} }, "tenants.quotas[acme]"},
+		{"command path", func(c *Config) { c.Commands.Allowed = map[string]string{"ls": "ls"} }, "absolute path"},
+		{"log level", func(c *Config) { c.LogLevel = "loud" }, "log_level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Auth.APIKeysFile = ""
+	cfg.Auth.Disabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() without a key file but auth.disabled = %v", err)
+	}
+}
+
+func TestConfigRestartRequired(t *testing.T) {
+	old := DefaultConfig()
+
+	reloadable := DefaultConfig()
+	reloadable.LogLevel = "debug"
+	reloadable.Workers.Timeout = Duration(time.Minute)
+	reloadable.Queue.FairShareWeights = map[string]int{"acme": 3}
+	reloadable.Tenants.DefaultQuota.MaxPendingTasks = 10
+	if changed := reloadable.RestartRequired(old); len(changed) != 0 {
+		t.Errorf("RestartRequired() for reloadable settings = %v, want none", changed)
+	}
+
+	restart := DefaultConfig()
+	restart.Server.Addr = ":9000"
+	restart.Workers.Count = 10
+	restart.Auth.Disabled = true
+	if changed, want := restart.RestartRequired(old), []string{"server", "workers", "auth"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("RestartRequired() = %v, want %v", changed, want)
+	}
+}
+
+func TestConfigWithReloaded(t *testing.T) {
+	started := DefaultConfig()
+
+	reloaded := DefaultConfig()
+	reloaded.LogLevel = "debug"
+	reloaded.Tenants.DefaultQuota.MaxPendingTasks = 10
+	reloaded.Server.Addr = ":9000"
+	applied := started.WithReloaded(reloaded)
+
+	if applied.LogLevel != "debug" || applied.Tenants.DefaultQuota.MaxPendingTasks != 10 {
+		t.Errorf("reloadable settings weren't applied: log_level %q, max_pending_tasks %d", applied.LogLevel, applied.Tenants.DefaultQuota.MaxPendingTasks)
+	}
+	if applied.Server.Addr != started.Server.Addr {
+		t.Errorf("server.addr = %q, want %q until a restart", applied.Server.Addr, started.Server.Addr)
+	}
+
+	// The same file reloaded again still needs a restart for server.addr,
+	// but nothing else.
+	if changed, want := reloaded.RestartRequired(applied), []string{"server"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("RestartRequired() = %v, want %v", changed, want)
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.LinkSigningKey = strings.Repeat("k", 32)
+
+	if got := cfg.Redacted().Auth.LinkSigningKey; got != "REDACTED" {
+		t.Errorf("redacted signing key = %q", got)
+	}
+	if cfg.Auth.LinkSigningKey == "REDACTED" {
+		t.Error("Redacted() changed the original config")
+	}
+}
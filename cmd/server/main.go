@@ -1,137 +1,314 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
 	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/auth"
+	"go-task-queue-system/infrastructure/logging"
 	"go-task-queue-system/infrastructure/processor"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	httpDelivery "go-task-queue-system/delivery/http"
+	"go-task-queue-system/infrastructure/events"
 	"go-task-queue-system/infrastructure/queue"
 	"go-task-queue-system/infrastructure/repository"
+	"go-task-queue-system/infrastructure/storage"
+	"go-task-queue-system/infrastructure/tracing"
 	"go-task-queue-system/infrastructure/worker"
 	"go-task-queue-system/usecase"
 )
 
-const (
-	serverPort    = ":8080"
-	queueCapacity = 100
-	workerCount   = 5
-	workerTimeout = 30 * time.Second
-)
+// logLevel is the level of the default logger; it can change on reload.
+var logLevel = new(slog.LevelVar)
 
 func main() {
-	log.Println("🚀 Starting Task Queue System...")
+	cfg, configFile, printConfig, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		fatal("invalid configuration", err)
+	}
+
+	if printConfig {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(cfg.Redacted()); err != nil {
+			fatal("failed to print config", err)
+		}
+		return
+	}
+
+	logLevel.Set(cfg.Level())
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, logLevel)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	slog.SetDefault(logger)
+
+	slog.Info("starting task queue system", "config_file", configFile, "log_level", cfg.LogLevel)
+
+	// API keys are loaded first, so a missing or broken key file stops the
+	// server before anything else starts.
+	var apiKeys *auth.KeyStore
+	if cfg.Auth.Disabled {
+		slog.Warn("authentication is DISABLED by auth.disabled, the API is open to anyone who can reach it")
+	} else {
+		apiKeys, err = auth.LoadKeyStore(cfg.Auth.APIKeysFile)
+		if err != nil {
+			fatal("failed to load API keys", err)
+		}
+		slog.Info("API key authentication enabled", "keys", apiKeys.Len())
+	}
 
 	// 1. Initialize Infrastructure Layer
 
-	// Repository (in-memory storage)
-	taskRepository := repository.NewMemoryRepository()
-	log.Println("✅ Repository initialized")
+	// Repository (in-memory storage), publishing task changes for live
+	// updates
+	taskEvents := events.NewBroker()
+	taskRepository := repository.NewPublishingRepository(repository.NewMemoryRepository(), taskEvents)
 
-	// Queue (channel-based)
-	taskQueue := queue.NewChannelQueue(queueCapacity)
-	log.Printf("✅ Queue initialized (capacity: %d)", queueCapacity)
+	// Artifact storage (local filesystem)
+	blobStore, err := storage.NewLocalBlobStore(cfg.Storage.ArtifactDir)
+	if err != nil {
+		fatal("failed to initialize artifact storage", err)
+	}
+
+	// Queue (fair-share between groups of tasks)
+	groupKey, err := queue.GroupKeyFuncByName(cfg.Queue.FairShareBy)
+	if err != nil {
+		fatal("failed to initialize queue", err)
+	}
+	taskQueue := queue.NewFairQueue(cfg.Queue.Capacity, groupKey, cfg.Queue.FairShareWeights)
+	slog.Info("queue initialized", "capacity", cfg.Queue.Capacity, "fair_share_by", cfg.Queue.FairShareBy)
+
+	// Tracing (spans to a file and/or an OTLP collector)
+	tracer, err := tracing.Setup(cfg.Tracing.ServiceName, cfg.Tracing.File, cfg.Tracing.OTLPEndpoint)
+	if err != nil {
+		fatal("failed to initialize tracing", err)
+	}
+	if cfg.Tracing.File != "" || cfg.Tracing.OTLPEndpoint != "" {
+		slog.Info("tracing enabled", "file", cfg.Tracing.File, "otlp_endpoint", cfg.Tracing.OTLPEndpoint)
+	}
 
 	// Processor Registry
 	processorRegistry := processor.NewProcessorRegistry()
 
 	// Register task processors
 	processorRegistry.Register(domain.TaskTypeEmail, processor.NewEmailProcessor())
-	processorRegistry.Register(domain.TaskTypeImageProcessing, processor.NewImageProcessor())
-	processorRegistry.Register(domain.TaskTypeReportGeneration, processor.NewReportProcessor())
-	log.Println("✅ Task processors registered")
+	processorRegistry.Register(domain.TaskTypeImageProcessing, processor.NewImageProcessor(cfg.Storage.ImageOutputDir, cfg.Storage.ImageSourceDir))
+	processorRegistry.Register(domain.TaskTypeReportGeneration, processor.NewReportProcessor(taskRepository, cfg.Storage.ReportOutputDir))
+	processorRegistry.Register(domain.TaskTypeCommand, processor.NewCommandProcessor(processor.CommandConfig{
+		AllowedCommands:    cfg.Commands.Allowed,
+		WorkDirRoot:        cfg.Storage.CommandWorkDir,
+		BaseEnv:            map[string]string{"PATH": "/usr/local/bin:/usr/bin:/bin"},
+		AllowedEnv:         cfg.Commands.AllowedEnv,
+		RetryableExitCodes: cfg.Commands.RetryableExitCodes,
+	}))
+	processorRegistry.Register(domain.TaskTypeHTTPRequest, processor.NewHTTPRequestProcessor(processor.HTTPRequestConfig{}))
+	slog.Info("task processors registered", "types", processorRegistry.TaskTypes())
 
 	// Worker Pool
 	workerPool := worker.NewWorkerPool(
-		workerCount,
+		cfg.Workers.Count,
 		taskQueue.GetChannel(),
 		taskRepository,
 		processorRegistry,
-		workerTimeout,
+		blobStore,
+		time.Duration(cfg.Workers.Timeout),
+		cfg.RemoteTaskTypes(),
+		tracer,
 	)
 	workerPool.Start()
-	log.Printf("✅ Worker pool started (%d workers)", workerCount)
 
 	// 2. Initialize Use Cases Layer
 
-	submitTaskUC := usecase.NewSubmitTaskUseCase(taskRepository, taskQueue)
+	quotaChecker := usecase.NewQuotaChecker(taskRepository, cfg.Tenants.DefaultQuota, cfg.Tenants.Quotas)
+	taskTimeouts := usecase.NewTaskTimeouts(cfg.TimeoutPolicy())
+	uniquenessRules := usecase.NewUniquenessRules(cfg.UniquenessRules())
+	dispatchTasksUC := usecase.NewDispatchTasksUseCase(taskRepository, taskQueue, time.Duration(cfg.Queue.ClaimTTL), cfg.RemoteTaskTypes())
+	submitTaskUC := usecase.NewSubmitTaskUseCase(taskRepository, taskQueue, dispatchTasksUC, quotaChecker, taskTimeouts, uniquenessRules)
 	getTaskUC := usecase.NewGetTaskUseCase(taskRepository)
 	listTasksUC := usecase.NewListTasksUseCase(taskRepository)
 	cancelTaskUC := usecase.NewCancelTaskUseCase(taskRepository)
 	getStatsUC := usecase.NewGetStatsUseCase(taskRepository, taskQueue)
-	log.Println("✅ Use cases initialized")
+	deleteTaskUC := usecase.NewDeleteTaskUseCase(taskRepository, blobStore)
+	getArtifactUC := usecase.NewGetArtifactUseCase(taskRepository, blobStore)
+	getAttemptsUC := usecase.NewGetTaskAttemptsUseCase(taskRepository)
+	leaseTaskUC := usecase.NewLeaseTaskUseCase(taskRepository, taskQueue, time.Duration(cfg.Workers.LeaseDuration))
+	renewLeaseUC := usecase.NewRenewLeaseUseCase(taskRepository, time.Duration(cfg.Workers.LeaseDuration))
+	completeLeasedTaskUC := usecase.NewCompleteLeasedTaskUseCase(taskRepository)
+	failLeasedTaskUC := usecase.NewFailLeasedTaskUseCase(taskRepository)
+	storeLeasedArtifactUC := usecase.NewStoreLeasedArtifactUseCase(taskRepository, blobStore)
+	retryTaskUC := usecase.NewRetryTaskUseCase(taskRepository, dispatchTasksUC, taskTimeouts)
+	watchTasksUC := usecase.NewWatchTasksUseCase(taskEvents)
+
+	// Lease reaper for remote workers
+	leaseReaper := worker.NewLeaseReaper(usecase.NewExpireLeasesUseCase(taskRepository), time.Duration(cfg.Workers.LeaseReapInterval))
+	leaseReaper.Start()
+
+	// Dispatcher from the repository to the queue
+	taskDispatcher := worker.NewTaskDispatcher(dispatchTasksUC, time.Duration(cfg.Queue.DispatchInterval))
+	taskDispatcher.Start()
+
+	// Expiry of tasks that waited past their deadline
+	taskExpirer := worker.NewTaskExpirer(usecase.NewExpireTasksUseCase(taskRepository), time.Duration(cfg.Workers.ExpiryCheckInterval))
+	taskExpirer.Start()
+
+	// Follow-ups of finished tasks
+	followUpStarter := worker.NewFollowUpStarter(usecase.NewStartFollowUpsUseCase(taskRepository, submitTaskUC), taskEvents, time.Duration(cfg.Workers.FollowUpInterval))
+	followUpStarter.Start()
 
 	// 3. Initialize HTTP Delivery Layer
 
+	// Without a configured key, signed artifact links are only valid for
+	// the lifetime of this process
+	signingKey := []byte(cfg.Auth.LinkSigningKey)
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			fatal("failed to generate link signing key", err)
+		}
+	}
+
 	handler := httpDelivery.NewHandler(
 		submitTaskUC,
 		getTaskUC,
 		listTasksUC,
 		cancelTaskUC,
 		getStatsUC,
+		deleteTaskUC,
+		getArtifactUC,
+		getAttemptsUC,
+		retryTaskUC,
+		watchTasksUC,
 		workerPool,
+		httpDelivery.NewURLSigner(signingKey),
+	)
+
+	workerAPI := httpDelivery.NewWorkerAPIHandler(
+		leaseTaskUC,
+		renewLeaseUC,
+		completeLeasedTaskUC,
+		failLeasedTaskUC,
+		storeLeasedArtifactUC,
+		tracer,
 	)
 
-	router := httpDelivery.SetupRoutes(handler)
-	log.Println("✅ HTTP routes configured")
+	router := httpDelivery.SetupRoutes(handler, workerAPI, httpDelivery.NewAuthenticator(apiKeys), tracer, processorRegistry.PayloadSchemas())
 
 	// 4. Start HTTP Server
 
 	server := &http.Server{
-		Addr:         serverPort,
+		Addr:         cfg.Server.Addr,
 		Handler:      router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout),
 	}
+	// Shutdown waits for open requests; end the event streams so it
+	// doesn't wait for them.
+	server.RegisterOnShutdown(taskEvents.Close)
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("🌐 Server starting on http://localhost%s", serverPort)
-		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Println("📋 Available Endpoints:")
-		log.Println("   GET  /health              - Health check")
-		log.Println("   POST /tasks               - Submit a task")
-		log.Println("   GET  /tasks               - List all tasks")
-		log.Println("   GET  /tasks?status=pending - Filter by status")
-		log.Println("   GET  /tasks/{id}          - Get task by ID")
-		log.Println("   POST /tasks/{id}/cancel   - Cancel a task")
-		log.Println("   GET  /stats               - System statistics")
-		log.Println("   GET  /workers/status      - Worker pool status")
-		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Println("✨ Ready to accept requests!")
-		log.Println("")
+		slog.Info("server listening", "addr", cfg.Server.Addr)
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("❌ Server failed to start: %v", err)
+			fatal("server failed to start", err)
 		}
 	}()
 
-	// 5. Graceful Shutdown
+	// 5. Reload on SIGHUP, shut down gracefully on SIGINT/SIGTERM
+
+	// applied is the configuration in effect, which reloads are compared
+	// against.
+	applied := cfg
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			break
+		}
+
+		slog.Info("reloading configuration")
+		reloaded, _, _, err := LoadConfig(os.Args[1:])
+		if err != nil {
+			slog.Error("keeping the current configuration", "error", err)
+			continue
+		}
+
+		workerPool.SetTimeout(time.Duration(reloaded.Workers.Timeout))
+		taskTimeouts.SetPolicy(reloaded.TimeoutPolicy())
+		taskQueue.SetWeights(reloaded.Queue.FairShareWeights)
+		quotaChecker.SetQuotas(reloaded.Tenants.DefaultQuota, reloaded.Tenants.Quotas)
+		uniquenessRules.SetRules(reloaded.UniquenessRules())
+		slog.Info("configuration reloaded", "log_level", reloaded.LogLevel, "worker_timeout", reloaded.Workers.Timeout.String())
+		logLevel.Set(reloaded.Level())
+
+		if changed := reloaded.RestartRequired(applied); len(changed) > 0 {
+			slog.Warn("some changes only take effect after a restart", "sections", strings.Join(changed, ", "))
+		}
+		applied = applied.WithReloaded(reloaded)
+	}
+
+	slog.Info("shutting down gracefully")
 
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
 
-	log.Println("")
-	log.Println("🛑 Shutting down gracefully...")
+	// Stop accepting requests, and with them new tasks
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("HTTP server shutdown", "error", err)
+	}
+	slog.Info("HTTP server stopped")
 
-	// Stop accepting new tasks
+	followUpStarter.Stop()
+	taskDispatcher.Stop()
 	taskQueue.Close()
-	log.Println("✅ Queue closed")
+	slog.Info("queue closed")
 
-	// Stop worker pool
-	workerPool.Stop()
-	log.Println("✅ Workers stopped")
+	// Let running tasks finish, up to the shutdown timeout
+	stopped := make(chan struct{})
+	go func() {
+		workerPool.Stop()
+		leaseReaper.Stop()
+		taskExpirer.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		slog.Info("workers stopped")
+	case <-ctx.Done():
+		slog.Warn("workers did not stop in time")
+	}
 
-	// Shutdown HTTP server
-	log.Println("✅ HTTP server stopped")
+	// Tasks still in the queue are lost with it; the next run dispatches
+	// them again.
+	released, err := dispatchTasksUC.ReleaseClaims()
+	if err != nil {
+		slog.Error("failed to release task dispatch claims", "error", err)
+	} else if released > 0 {
+		slog.Info("queued tasks will be dispatched again", "tasks", released)
+	}
+
+	if err := tracer.Shutdown(ctx); err != nil {
+		slog.Warn("failed to flush trace spans", "error", err)
+	}
+
+	slog.Info("shutdown complete")
+}
 
-	log.Println("👋 Goodbye!")
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
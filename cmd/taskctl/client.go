@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// task mirrors the fields of the server's task response that taskctl
+// shows.
+type task struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	Status      string                 `json:"status"`
+	Priority    string                 `json:"priority"`
+	Payload     map[string]interface{} `json:"payload"`
+	Result      map[string]interface{} `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	MaxRetries  int                    `json:"max_retries"`
+	RetryCount  int                    `json:"retry_count"`
+	CreatedAt   string                 `json:"created_at"`
+	StartedAt   *string                `json:"started_at,omitempty"`
+	CompletedAt *string                `json:"completed_at,omitempty"`
+	Tenant      string                 `json:"tenant"`
+	Version     int                    `json:"version"`
+	Progress    *struct {
+		Percent int    `json:"percent"`
+		Stage   string `json:"stage"`
+		Message string `json:"message"`
+	} `json:"progress,omitempty"`
+	Artifacts []struct {
+		Name        string `json:"name"`
+		Size        int64  `json:"size"`
+		DownloadURL string `json:"download_url"`
+	} `json:"artifacts,omitempty"`
+	// Deduplication is only sent in reply to a submission.
+	Deduplication *struct {
+		Outcome     string `json:"outcome"`
+		DuplicateOf string `json:"duplicate_of"`
+	} `json:"deduplication,omitempty"`
+}
+
+func (t *task) finished() bool {
+	return t.Status == "completed" || t.Status == "failed" || t.Status == "cancelled" || t.Status == "expired"
+}
+
+type apiError struct {
+	StatusCode int
+	Message    string
+	Details    string
+}
+
+func (e *apiError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("%s: %s (HTTP %d)", e.Message, e.Details, e.StatusCode)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+type client struct {
+	baseURL    string
+	apiKey     string
+	tenant     string
+	httpClient *http.Client
+}
+
+func newClient(baseURL, apiKey, tenant string) *client {
+	return &client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		tenant:     tenant,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request and returns the response body. Non-2xx responses are
+// returned as *apiError.
+func (c *client) do(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant", c.tenant)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apiError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		var decoded struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &decoded) == nil && decoded.Error != "" {
+			apiErr.Message, apiErr.Details = decoded.Error, decoded.Message
+		}
+		return nil, apiErr
+	}
+
+	return data, nil
+}
+
+func (c *client) submit(taskType, priority, ttl string, payload map[string]interface{}) ([]byte, *task, error) {
+	body := map[string]interface{}{
+		"type":     taskType,
+		"priority": priority,
+		"payload":  payload,
+	}
+	if ttl != "" {
+		body["ttl"] = ttl
+	}
+
+	data, err := c.do(http.MethodPost, "/tasks", body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodeTask(data)
+}
+
+func (c *client) getTask(id string) ([]byte, *task, error) {
+	data, err := c.do(http.MethodGet, "/tasks/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodeTask(data)
+}
+
+func (c *client) listTasks(status string) ([]*task, error) {
+	path := "/tasks"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+
+	data, err := c.do(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Tasks []*task `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	return list.Tasks, nil
+}
+
+func (c *client) cancelTask(id string) error {
+	_, err := c.do(http.MethodPost, "/tasks/"+url.PathEscape(id)+"/cancel", nil)
+	return err
+}
+
+func decodeTask(data []byte) ([]byte, *task, error) {
+	var t task
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, nil, err
+	}
+	return data, &t, nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type commands struct {
+	client *client
+	out    *printer
+}
+
+func (c *commands) submit(args []string) (int, error) {
+	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
+	taskType := fs.String("type", "", "task type (required)")
+	priority := fs.String("priority", "", "high, medium or low")
+	payloadJSON := fs.String("payload", "", "payload as a JSON object")
+	payloadFile := fs.String("f", "", "read the payload from a JSON file, or - for stdin")
+	ttl := fs.String("ttl", "", "let the task expire if it hasn't run within this long, e.g. 15m")
+	wait := fs.Bool("wait", false, "wait for the task to finish and exit with its outcome")
+	interval := fs.Duration("interval", time.Second, "poll interval with -wait")
+	timeout := fs.Duration("timeout", 0, "give up waiting after this long (0 waits forever)")
+	var sets keyValueFlags
+	fs.Var(&sets, "set", "set a payload field, key=value (repeatable; JSON values are decoded)")
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, nil // the flag package has printed the error
+	}
+	if *taskType == "" {
+		return exitUsage, usagef("-type is required")
+	}
+	if *payloadJSON != "" && *payloadFile != "" {
+		return exitUsage, usagef("use either -payload or -f, not both")
+	}
+
+	payload := map[string]interface{}{}
+	var raw []byte
+	switch {
+	case *payloadJSON != "":
+		raw = []byte(*payloadJSON)
+	case *payloadFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return exitError, err
+		}
+		raw = data
+	case *payloadFile != "":
+		data, err := os.ReadFile(*payloadFile)
+		if err != nil {
+			return exitError, err
+		}
+		raw = data
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return exitUsage, usagef("payload must be a JSON object: %v", err)
+		}
+	}
+	for key, value := range sets {
+		payload[key] = value
+	}
+
+	data, t, err := c.client.submit(*taskType, *priority, *ttl, payload)
+	if err != nil {
+		return exitError, err
+	}
+
+	if *wait {
+		if !c.out.json() {
+			fmt.Fprintf(c.out.w, "Submitted task %s\n", t.ID)
+		}
+		return c.follow(t.ID, *interval, *timeout)
+	}
+
+	if c.out.json() {
+		return exitOK, c.out.raw(data)
+	}
+	c.out.task(t)
+	return exitOK, nil
+}
+
+func (c *commands) get(args []string) (int, error) {
+	id, err := taskIDArg("get", args)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	data, t, err := c.client.getTask(id)
+	if err != nil {
+		return exitError, err
+	}
+
+	if c.out.json() {
+		return exitOK, c.out.raw(data)
+	}
+	c.out.task(t)
+	return exitOK, nil
+}
+
+func (c *commands) list(args []string) (int, error) {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	status := fs.String("status", "", "only tasks with this status")
+	taskType := fs.String("type", "", "only tasks of this type")
+	limit := fs.Int("limit", 0, "show at most this many tasks, newest first (0 shows all)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, nil // the flag package has printed the error
+	}
+
+	tasks, err := c.client.listTasks(*status)
+	if err != nil {
+		return exitError, err
+	}
+
+	filtered := make([]*task, 0, len(tasks))
+	for _, t := range tasks {
+		if *taskType == "" || t.Type == *taskType {
+			filtered = append(filtered, t)
+		}
+	}
+
+	// RFC3339 timestamps in the same zone sort chronologically as strings.
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt > filtered[j].CreatedAt
+	})
+	if *limit > 0 && len(filtered) > *limit {
+		filtered = filtered[:*limit]
+	}
+
+	if c.out.json() {
+		return exitOK, c.out.value(map[string]interface{}{
+			"tasks": filtered,
+			"total": len(filtered),
+		})
+	}
+	c.out.taskTable(filtered)
+	return exitOK, nil
+}
+
+func (c *commands) cancel(args []string) (int, error) {
+	id, err := taskIDArg("cancel", args)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	if err := c.client.cancelTask(id); err != nil {
+		return exitError, err
+	}
+
+	if c.out.json() {
+		return exitOK, c.out.value(map[string]string{"id": id, "status": "cancelled"})
+	}
+	fmt.Fprintf(c.out.w, "Task %s cancelled\n", id)
+	return exitOK, nil
+}
+
+func (c *commands) watch(args []string) (int, error) {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Second, "poll interval")
+	timeout := fs.Duration("timeout", 0, "give up after this long (0 waits forever)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, nil // the flag package has printed the error
+	}
+
+	id, err := taskIDArg("watch", fs.Args())
+	if err != nil {
+		return exitUsage, err
+	}
+
+	return c.follow(id, *interval, *timeout)
+}
+
+// follow polls a task until it finishes, printing status and progress
+// changes, and returns the exit code for its outcome.
+func (c *commands) follow(id string, interval, timeout time.Duration) (int, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	lastLine := ""
+	for {
+		data, t, err := c.client.getTask(id)
+		if err != nil {
+			return exitError, err
+		}
+
+		if !c.out.json() {
+			line := t.Status
+			if t.Progress != nil && !t.finished() {
+				line += " " + progressText(t)
+			}
+			if line != lastLine {
+				fmt.Fprintf(c.out.w, "%s  %s\n", time.Now().Format("15:04:05"), line)
+				lastLine = line
+			}
+		}
+
+		if t.finished() {
+			if c.out.json() {
+				if err := c.out.raw(data); err != nil {
+					return exitError, err
+				}
+			} else {
+				fmt.Fprintln(c.out.w)
+				c.out.task(t)
+			}
+			return outcomeCode(t), nil
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return exitTimeout, fmt.Errorf("task %s still %s after %s", id, t.Status, timeout)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func (c *commands) stats(args []string) (int, error) {
+	return c.show("/stats", args)
+}
+
+func (c *commands) workers(args []string) (int, error) {
+	return c.show("/workers/status", args)
+}
+
+func (c *commands) show(path string, args []string) (int, error) {
+	if len(args) > 0 {
+		return exitUsage, usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	data, err := c.client.do("GET", path, nil)
+	if err != nil {
+		return exitError, err
+	}
+
+	if c.out.json() {
+		return exitOK, c.out.raw(data)
+	}
+	return exitOK, c.out.keyValues(data)
+}
+
+func outcomeCode(t *task) int {
+	switch t.Status {
+	case "completed":
+		return exitOK
+	case "cancelled":
+		return exitCancelled
+	case "expired":
+		return exitExpired
+	default:
+		return exitFailed
+	}
+}
+
+func taskIDArg(command string, args []string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", usagef("usage: taskctl %s <task-id>", command)
+	}
+	return args[0], nil
+}
+
+// keyValueFlags collects repeated -set key=value flags. Values that parse
+// as JSON (numbers, booleans, objects...) are decoded, anything else is
+// kept as a string.
+type keyValueFlags map[string]interface{}
+
+func (f *keyValueFlags) String() string {
+	return ""
+}
+
+func (f *keyValueFlags) Set(value string) error {
+	key, raw, found := strings.Cut(value, "=")
+	if !found || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+
+	if *f == nil {
+		*f = keyValueFlags{}
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err == nil {
+		(*f)[key] = decoded
+	} else {
+		(*f)[key] = raw
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Exit codes. Commands that wait for a task (watch, submit -wait) report
+// the task's outcome through them.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitFailed    = 2
+	exitCancelled = 3
+	exitTimeout   = 4
+	exitExpired   = 5
+	exitUsage     = 64
+)
+
+const usage = `taskctl - command-line client for the task queue
+
+Usage:
+  taskctl [global flags] <command> [flags] [args]
+
+Commands:
+  submit    Submit a task (payload from -payload, -f FILE, -f - for stdin, or -set key=value)
+  get       Show a task:             taskctl get <id>
+  list      List tasks:              taskctl list [-status pending] [-type email] [-limit 20]
+  cancel    Cancel a task:           taskctl cancel <id>
+  watch     Follow a task until it finishes: taskctl watch <id>
+  stats     Show queue statistics
+  workers   Show worker pool status
+
+Global flags:
+  -server URL     server URL (env TQ_SERVER_URL, default http://localhost:8080)
+  -api-key KEY    API key (env TQ_API_KEY)
+  -tenant NAME    act for this tenant (env TQ_TENANT)
+  -config FILE    config file (env TASKCTL_CONFIG, default ~/.config/taskctl/config.json)
+  -o FORMAT       output format: table or json
+
+Exit codes:
+  0 success / task completed, 1 error, 2 task failed, 3 task cancelled,
+  4 gave up waiting, 5 task expired, 64 usage error
+`
+
+// config holds the connection settings. Flags override environment
+// variables, which override the config file.
+type config struct {
+	Server string `json:"server"`
+	APIKey string `json:"api_key"`
+	Tenant string `json:"tenant"`
+	Output string `json:"output"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	global := flag.NewFlagSet("taskctl", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+
+	server := global.String("server", "", "")
+	apiKey := global.String("api-key", "", "")
+	tenant := global.String("tenant", "", "")
+	configPath := global.String("config", "", "")
+	output := global.String("o", "", "")
+
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if global.NArg() == 0 {
+		global.Usage()
+		return exitUsage
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskctl: %v\n", err)
+		return exitError
+	}
+	overrideFromEnv(cfg)
+	override(&cfg.Server, *server)
+	override(&cfg.APIKey, *apiKey)
+	override(&cfg.Tenant, *tenant)
+	override(&cfg.Output, *output)
+
+	if cfg.Output != "table" && cfg.Output != "json" {
+		fmt.Fprintf(os.Stderr, "taskctl: unknown output format %q (use table or json)\n", cfg.Output)
+		return exitUsage
+	}
+
+	cli := &commands{
+		client: newClient(cfg.Server, cfg.APIKey, cfg.Tenant),
+		out:    newPrinter(os.Stdout, cfg.Output),
+	}
+
+	command, commandArgs := global.Arg(0), global.Args()[1:]
+
+	var handler func([]string) (int, error)
+	switch command {
+	case "submit":
+		handler = cli.submit
+	case "get":
+		handler = cli.get
+	case "list":
+		handler = cli.list
+	case "cancel":
+		handler = cli.cancel
+	case "watch":
+		handler = cli.watch
+	case "stats":
+		handler = cli.stats
+	case "workers":
+		handler = cli.workers
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "taskctl: unknown command %q\n\n%s", command, usage)
+		return exitUsage
+	}
+
+	code, err := handler(commandArgs)
+	if err != nil {
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(os.Stderr, "taskctl %s: %v\n", command, err)
+			return exitUsage
+		}
+		fmt.Fprintf(os.Stderr, "taskctl %s: %v\n", command, err)
+		if code == exitOK {
+			code = exitError
+		}
+	}
+	return code
+}
+
+func loadConfig(path string) (*config, error) {
+	cfg := &config{
+		Server: "http://localhost:8080",
+		Output: "table",
+	}
+
+	explicit := path != "" || os.Getenv("TASKCTL_CONFIG") != ""
+	if path == "" {
+		path = os.Getenv("TASKCTL_CONFIG")
+	}
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return cfg, nil
+		}
+		path = filepath.Join(dir, "taskctl", "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func overrideFromEnv(cfg *config) {
+	override(&cfg.Server, os.Getenv("TQ_SERVER_URL"))
+	override(&cfg.APIKey, os.Getenv("TQ_API_KEY"))
+	override(&cfg.Tenant, os.Getenv("TQ_TENANT"))
+}
+
+func override(setting *string, value string) {
+	if value != "" {
+		*setting = value
+	}
+}
+
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
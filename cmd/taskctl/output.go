@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, format: format}
+}
+
+func (p *printer) json() bool {
+	return p.format == "json"
+}
+
+// raw prints a server response as indented JSON.
+func (p *printer) raw(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return p.value(value)
+}
+
+func (p *printer) value(value interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (p *printer) task(t *task) {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", t.ID)
+	if dedup := t.Deduplication; dedup != nil && dedup.DuplicateOf != "" {
+		fmt.Fprintf(tw, "Submission:\t%s (duplicate of %s)\n", dedup.Outcome, dedup.DuplicateOf)
+	}
+	fmt.Fprintf(tw, "Type:\t%s\n", t.Type)
+	fmt.Fprintf(tw, "Status:\t%s\n", t.Status)
+	fmt.Fprintf(tw, "Priority:\t%s\n", t.Priority)
+	fmt.Fprintf(tw, "Tenant:\t%s\n", t.Tenant)
+	fmt.Fprintf(tw, "Retries:\t%d/%d\n", t.RetryCount, t.MaxRetries)
+	fmt.Fprintf(tw, "Created:\t%s\n", t.CreatedAt)
+	if t.StartedAt != nil {
+		fmt.Fprintf(tw, "Started:\t%s\n", *t.StartedAt)
+	}
+	if t.CompletedAt != nil {
+		fmt.Fprintf(tw, "Completed:\t%s\n", *t.CompletedAt)
+	}
+	if t.Progress != nil && !t.finished() {
+		fmt.Fprintf(tw, "Progress:\t%s\n", progressText(t))
+	}
+	if t.Error != "" {
+		fmt.Fprintf(tw, "Error:\t%s\n", t.Error)
+	}
+	for _, key := range sortedKeys(t.Result) {
+		fmt.Fprintf(tw, "Result %s:\t%s\n", key, compact(t.Result[key]))
+	}
+	for _, artifact := range t.Artifacts {
+		fmt.Fprintf(tw, "Artifact:\t%s (%d bytes) %s\n", artifact.Name, artifact.Size, artifact.DownloadURL)
+	}
+	tw.Flush()
+}
+
+func (p *printer) taskTable(tasks []*task) {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tSTATUS\tPRIORITY\tTENANT\tRETRIES\tCREATED")
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d/%d\t%s\n",
+			t.ID, t.Type, t.Status, t.Priority, t.Tenant, t.RetryCount, t.MaxRetries, t.CreatedAt)
+	}
+	tw.Flush()
+}
+
+// keyValues prints a flat JSON object as a two-column table. Nested values
+// are shown as compact JSON.
+func (p *printer) keyValues(data []byte) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(tw, "%s:\t%s\n", key, compact(values[key]))
+	}
+	return tw.Flush()
+}
+
+func progressText(t *task) string {
+	text := fmt.Sprintf("%d%%", t.Progress.Percent)
+	if t.Progress.Stage != "" {
+		text += " " + t.Progress.Stage
+	}
+	if t.Progress.Message != "" {
+		text += " (" + t.Progress.Message + ")"
+	}
+	return text
+}
+
+func compact(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strings.TrimRight(s, "\n")
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
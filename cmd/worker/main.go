@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/logging"
+	"go-task-queue-system/infrastructure/processor"
+	"go-task-queue-system/infrastructure/tracing"
+	"go-task-queue-system/infrastructure/worker"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	imageOutputDir = "data/images"
+	imageSourceDir = "data/image_sources"
+	commandWorkDir = "data/commands"
+)
+
+// Executables that "command" tasks may run, by the name tasks refer to them.
+var allowedCommands = map[string]string{
+	"echo": "/bin/echo",
+	"date": "/bin/date",
+}
+
+func main() {
+	hostname, _ := os.Hostname()
+
+	serverURL := flag.String("server", envOr("TQ_SERVER_URL", "http://localhost:8080"), "task queue server URL (env TQ_SERVER_URL)")
+	apiKey := flag.String("api-key", os.Getenv("TQ_API_KEY"), "API key with the tasks:work scope (env TQ_API_KEY)")
+	workerID := flag.String("id", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "worker ID reported to the server")
+	concurrency := flag.Int("concurrency", 2, "number of tasks to run at once")
+	types := flag.String("types", "", "comma-separated task types to run (default: all this binary supports)")
+	timeout := flag.Duration("timeout", 30*time.Second, "time limit for tasks the server sends without one")
+	leaseDuration := flag.Duration("lease", 30*time.Second, "lease duration requested from the server")
+	pollInterval := flag.Duration("poll", 2*time.Second, "wait between lease requests when there is no work")
+	logLevel := flag.String("log-level", envOr("TQ_LOG_LEVEL", "info"), "debug, info, warn or error (env TQ_LOG_LEVEL)")
+	traceFile := flag.String("trace-file", os.Getenv("TQ_TRACE_FILE"), "file to write trace spans to as JSON lines (env TQ_TRACE_FILE)")
+	otlpEndpoint := flag.String("otlp-endpoint", os.Getenv("TQ_OTLP_ENDPOINT"), "OTLP/HTTP collector to send trace spans to (env TQ_OTLP_ENDPOINT)")
+	logFormat := flag.String("log-format", envOr("TQ_LOG_FORMAT", "text"), "text or json (env TQ_LOG_FORMAT)")
+	flag.Parse()
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		fatal("invalid -log-level", err)
+	}
+	logger, err := logging.New(os.Stderr, *logFormat, level)
+	if err != nil {
+		fatal("invalid -log-format", err)
+	}
+	slog.SetDefault(logger)
+
+	tracer, err := tracing.Setup("task-queue-worker", *traceFile, *otlpEndpoint)
+	if err != nil {
+		fatal("failed to initialize tracing", err)
+	}
+
+	// Report generation reads the task history, which only the server has.
+	processorRegistry := processor.NewProcessorRegistry()
+	processorRegistry.Register(domain.TaskTypeEmail, processor.NewEmailProcessor())
+	processorRegistry.Register(domain.TaskTypeImageProcessing, processor.NewImageProcessor(imageOutputDir, imageSourceDir))
+	processorRegistry.Register(domain.TaskTypeCommand, processor.NewCommandProcessor(processor.CommandConfig{
+		AllowedCommands:    allowedCommands,
+		WorkDirRoot:        commandWorkDir,
+		BaseEnv:            map[string]string{"PATH": "/usr/local/bin:/usr/bin:/bin"},
+		RetryableExitCodes: []int{75}, // EX_TEMPFAIL
+	}))
+	processorRegistry.Register(domain.TaskTypeHTTPRequest, processor.NewHTTPRequestProcessor(processor.HTTPRequestConfig{}))
+
+	taskTypes, err := selectTaskTypes(*types, processorRegistry)
+	if err != nil {
+		fatal("cannot select task types", err)
+	}
+
+	client := worker.NewLeaseClient(*serverURL, *apiKey)
+
+	workers := make([]*worker.RemoteWorker, 0, *concurrency)
+	var wg sync.WaitGroup
+	for i := 1; i <= *concurrency; i++ {
+		w := worker.NewRemoteWorker(
+			fmt.Sprintf("%s-%d", *workerID, i),
+			client,
+			processorRegistry,
+			taskTypes,
+			*timeout,
+			*leaseDuration,
+			*pollInterval,
+			tracer,
+		)
+		workers = append(workers, w)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Start()
+		}()
+	}
+	slog.Info("remote workers started", "workers", *concurrency, "server", *serverURL, "types", taskTypes)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	slog.Info("shutting down, finishing running tasks")
+	for _, w := range workers {
+		w.Stop()
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		slog.Warn("failed to flush trace spans", "error", err)
+	}
+	slog.Info("remote workers stopped")
+}
+
+func selectTaskTypes(list string, registry *processor.ProcessorRegistry) ([]domain.TaskType, error) {
+	if list == "" {
+		return registry.TaskTypes(), nil
+	}
+
+	var taskTypes []domain.TaskType
+	for _, name := range strings.Split(list, ",") {
+		taskType := domain.TaskType(strings.TrimSpace(name))
+		if !registry.HasProcessor(taskType) {
+			return nil, fmt.Errorf("this worker cannot run task type %q", taskType)
+		}
+		taskTypes = append(taskTypes, taskType)
+	}
+	return taskTypes, nil
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
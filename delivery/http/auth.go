@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"go-task-queue-system/infrastructure/auth"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type apiKeyContextKey struct{}
+
+// Authenticator checks bearer API keys on protected routes. A nil key store
+// disables authentication.
+type Authenticator struct {
+	keys *auth.KeyStore
+}
+
+func NewAuthenticator(keys *auth.KeyStore) *Authenticator {
+	return &Authenticator{
+		keys: keys,
+	}
+}
+
+func (a *Authenticator) Require(scope auth.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.keys == nil {
+			next(w, r)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="task-queue"`)
+			respondError(w, http.StatusUnauthorized, "Missing API key", "send it as 'Authorization: Bearer <key>'")
+			return
+		}
+
+		key, err := a.keys.Authenticate(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="task-queue", error="invalid_token"`)
+			respondError(w, http.StatusUnauthorized, "Invalid API key", "")
+			return
+		}
+
+		if !key.HasScope(scope) {
+			respondError(w, http.StatusForbidden, "Insufficient scope", "this key lacks the "+string(scope)+" scope")
+			return
+		}
+
+		if allowed, retryAfter := a.keys.Allow(key); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, "Rate limit exceeded", "")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	}
+}
+
+func apiKeyFromContext(ctx context.Context) (*auth.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*auth.APIKey)
+	return key, ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
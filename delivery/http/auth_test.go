@@ -0,0 +1,80 @@
+package http
+
+import (
+	"go-task-queue-system/infrastructure/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticatorRequire(t *testing.T) {
+	keys, err := auth.NewKeyStore([]auth.APIKey{
+		{ID: "reader", Key: "reader-secret", Scopes: []auth.Scope{auth.ScopeRead}},
+		{ID: "ops", Key: "ops-secret", Scopes: []auth.Scope{auth.ScopeAdmin}},
+		{ID: "limited", Key: "limited-secret", Scopes: []auth.Scope{auth.ScopeRead}, RateLimit: 1, Burst: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator := NewAuthenticator(keys)
+
+	var seen string
+	handler := authenticator.Require(auth.ScopeSubmit, func(w http.ResponseWriter, r *http.Request) {
+		key, _ := apiKeyFromContext(r.Context())
+		seen = key.ID
+	})
+	readHandler := authenticator.Require(auth.ScopeRead, func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		authorization string
+		wantStatus    int
+	}{
+		{"no key", handler, "", http.StatusUnauthorized},
+		{"not bearer", handler, "Basic b3BzOm9wcw==", http.StatusUnauthorized},
+		{"unknown key", handler, "Bearer nope", http.StatusUnauthorized},
+		{"missing scope", handler, "Bearer reader-secret", http.StatusForbidden},
+		{"admin", handler, "Bearer ops-secret", http.StatusOK},
+		{"scheme is case-insensitive", handler, "bearer ops-secret", http.StatusOK},
+		{"within rate limit", readHandler, "Bearer limited-secret", http.StatusOK},
+		{"over rate limit", readHandler, "Bearer limited-secret", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+			if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+				t.Error("429 without Retry-After")
+			}
+		})
+	}
+
+	if seen != "ops" {
+		t.Errorf("handler saw key %q, want ops", seen)
+	}
+}
+
+func TestAuthenticatorWithoutKeys(t *testing.T) {
+	called := false
+	handler := NewAuthenticator(nil).Require(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/tasks/1", nil))
+	if !called {
+		t.Error("request was refused although authentication is disabled")
+	}
+}
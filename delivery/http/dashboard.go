@@ -0,0 +1,39 @@
+package http
+
+import (
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+)
+
+// dashboardFiles is the web dashboard. It is static and talks to the same
+// JSON API as every other client, so serving it needs no API key.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	serveDashboardFile(w, r, "index.html")
+}
+
+func (h *Handler) DashboardAsset(w http.ResponseWriter, r *http.Request) {
+	serveDashboardFile(w, r, r.PathValue("file"))
+}
+
+func serveDashboardFile(w http.ResponseWriter, r *http.Request, name string) {
+	content, err := fs.ReadFile(dashboardFiles, path.Join("dashboard", path.Clean("/"+name)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(content)
+}
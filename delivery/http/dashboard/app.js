@@ -0,0 +1,537 @@
+// Task queue dashboard. Everything here goes through the public JSON API:
+// GET /tasks for the initial list, GET /events for live updates, and the
+// task endpoints for details and actions.
+"use strict";
+
+const STATUSES = ["pending", "processing", "completed", "failed", "cancelled", "expired"];
+const TYPES = ["email", "image_processing", "report_generation", "command", "http_request"];
+const MAX_ROWS = 200;
+const QUEUE_WINDOW_MS = 10 * 60 * 1000;
+const RATE_MINUTES = 30;
+const RECONNECT_MS = 3000;
+
+const state = {
+  apiKey: localStorage.getItem("tq.apiKey") || "",
+  tenant: localStorage.getItem("tq.tenant") || "",
+  tasks: new Map(),
+  queueSamples: [],
+  selectedId: null,
+  stream: null,
+  renderPending: false,
+};
+
+const $ = (id) => document.getElementById(id);
+
+// el builds an element; children that are strings become text, so nothing
+// from the API is ever parsed as HTML.
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs || {})) {
+    if (name === "class") node.className = value;
+    else if (name.startsWith("on")) node.addEventListener(name.slice(2), value);
+    else node.setAttribute(name, value);
+  }
+  for (const child of children) {
+    if (child === null || child === undefined) continue;
+    node.append(child instanceof Node ? child : String(child));
+  }
+  return node;
+}
+
+function headers(extra) {
+  const result = Object.assign({}, extra);
+  if (state.apiKey) result["Authorization"] = "Bearer " + state.apiKey;
+  if (state.tenant) result["X-Tenant"] = state.tenant;
+  return result;
+}
+
+async function api(method, path, body) {
+  const init = { method, headers: headers(body ? { "Content-Type": "application/json" } : {}) };
+  if (body) init.body = JSON.stringify(body);
+
+  const response = await fetch(path, init);
+  const text = await response.text();
+  const data = text ? JSON.parse(text) : null;
+  if (!response.ok) {
+    const message = data && data.error ? data.error + (data.message ? ": " + data.message : "") : response.statusText;
+    const error = new Error(message);
+    error.status = response.status;
+    throw error;
+  }
+  return data;
+}
+
+function notify(message, isError) {
+  const notice = $("notice");
+  notice.textContent = message;
+  notice.className = "notice" + (isError ? " error" : "");
+  notice.hidden = !message;
+}
+
+function formatTime(value) {
+  if (!value) return "";
+  const date = new Date(value);
+  return date.toLocaleString();
+}
+
+function formatDuration(ms) {
+  if (ms < 1000) return ms + " ms";
+  if (ms < 60000) return (ms / 1000).toFixed(1) + " s";
+  return Math.floor(ms / 60000) + " m " + Math.round((ms % 60000) / 1000) + " s";
+}
+
+function shortId(id) {
+  return id.slice(0, 8);
+}
+
+// Connection and live updates
+
+async function connect() {
+  if (state.stream) state.stream.abort();
+  state.stream = new AbortController();
+  const signal = state.stream.signal;
+
+  try {
+    const list = await api("GET", "/tasks");
+    state.tasks = new Map(list.tasks.map((task) => [task.id, task]));
+    notify("");
+    scheduleRender();
+  } catch (error) {
+    setLive(false);
+    notify(error.status === 401 || error.status === 403
+      ? "Enter an API key with the tasks:read scope. (" + error.message + ")"
+      : "Cannot load tasks: " + error.message, true);
+    return;
+  }
+
+  try {
+    await readEvents(signal);
+  } catch (error) {
+    if (signal.aborted) return;
+  }
+  if (signal.aborted) return;
+
+  setLive(false);
+  setTimeout(() => {
+    if (!signal.aborted) connect();
+  }, RECONNECT_MS);
+}
+
+// readEvents reads the server-sent event stream with fetch, since
+// EventSource can't send the Authorization header.
+async function readEvents(signal) {
+  const response = await fetch("/events", { headers: headers({ Accept: "text/event-stream" }), signal });
+  if (!response.ok) throw new Error(response.statusText);
+  setLive(true);
+
+  const reader = response.body.getReader();
+  const decoder = new TextDecoder();
+  let buffer = "";
+
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) return;
+    buffer += decoder.decode(value, { stream: true });
+
+    let end;
+    while ((end = buffer.indexOf("\n\n")) >= 0) {
+      const message = buffer.slice(0, end);
+      buffer = buffer.slice(end + 2);
+
+      let event = "message";
+      const data = [];
+      for (const line of message.split("\n")) {
+        if (line.startsWith("event:")) event = line.slice(6).trim();
+        else if (line.startsWith("data:")) data.push(line.slice(5).trim());
+      }
+      if (data.length > 0) handleEvent(event, JSON.parse(data.join("\n")));
+    }
+  }
+}
+
+function handleEvent(event, data) {
+  switch (event) {
+    case "task":
+      if (data.kind === "deleted") state.tasks.delete(data.task.id);
+      else state.tasks.set(data.task.id, data.task);
+      if (data.task.id === state.selectedId) {
+        if (data.kind === "deleted") closeDetail();
+        else renderDetail(data.task, false);
+      }
+      scheduleRender();
+      break;
+    case "stats":
+      renderStats(data);
+      break;
+    case "workers":
+      renderWorkers(data);
+      break;
+  }
+}
+
+function setLive(on) {
+  const live = $("live");
+  live.textContent = on ? "live" : "offline";
+  live.className = "live " + (on ? "on" : "off");
+}
+
+// Rendering
+
+function scheduleRender() {
+  if (state.renderPending) return;
+  state.renderPending = true;
+  requestAnimationFrame(() => {
+    state.renderPending = false;
+    renderTasks();
+    renderRateCharts();
+  });
+}
+
+function renderStats(stats) {
+  $("stat-queue").textContent = stats.queue_size;
+  $("stat-pending").textContent = stats.pending_tasks;
+  $("stat-processing").textContent = stats.processing_tasks;
+  $("stat-completed").textContent = stats.completed_tasks;
+  $("stat-failed").textContent = stats.failed_tasks;
+  $("stat-cancelled").textContent = stats.cancelled_tasks;
+  $("stat-expired").textContent = stats.expired_tasks;
+
+  const now = Date.now();
+  state.queueSamples.push({ at: now, queued: stats.queue_size, pending: stats.pending_tasks });
+  state.queueSamples = state.queueSamples.filter((sample) => now - sample.at <= QUEUE_WINDOW_MS);
+
+  drawChart($("chart-queue"), [
+    { color: "#2f6fde", values: state.queueSamples.map((sample) => sample.queued), label: "queued" },
+    { color: "#8a6d00", values: state.queueSamples.map((sample) => sample.pending), label: "pending" },
+  ], "line");
+}
+
+function renderWorkers(status) {
+  const rows = [];
+  for (const worker of status.workers || []) {
+    rows.push(el("tr", {},
+      el("td", {}, worker.id),
+      el("td", { class: "status " + worker.state }, worker.state),
+      el("td", {}, worker.task_id ? taskLink(worker.task_id) : ""),
+      el("td", {}, worker.task_type || ""),
+      el("td", {}, formatTime(worker.since))));
+  }
+
+  // Remote workers show up as the holders of leased tasks.
+  for (const task of state.tasks.values()) {
+    if (task.status === "processing" && task.leased_by) {
+      rows.push(el("tr", {},
+        el("td", {}, task.leased_by + " (remote)"),
+        el("td", { class: "status busy" }, "busy"),
+        el("td", {}, taskLink(task.id)),
+        el("td", {}, task.type),
+        el("td", {}, formatTime(task.started_at))));
+    }
+  }
+
+  if (rows.length === 0) {
+    rows.push(el("tr", {}, el("td", { colspan: "5" }, "No workers")));
+  }
+  $("workers").replaceChildren(...rows);
+}
+
+function taskLink(id) {
+  return el("a", { href: "#" + id, onclick: (event) => { event.preventDefault(); openDetail(id); } }, shortId(id));
+}
+
+function matches(task, query) {
+  if (!query) return true;
+  const haystack = [task.id, task.type, task.status, task.priority, task.tenant, task.error, task.submitted_by]
+    .concat(JSON.stringify(task.payload || {}))
+    .join(" ")
+    .toLowerCase();
+  return query.split(/\s+/).every((word) => haystack.includes(word));
+}
+
+function renderTasks() {
+  const query = $("search").value.trim().toLowerCase();
+  const status = $("filter-status").value;
+  const type = $("filter-type").value;
+
+  const tasks = [...state.tasks.values()]
+    .filter((task) => (!status || task.status === status) && (!type || task.type === type) && matches(task, query))
+    .sort((a, b) => b.created_at.localeCompare(a.created_at));
+
+  const rows = tasks.slice(0, MAX_ROWS).map((task) => {
+    const percent = task.progress ? task.progress.percent : (task.status === "completed" ? 100 : 0);
+    return el("tr", { class: task.id === state.selectedId ? "selected" : "", onclick: () => openDetail(task.id) },
+      el("td", {}, el("code", { title: task.id }, shortId(task.id))),
+      el("td", {}, task.type),
+      el("td", { class: "status " + task.status }, task.status),
+      el("td", {}, task.priority),
+      el("td", {}, task.tenant),
+      el("td", {}, el("span", { class: "progress", title: percent + "%" }, el("span", { style: "width:" + percent + "%" }))),
+      el("td", {}, formatTime(task.created_at)),
+      el("td", {}, formatTime(task.updated_at)));
+  });
+
+  $("tasks").replaceChildren(...rows);
+  $("task-count").textContent = tasks.length > MAX_ROWS
+    ? "showing " + MAX_ROWS + " of " + tasks.length
+    : tasks.length + " task" + (tasks.length === 1 ? "" : "s");
+}
+
+// renderRateCharts counts status changes per minute from the status
+// history of the tasks in view.
+function renderRateCharts() {
+  const now = Date.now();
+  const completed = new Array(RATE_MINUTES).fill(0);
+  const failed = new Array(RATE_MINUTES).fill(0);
+
+  for (const task of state.tasks.values()) {
+    for (const transition of task.status_history || []) {
+      const age = Math.floor((now - Date.parse(transition.at)) / 60000);
+      if (age < 0 || age >= RATE_MINUTES) continue;
+      const bucket = RATE_MINUTES - 1 - age;
+      if (transition.to === "completed") completed[bucket]++;
+      if (transition.to === "failed") failed[bucket]++;
+    }
+  }
+
+  drawChart($("chart-throughput"), [{ color: "#1e8a4c", values: completed }], "bar");
+  drawChart($("chart-failures"), [{ color: "#c0392b", values: failed }], "bar");
+}
+
+function drawChart(canvas, series, kind) {
+  const ratio = window.devicePixelRatio || 1;
+  const width = canvas.clientWidth || canvas.width;
+  const height = canvas.clientHeight || canvas.height;
+  canvas.width = width * ratio;
+  canvas.height = height * ratio;
+
+  const ctx = canvas.getContext("2d");
+  ctx.scale(ratio, ratio);
+  ctx.clearRect(0, 0, width, height);
+
+  const pad = { left: 32, right: 8, top: 8, bottom: 16 };
+  const plotWidth = width - pad.left - pad.right;
+  const plotHeight = height - pad.top - pad.bottom;
+  const max = Math.max(1, ...series.flatMap((s) => s.values));
+
+  ctx.strokeStyle = "#dde1e8";
+  ctx.fillStyle = "#6b7385";
+  ctx.font = "11px system-ui, sans-serif";
+  ctx.textAlign = "right";
+  ctx.textBaseline = "middle";
+  for (const fraction of [0, 0.5, 1]) {
+    const y = pad.top + plotHeight * (1 - fraction);
+    ctx.beginPath();
+    ctx.moveTo(pad.left, y);
+    ctx.lineTo(width - pad.right, y);
+    ctx.stroke();
+    ctx.fillText(String(Math.round(max * fraction)), pad.left - 4, y);
+  }
+
+  for (const s of series) {
+    const n = s.values.length;
+    if (n === 0) continue;
+    ctx.fillStyle = s.color;
+    ctx.strokeStyle = s.color;
+
+    if (kind === "bar") {
+      const slot = plotWidth / n;
+      s.values.forEach((value, i) => {
+        const barHeight = (value / max) * plotHeight;
+        ctx.fillRect(pad.left + i * slot + 1, pad.top + plotHeight - barHeight, Math.max(1, slot - 2), barHeight);
+      });
+    } else {
+      ctx.lineWidth = 2;
+      ctx.beginPath();
+      s.values.forEach((value, i) => {
+        const x = pad.left + (n === 1 ? plotWidth : (i / (n - 1)) * plotWidth);
+        const y = pad.top + plotHeight * (1 - value / max);
+        if (i === 0) ctx.moveTo(x, y);
+        else ctx.lineTo(x, y);
+      });
+      ctx.stroke();
+    }
+  }
+
+  const legend = series.filter((s) => s.label);
+  ctx.textAlign = "left";
+  legend.forEach((s, i) => {
+    ctx.fillStyle = s.color;
+    ctx.fillText(s.label, pad.left + 6 + i * 70, pad.top + 6);
+  });
+}
+
+// Task details and actions
+
+async function openDetail(id) {
+  state.selectedId = id;
+  scheduleRender();
+
+  try {
+    const task = await api("GET", "/tasks/" + encodeURIComponent(id));
+    renderDetail(task, true);
+  } catch (error) {
+    notify("Cannot load task: " + error.message, true);
+  }
+}
+
+function closeDetail() {
+  state.selectedId = null;
+  $("detail").hidden = true;
+  scheduleRender();
+}
+
+// taskLink opens a related task, such as a follow-up, in the detail pane.
+function taskLink(id) {
+  if (!id) return "";
+  return el("a", { href: "#", onclick: (event) => { event.preventDefault(); openDetail(id); } }, id);
+}
+
+function renderDetail(task, loadAttempts) {
+  $("detail").hidden = false;
+  $("detail-title").textContent = task.type + " · " + task.id;
+
+  const fields = [
+    ["Status", el("span", { class: "status " + task.status }, task.status)],
+    ["Priority", task.priority],
+    ["Tenant", task.tenant],
+    ["Submitted by", task.submitted_by || ""],
+    ["Retries", task.retry_count + " / " + task.max_retries],
+    ["Timeout", task.timeout_seconds ? task.timeout_seconds + "s" : ""],
+    ["Expires", formatTime(task.expires_at)],
+    ["Unique key", task.unique_key || ""],
+    ["Failure reason", task.failure_reason || ""],
+    ["Progress", task.progress ? task.progress.percent + "% " + (task.progress.stage || "") + (task.progress.message ? " – " + task.progress.message : "") : ""],
+    ["Created", formatTime(task.created_at)],
+    ["Started", formatTime(task.started_at)],
+    ["Completed", formatTime(task.completed_at)],
+    ["Leased by", task.leased_by || ""],
+    ["Trace", task.trace_parent || ""],
+    ["Parent", taskLink(task.parent_id)],
+    ["Follow-up", task.follow_up_error ? "not started: " + task.follow_up_error : taskLink(task.follow_up_id)],
+    ["On success", task.on_success ? task.on_success.type : ""],
+    ["On failure", task.on_failure ? task.on_failure.type : ""],
+  ];
+  $("detail-fields").replaceChildren(...fields.flatMap(([name, value]) => [el("dt", {}, name), el("dd", {}, value)]));
+
+  $("detail-payload").textContent = JSON.stringify(task.payload, null, 2);
+  $("detail-result").textContent = task.result ? JSON.stringify(task.result, null, 2) : "—";
+  $("detail-error").textContent = task.error || "—";
+
+  const artifacts = (task.artifacts || []).map((artifact) => el("li", {},
+    el("a", { href: "#", onclick: (event) => { event.preventDefault(); download(task.id, artifact.name); } }, artifact.name),
+    " (" + artifact.content_type + ", " + artifact.size + " bytes)"));
+  $("detail-artifacts").replaceChildren(...(artifacts.length ? artifacts : [el("li", {}, "none")]));
+
+  $("detail-history").replaceChildren(...(task.status_history || []).map((transition) =>
+    el("li", {}, formatTime(transition.at) + ": " + transition.from + " → " + transition.to)));
+
+  $("action-cancel").disabled = task.status !== "pending";
+  $("action-retry").disabled = task.status !== "failed";
+  $("detail").dataset.task = JSON.stringify({
+    id: task.id, type: task.type, priority: task.priority, payload: task.payload,
+    timeout_seconds: task.timeout_seconds, failure_reason: task.failure_reason,
+  });
+
+  if (loadAttempts || task.status !== "processing") loadAttemptsFor(task.id);
+}
+
+async function loadAttemptsFor(id) {
+  try {
+    const list = await api("GET", "/tasks/" + encodeURIComponent(id) + "/attempts");
+    if (id !== state.selectedId) return;
+    const rows = list.attempts.map((attempt) => el("tr", {},
+      el("td", {}, attempt.attempt),
+      el("td", {}, attempt.worker_id),
+      el("td", {}, formatTime(attempt.started_at)),
+      el("td", {}, formatDuration(attempt.duration_ms)),
+      el("td", { class: "status " + (attempt.outcome === "succeeded" ? "completed" : "failed") },
+        attempt.outcome + (attempt.failure_reason ? " (" + attempt.failure_reason + ")" : "")),
+      el("td", { class: "wrap" }, attempt.error || "")));
+    $("detail-attempts").replaceChildren(...(rows.length ? rows : [el("tr", {}, el("td", { colspan: "6" }, "No attempts yet"))]));
+  } catch (error) {
+    $("detail-attempts").replaceChildren(el("tr", {}, el("td", { colspan: "6" }, "Cannot load attempts: " + error.message)));
+  }
+}
+
+async function download(id, name) {
+  try {
+    const link = await api("GET", "/tasks/" + encodeURIComponent(id) + "/artifacts/" + encodeURIComponent(name) + "/link");
+    window.open(link.url, "_blank");
+  } catch (error) {
+    notify("Cannot create download link: " + error.message, true);
+  }
+}
+
+async function runAction(label, request) {
+  try {
+    const result = await request();
+    notify(label);
+    return result;
+  } catch (error) {
+    notify(error.message, true);
+  }
+}
+
+function selectedTask() {
+  return JSON.parse($("detail").dataset.task);
+}
+
+$("action-cancel").addEventListener("click", () => {
+  const task = selectedTask();
+  if (!confirm("Cancel task " + task.id + "?")) return;
+  runAction("Task cancelled", () => api("POST", "/tasks/" + encodeURIComponent(task.id) + "/cancel"));
+});
+
+// A task that ran out of time can be retried with a longer time limit.
+$("action-retry").addEventListener("click", () => {
+  const task = selectedTask();
+  let path = "/tasks/" + encodeURIComponent(task.id) + "/retry";
+  if (task.failure_reason === "timeout") {
+    const timeout = prompt("The task timed out after " + task.timeout_seconds + "s. New time limit (e.g. 5m), or empty to keep it:", "");
+    if (timeout === null) return;
+    if (timeout.trim()) path += "?timeout=" + encodeURIComponent(timeout.trim());
+  }
+  runAction("Task queued again", () => api("POST", path));
+});
+
+// Replay submits a new task with the same type, priority, payload and
+// timeout. A type with a uniqueness rule may merge the copy into an
+// existing task, which is opened instead.
+$("action-replay").addEventListener("click", async () => {
+  const task = selectedTask();
+  if (!confirm("Submit a copy of task " + task.id + "?")) return;
+  const copy = await runAction("Copy submitted", () =>
+    api("POST", "/tasks", { type: task.type, priority: task.priority, payload: task.payload, timeout_seconds: task.timeout_seconds }));
+  if (!copy) return;
+  if (copy.deduplication && copy.deduplication.outcome === "merged") notify("Merged into duplicate task " + copy.id);
+  openDetail(copy.id);
+});
+
+$("detail-close").addEventListener("click", closeDetail);
+document.addEventListener("keydown", (event) => {
+  if (event.key === "Escape") closeDetail();
+});
+
+// Setup
+
+for (const status of STATUSES) $("filter-status").append(el("option", { value: status }, status));
+for (const type of TYPES) $("filter-type").append(el("option", { value: type }, type));
+for (const id of ["search", "filter-status", "filter-type"]) $(id).addEventListener("input", scheduleRender);
+
+$("api-key").value = state.apiKey;
+$("tenant").value = state.tenant;
+$("connection").addEventListener("submit", (event) => {
+  event.preventDefault();
+  state.apiKey = $("api-key").value.trim();
+  state.tenant = $("tenant").value.trim();
+  localStorage.setItem("tq.apiKey", state.apiKey);
+  localStorage.setItem("tq.tenant", state.tenant);
+  state.queueSamples = [];
+  connect();
+});
+
+// The charts are drawn from the clock too, so they move on when idle.
+setInterval(renderRateCharts, 15000);
+window.addEventListener("resize", scheduleRender);
+
+connect();
@@ -1,11 +1,40 @@
 package http
 
-import "go-task-queue-system/domain"
+import (
+	"go-task-queue-system/domain"
+	"go-task-queue-system/usecase"
+	"math"
+	"net/url"
+)
 
 type SubmitTaskRequest struct {
 	Type     string                 `json:"type"`
 	Priority string                 `json:"priority,omitempty"`
 	Payload  map[string]interface{} `json:"payload"`
+	// TimeoutSeconds limits each attempt; the server caps it at its
+	// maximum. Without it the task type's default applies.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// ExpiresAt (RFC 3339) or TTL (a duration such as "15m") sets when
+	// the task stops being worth running.
+	ExpiresAt string `json:"expires_at,omitempty"`
+	TTL       string `json:"ttl,omitempty"`
+	// RejectIfQueueFull asks for a 429 instead of a place in the backlog
+	// when the queue is full.
+	RejectIfQueueFull bool `json:"reject_if_queue_full,omitempty"`
+	// OnSuccess and OnFailure are submitted when the task finishes.
+	OnSuccess *FollowUpSpec `json:"on_success,omitempty"`
+	OnFailure *FollowUpSpec `json:"on_failure,omitempty"`
+}
+
+// FollowUpSpec is a task to submit when another one finishes. String
+// values in its payload may refer to the finished task, e.g.
+// "{{ .parent.result.processed_url }}".
+type FollowUpSpec struct {
+	Type      string                 `json:"type"`
+	Priority  string                 `json:"priority,omitempty"`
+	Payload   map[string]interface{} `json:"payload"`
+	OnSuccess *FollowUpSpec          `json:"on_success,omitempty"`
+	OnFailure *FollowUpSpec          `json:"on_failure,omitempty"`
 }
 
 type TaskResponse struct {
@@ -16,12 +45,135 @@ type TaskResponse struct {
 	Payload     map[string]interface{} `json:"payload"`
 	Result      map[string]interface{} `json:"result,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	Artifacts   []*ArtifactResponse    `json:"artifacts,omitempty"`
+	Progress    *ProgressResponse      `json:"progress,omitempty"`
 	MaxRetries  int                    `json:"max_retries"`
 	RetryCount  int                    `json:"retry_count"`
 	CreatedAt   string                 `json:"created_at"`
 	UpdatedAt   string                 `json:"updated_at"`
 	StartedAt   *string                `json:"started_at,omitempty"`
 	CompletedAt *string                `json:"completed_at,omitempty"`
+	History     []*TransitionResponse  `json:"status_history,omitempty"`
+	Version     int                    `json:"version"`
+	SubmittedBy string                 `json:"submitted_by,omitempty"`
+	Tenant      string                 `json:"tenant"`
+	TraceParent string                 `json:"trace_parent,omitempty"`
+
+	FailureReason  string  `json:"failure_reason,omitempty"`
+	TimeoutSeconds int     `json:"timeout_seconds,omitempty"`
+	ExpiresAt      *string `json:"expires_at,omitempty"`
+	Backlogged     bool    `json:"backlogged,omitempty"`
+	DispatchedAt   *string `json:"dispatched_at,omitempty"`
+	UniqueKey      string  `json:"unique_key,omitempty"`
+
+	OnSuccess     *FollowUpSpec `json:"on_success,omitempty"`
+	OnFailure     *FollowUpSpec `json:"on_failure,omitempty"`
+	ParentID      string        `json:"parent_id,omitempty"`
+	FollowUpID    string        `json:"follow_up_id,omitempty"`
+	FollowUpError string        `json:"follow_up_error,omitempty"`
+
+	// Deduplication is only set on the response to a submission.
+	Deduplication *DeduplicationResponse `json:"deduplication,omitempty"`
+
+	LeasedBy       string  `json:"leased_by,omitempty"`
+	LeaseExpiresAt *string `json:"lease_expires_at,omitempty"`
+}
+
+// DeduplicationResponse reports how a submission of a task type with a
+// uniqueness rule was handled.
+type DeduplicationResponse struct {
+	Outcome     string `json:"outcome"`
+	UniqueKey   string `json:"unique_key"`
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+}
+
+type TransitionResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	At   string `json:"at"`
+}
+
+type ProgressResponse struct {
+	Percent   int    `json:"percent"`
+	Stage     string `json:"stage,omitempty"`
+	Message   string `json:"message,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ArtifactResponse struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	Checksum    string `json:"checksum"`
+	CreatedAt   string `json:"created_at"`
+	DownloadURL string `json:"download_url"`
+}
+
+type ArtifactLinkResponse struct {
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type AttemptResponse struct {
+	Attempt    int    `json:"attempt"`
+	WorkerID   string `json:"worker_id"`
+	StartedAt  string `json:"started_at"`
+	EndedAt    string `json:"ended_at"`
+	DurationMs int64  `json:"duration_ms"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+	ResultSize int    `json:"result_size"`
+
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+type AttemptListResponse struct {
+	TaskID   string             `json:"task_id"`
+	Attempts []*AttemptResponse `json:"attempts"`
+	Total    int                `json:"total"`
+}
+
+type LeaseRequest struct {
+	WorkerID     string   `json:"worker_id"`
+	TaskTypes    []string `json:"task_types"`
+	LeaseSeconds int      `json:"lease_seconds,omitempty"`
+}
+
+type LeaseResponse struct {
+	Task           *TaskResponse `json:"task"`
+	LeaseExpiresAt string        `json:"lease_expires_at"`
+}
+
+type HeartbeatRequest struct {
+	WorkerID     string           `json:"worker_id"`
+	LeaseSeconds int              `json:"lease_seconds,omitempty"`
+	Progress     *ProgressRequest `json:"progress,omitempty"`
+}
+
+type ProgressRequest struct {
+	Percent int    `json:"percent"`
+	Stage   string `json:"stage,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type HeartbeatResponse struct {
+	LeaseExpiresAt string `json:"lease_expires_at"`
+}
+
+type CompleteTaskRequest struct {
+	WorkerID string                 `json:"worker_id"`
+	Result   map[string]interface{} `json:"result"`
+}
+
+type FailTaskRequest struct {
+	WorkerID  string `json:"worker_id"`
+	Error     string `json:"error"`
+	Permanent bool   `json:"permanent,omitempty"`
+	// Reason is timeout when the task ran out of time.
+	Reason string `json:"reason,omitempty"`
+	// Result is what the attempt returned, if anything, such as the
+	// response to a failed request.
+	Result map[string]interface{} `json:"result,omitempty"`
 }
 
 type TaskListResponse struct {
@@ -36,7 +188,22 @@ type StatsResponse struct {
 	CompletedTasks  int `json:"completed_tasks"`
 	FailedTasks     int `json:"failed_tasks"`
 	CancelledTasks  int `json:"cancelled_tasks"`
+	ExpiredTasks    int `json:"expired_tasks"`
 	QueueSize       int `json:"queue_size"`
+	BacklogSize     int `json:"backlog_size"`
+
+	Tenant      string                    `json:"tenant,omitempty"`
+	Tenants     map[string]*StatsResponse `json:"tenants,omitempty"`
+	QueueShares []*QueueShareResponse     `json:"queue_shares,omitempty"`
+}
+
+type QueueShareResponse struct {
+	Group       string  `json:"group"`
+	Weight      int     `json:"weight"`
+	Queued      int     `json:"queued"`
+	Dispatched  int     `json:"dispatched"`
+	Share       float64 `json:"share"`
+	TargetShare float64 `json:"target_share"`
 }
 
 type ErrorResponse struct {
@@ -54,23 +221,80 @@ type HealthResponse struct {
 }
 
 type WorkerStatusResponse struct {
-	WorkerCount int    `json:"worker_count"`
-	Timeout     string `json:"timeout"`
+	WorkerCount int                    `json:"worker_count"`
+	Timeout     string                 `json:"timeout"`
+	RemoteTypes []string               `json:"remote_types"`
+	Workers     []*WorkerStateResponse `json:"workers"`
+}
+
+type WorkerStateResponse struct {
+	ID       string `json:"id"`
+	State    string `json:"state"`
+	TaskID   string `json:"task_id,omitempty"`
+	TaskType string `json:"task_type,omitempty"`
+	Since    string `json:"since"`
+}
+
+type TaskEventResponse struct {
+	Kind string        `json:"kind"`
+	At   string        `json:"at"`
+	Task *TaskResponse `json:"task"`
+}
+
+// ToSubmitTaskResponse describes the task a submission resulted in.
+func ToSubmitTaskResponse(result *usecase.SubmitTaskResult) *TaskResponse {
+	response := ToTaskResponse(result.Task)
+	if result.Task.UniqueKey != "" {
+		response.Deduplication = &DeduplicationResponse{
+			Outcome:     string(result.Outcome),
+			UniqueKey:   result.Task.UniqueKey,
+			DuplicateOf: result.DuplicateOf,
+		}
+	}
+	return response
 }
 
 func ToTaskResponse(task *domain.Task) *TaskResponse {
 	response := &TaskResponse{
-		ID:         task.ID,
-		Type:       task.Type.String(),
-		Status:     task.Status.String(),
-		Priority:   task.Priority.String(),
-		Payload:    task.Payload,
-		Result:     task.Result,
-		Error:      task.Error,
-		MaxRetries: task.MaxRetries,
-		RetryCount: task.RetryCount,
-		CreatedAt:  task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:  task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:          task.ID,
+		Type:        task.Type.String(),
+		Status:      task.Status.String(),
+		Priority:    task.Priority.String(),
+		Payload:     task.Payload,
+		Result:      task.Result,
+		Error:       task.Error,
+		MaxRetries:  task.MaxRetries,
+		RetryCount:  task.RetryCount,
+		Version:     task.Version,
+		SubmittedBy: task.SubmittedBy,
+		Tenant:      task.Tenant,
+		TraceParent: task.TraceParent,
+		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+
+		FailureReason:  task.FailureReason.String(),
+		TimeoutSeconds: int(math.Ceil(task.Timeout.Seconds())),
+		Backlogged:     task.AwaitingDispatch(),
+		UniqueKey:      task.UniqueKey,
+
+		OnSuccess:     ToFollowUpSpec(task.OnSuccess),
+		OnFailure:     ToFollowUpSpec(task.OnFailure),
+		ParentID:      task.ParentID,
+		FollowUpID:    task.FollowUpID,
+		FollowUpError: task.FollowUpError,
+	}
+
+	for _, artifact := range task.Artifacts {
+		response.Artifacts = append(response.Artifacts, ToArtifactResponse(task.ID, artifact))
+	}
+
+	if task.Progress != nil {
+		response.Progress = &ProgressResponse{
+			Percent:   task.Progress.Percent,
+			Stage:     task.Progress.Stage,
+			Message:   task.Progress.Message,
+			UpdatedAt: task.Progress.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
 	}
 
 	if task.StartedAt != nil {
@@ -83,6 +307,124 @@ func ToTaskResponse(task *domain.Task) *TaskResponse {
 		response.CompletedAt = &completedAt
 	}
 
+	if task.ExpiresAt != nil {
+		expiresAt := task.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ExpiresAt = &expiresAt
+	}
+
+	if task.DispatchedAt != nil {
+		dispatchedAt := task.DispatchedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DispatchedAt = &dispatchedAt
+	}
+
+	if task.LeaseExpiresAt != nil {
+		leaseExpiresAt := task.LeaseExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		response.LeasedBy = task.LeasedBy
+		response.LeaseExpiresAt = &leaseExpiresAt
+	}
+
+	for _, transition := range task.Transitions {
+		response.History = append(response.History, &TransitionResponse{
+			From: transition.From.String(),
+			To:   transition.To.String(),
+			At:   transition.At.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	return response
+}
+
+// ToFollowUpSpec returns nil for a nil follow-up.
+func ToFollowUpSpec(followUp *domain.FollowUp) *FollowUpSpec {
+	if followUp == nil {
+		return nil
+	}
+	return &FollowUpSpec{
+		Type:      followUp.Type.String(),
+		Priority:  followUp.Priority.String(),
+		Payload:   followUp.Payload,
+		OnSuccess: ToFollowUpSpec(followUp.OnSuccess),
+		OnFailure: ToFollowUpSpec(followUp.OnFailure),
+	}
+}
+
+// ToFollowUp returns nil for a nil spec.
+func (s *FollowUpSpec) ToFollowUp() *domain.FollowUp {
+	if s == nil {
+		return nil
+	}
+	return &domain.FollowUp{
+		Type:      domain.TaskType(s.Type),
+		Priority:  domain.TaskPriority(s.Priority),
+		Payload:   s.Payload,
+		OnSuccess: s.OnSuccess.ToFollowUp(),
+		OnFailure: s.OnFailure.ToFollowUp(),
+	}
+}
+
+func ToArtifactResponse(taskID string, artifact domain.Artifact) *ArtifactResponse {
+	return &ArtifactResponse{
+		Name:        artifact.Name,
+		Size:        artifact.Size,
+		ContentType: artifact.ContentType,
+		Checksum:    artifact.Checksum,
+		CreatedAt:   artifact.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DownloadURL: artifactPath(taskID, artifact.Name),
+	}
+}
+
+func artifactPath(taskID, name string) string {
+	return "/tasks/" + url.PathEscape(taskID) + "/artifacts/" + url.PathEscape(name)
+}
+
+func ToAttemptListResponse(taskID string, attempts []*domain.TaskAttempt) *AttemptListResponse {
+	responses := make([]*AttemptResponse, len(attempts))
+	for i, attempt := range attempts {
+		responses[i] = &AttemptResponse{
+			Attempt:    attempt.Attempt,
+			WorkerID:   attempt.WorkerID,
+			StartedAt:  attempt.StartedAt.Format("2006-01-02T15:04:05Z07:00"),
+			EndedAt:    attempt.EndedAt.Format("2006-01-02T15:04:05Z07:00"),
+			DurationMs: attempt.Duration.Milliseconds(),
+			Outcome:    attempt.Outcome.String(),
+			Error:      attempt.Error,
+			ResultSize: attempt.ResultSize,
+
+			FailureReason: attempt.FailureReason.String(),
+		}
+	}
+
+	return &AttemptListResponse{
+		TaskID:   taskID,
+		Attempts: responses,
+		Total:    len(attempts),
+	}
+}
+
+func ToStatsResponse(stats *usecase.TaskStats) *StatsResponse {
+	response := &StatsResponse{
+		TotalTasks:      stats.TotalTasks,
+		PendingTasks:    stats.PendingTasks,
+		ProcessingTasks: stats.ProcessingTasks,
+		CompletedTasks:  stats.CompletedTasks,
+		FailedTasks:     stats.FailedTasks,
+		CancelledTasks:  stats.CancelledTasks,
+		ExpiredTasks:    stats.ExpiredTasks,
+		QueueSize:       stats.QueueSize,
+		BacklogSize:     stats.BacklogSize,
+	}
+
+	for _, share := range stats.QueueShares {
+		response.QueueShares = append(response.QueueShares, &QueueShareResponse{
+			Group:       share.Group,
+			Weight:      share.Weight,
+			Queued:      share.Queued,
+			Dispatched:  share.Dispatched,
+			Share:       share.Share,
+			TargetShare: share.TargetShare,
+		})
+	}
+
 	return response
 }
 
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-task-queue-system/infrastructure/logging"
+	"net/http"
+	"time"
+)
+
+// eventsSnapshotInterval is how often the event stream sends stats and
+// worker states. Task changes are sent as they happen.
+const eventsSnapshotInterval = 2 * time.Second
+
+// StreamEvents sends live updates as server-sent events: a "task" event
+// for every change to a task the caller can see, and "stats" and
+// "workers" events with the bodies of GET /stats and GET /workers/status
+// every couple of seconds.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	// The stream outlives the server's write timeout.
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	events, stop := h.watchTasksUC.Execute(tenant)
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	logger := logging.FromContext(r.Context())
+	send := func(event string, data interface{}) bool {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			logger.Error("failed to encode event", "event", event, "error", err)
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+
+	sendSnapshot := func() bool {
+		stats, err := h.stats(tenant)
+		if err != nil {
+			logger.Warn("failed to collect stats for event stream", "error", err)
+		} else if !send("stats", stats) {
+			return false
+		}
+		return send("workers", h.workerPool.GetStatus())
+	}
+
+	if !sendSnapshot() {
+		return
+	}
+
+	ticker := time.NewTicker(eventsSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !send("task", TaskEventResponse{
+				Kind: event.Kind.String(),
+				At:   event.At.Format(time.RFC3339Nano),
+				Task: ToTaskResponse(event.Task),
+			}) {
+				return
+			}
+
+		case <-ticker.C:
+			if !sendSnapshot() {
+				return
+			}
+		}
+	}
+}
@@ -2,20 +2,42 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/logging"
+	"go-task-queue-system/infrastructure/tracing"
 	"go-task-queue-system/usecase"
-	"log"
+	"io"
+	"math"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	defaultArtifactLinkTTL = 15 * time.Minute
+	maxArtifactLinkTTL     = 24 * time.Hour
+
+	// queueFullRetryAfter is what callers that won't wait in the backlog
+	// are told to wait before submitting again.
+	queueFullRetryAfter = 5 * time.Second
 )
 
 type Handler struct {
-	submitTaskUC *usecase.SubmitTaskUseCase
-	getTaskUC    *usecase.GetTaskUseCase
-	listTasksUC  *usecase.ListTasksUseCase
-	cancelTaskUC *usecase.CancelTaskUseCase
-	getStatsUC   *usecase.GetStatsUseCase
-	workerPool   WorkerPool
+	submitTaskUC  *usecase.SubmitTaskUseCase
+	getTaskUC     *usecase.GetTaskUseCase
+	listTasksUC   *usecase.ListTasksUseCase
+	cancelTaskUC  *usecase.CancelTaskUseCase
+	getStatsUC    *usecase.GetStatsUseCase
+	deleteTaskUC  *usecase.DeleteTaskUseCase
+	getArtifactUC *usecase.GetArtifactUseCase
+	getAttemptsUC *usecase.GetTaskAttemptsUseCase
+	retryTaskUC   *usecase.RetryTaskUseCase
+	watchTasksUC  *usecase.WatchTasksUseCase
+	workerPool    WorkerPool
+	urlSigner     *URLSigner
 }
 
 type WorkerPool interface {
@@ -28,22 +50,34 @@ func NewHandler(
 	listTasksUC *usecase.ListTasksUseCase,
 	cancelTaskUC *usecase.CancelTaskUseCase,
 	getStatsUC *usecase.GetStatsUseCase,
+	deleteTaskUC *usecase.DeleteTaskUseCase,
+	getArtifactUC *usecase.GetArtifactUseCase,
+	getAttemptsUC *usecase.GetTaskAttemptsUseCase,
+	retryTaskUC *usecase.RetryTaskUseCase,
+	watchTasksUC *usecase.WatchTasksUseCase,
 	workerPool WorkerPool,
+	urlSigner *URLSigner,
 ) *Handler {
 	return &Handler{
-		submitTaskUC: submitTaskUC,
-		getTaskUC:    getTaskUC,
-		listTasksUC:  listTasksUC,
-		cancelTaskUC: cancelTaskUC,
-		getStatsUC:   getStatsUC,
-		workerPool:   workerPool,
+		submitTaskUC:  submitTaskUC,
+		getTaskUC:     getTaskUC,
+		listTasksUC:   listTasksUC,
+		cancelTaskUC:  cancelTaskUC,
+		getStatsUC:    getStatsUC,
+		deleteTaskUC:  deleteTaskUC,
+		getArtifactUC: getArtifactUC,
+		getAttemptsUC: getAttemptsUC,
+		retryTaskUC:   retryTaskUC,
+		watchTasksUC:  watchTasksUC,
+		workerPool:    workerPool,
+		urlSigner:     urlSigner,
 	}
 }
 
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	response := HealthResponse{
 		Status:  "healthy",
-		Version: "1.0.0",
+		Version: apiVersion,
 	}
 	respondJSON(w, http.StatusOK, response)
 }
@@ -71,14 +105,115 @@ func (h *Handler) SubmitTask(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	task, err := h.submitTaskUC.Execute(taskType, priority, req.Payload)
+	expiresAt, err := parseExpiry(req.ExpiresAt, req.TTL)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid expiry", err.Error())
+		return
+	}
+
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	opts := usecase.SubmitTaskOptions{
+		Tenant:      tenant,
+		TraceParent: tracing.SpanContextFromContext(r.Context()).TraceParent(),
+		Timeout:     time.Duration(req.TimeoutSeconds) * time.Second,
+		ExpiresAt:   expiresAt,
+
+		RejectIfQueueFull: req.RejectIfQueueFull,
+
+		OnSuccess: req.OnSuccess.ToFollowUp(),
+		OnFailure: req.OnFailure.ToFollowUp(),
+	}
+	if key, ok := apiKeyFromContext(r.Context()); ok {
+		// Follow-ups run as the submitter too.
+		for _, submitted := range append(followUpTypes(opts.OnSuccess, opts.OnFailure), taskType) {
+			if !key.CanSubmit(submitted.String()) {
+				respondError(w, http.StatusForbidden, "Task type not allowed", "this key may not submit "+submitted.String()+" tasks")
+				return
+			}
+		}
+		opts.SubmittedBy = key.ID
+	}
+
+	result, err := h.submitTaskUC.Execute(taskType, priority, req.Payload, opts)
 	if err != nil {
+		var quotaErr *domain.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(quotaErr.RetryAfter.Seconds())))))
+			respondError(w, http.StatusTooManyRequests, "Quota exceeded", err.Error())
+			return
+		}
+		if err == domain.ErrQueueFull {
+			w.Header().Set("Retry-After", strconv.Itoa(int(queueFullRetryAfter.Seconds())))
+			respondError(w, http.StatusTooManyRequests, "Queue is full", "try again later, or submit without reject_if_queue_full to wait in the backlog")
+			return
+		}
+		if err == domain.ErrInvalidTimeout {
+			respondError(w, http.StatusBadRequest, "Invalid timeout", err.Error())
+			return
+		}
+		if err == domain.ErrInvalidExpiry {
+			respondError(w, http.StatusBadRequest, "Invalid expiry", err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidFollowUp) {
+			respondError(w, http.StatusBadRequest, "Invalid follow-up task", err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrDuplicateTask) {
+			respondError(w, http.StatusConflict, "Duplicate task", err.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to submit task", err.Error())
 		return
 	}
 
-	log.Printf("✅ Task submitted: %s (type: %s)", task.ID, task.Type)
-	respondJSON(w, http.StatusCreated, ToTaskResponse(task))
+	logger := logging.ForTask(logging.FromContext(r.Context()), result.Task)
+	if result.Outcome == usecase.SubmitOutcomeMerged {
+		logger.Info("submission merged into duplicate task")
+		respondJSON(w, http.StatusOK, ToSubmitTaskResponse(result))
+		return
+	}
+	logger.Info("task submitted", "priority", result.Task.Priority.String(), "outcome", string(result.Outcome))
+	respondJSON(w, http.StatusCreated, ToSubmitTaskResponse(result))
+}
+
+// followUpTypes lists the task types of followUps and of their own
+// follow-ups.
+func followUpTypes(followUps ...*domain.FollowUp) []domain.TaskType {
+	var types []domain.TaskType
+	for _, followUp := range followUps {
+		if followUp != nil {
+			types = append(types, followUp.Type)
+			types = append(types, followUpTypes(followUp.OnSuccess, followUp.OnFailure)...)
+		}
+	}
+	return types
+}
+
+// parseExpiry turns the expires_at or ttl of a submission into a deadline.
+// It returns the zero time if neither is set.
+func parseExpiry(expiresAt, ttl string) (time.Time, error) {
+	switch {
+	case expiresAt != "" && ttl != "":
+		return time.Time{}, errors.New("set expires_at or ttl, not both")
+	case expiresAt != "":
+		deadline, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return time.Time{}, errors.New("expires_at must be an RFC 3339 time")
+		}
+		return deadline, nil
+	case ttl != "":
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			return time.Time{}, errors.New("ttl must be a positive duration such as 15m")
+		}
+		return time.Now().Add(parsed), nil
+	}
+	return time.Time{}, nil
 }
 
 func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
@@ -90,7 +225,12 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.getTaskUC.Execute(taskID)
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.getTaskUC.Execute(taskID, tenant)
 	if err != nil {
 		if err == domain.ErrTaskNotFound {
 			respondError(w, http.StatusNotFound, "Task not found", "")
@@ -100,12 +240,18 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", versionETag(task.Version))
 	respondJSON(w, http.StatusOK, ToTaskResponse(task))
 }
 
 func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 	statusParam := r.URL.Query().Get("status")
 
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
 	var tasks []*domain.Task
 	var err error
 
@@ -115,9 +261,9 @@ func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "Invalid status", "")
 			return
 		}
-		tasks, err = h.listTasksUC.ExecuteByStatus(status)
+		tasks, err = h.listTasksUC.ExecuteByStatus(status, tenant)
 	} else {
-		tasks, err = h.listTasksUC.Execute()
+		tasks, err = h.listTasksUC.Execute(tenant)
 	}
 
 	if err != nil {
@@ -128,6 +274,33 @@ func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, ToTaskListResponse(tasks))
 }
 
+func (h *Handler) GetTaskAttempts(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimPrefix(r.URL.Path, "/tasks/")
+	taskID = strings.TrimSuffix(taskID, "/attempts")
+
+	if taskID == "" {
+		respondError(w, http.StatusBadRequest, "Task ID is required", "")
+		return
+	}
+
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.getAttemptsUC.Execute(taskID, tenant)
+	if err != nil {
+		if err == domain.ErrTaskNotFound {
+			respondError(w, http.StatusNotFound, "Task not found", "")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve attempts", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ToAttemptListResponse(taskID, attempts))
+}
+
 func (h *Handler) CancelTask(w http.ResponseWriter, r *http.Request) {
 	taskID := strings.TrimPrefix(r.URL.Path, "/tasks/")
 	taskID = strings.TrimSuffix(taskID, "/cancel")
@@ -137,12 +310,40 @@ func (h *Handler) CancelTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.cancelTaskUC.Execute(taskID)
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		version, ok := parseVersionETag(ifMatch)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Invalid If-Match header", "expected a task version ETag such as \"3\"")
+			return
+		}
+		err = h.cancelTaskUC.ExecuteIfVersion(taskID, tenant, version)
+		if err == domain.ErrVersionConflict {
+			respondError(w, http.StatusPreconditionFailed, "Task has been modified", "fetch the task again for its current ETag")
+			return
+		}
+	} else {
+		err = h.cancelTaskUC.Execute(taskID, tenant)
+	}
+
 	if err != nil {
 		if err == domain.ErrTaskNotFound {
 			respondError(w, http.StatusNotFound, "Task not found", "")
 			return
 		}
+		if err == domain.ErrVersionConflict {
+			respondError(w, http.StatusConflict, "Task is being modified", "try again")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			respondError(w, http.StatusConflict, "Task cannot be cancelled", err.Error())
+			return
+		}
 		respondError(w, http.StatusBadRequest, "Failed to cancel task", err.Error())
 		return
 	}
@@ -150,24 +351,206 @@ func (h *Handler) CancelTask(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, SuccessResponse{Message: "Task cancelled successfully"})
 }
 
+// RetryTask puts a failed task back in the queue, even one whose retries
+// are used up.
+func (h *Handler) RetryTask(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.TrimPrefix(r.URL.Path, "/tasks/")
+	taskID = strings.TrimSuffix(taskID, "/retry")
+
+	var timeout time.Duration
+	if timeoutParam := r.URL.Query().Get("timeout"); timeoutParam != "" {
+		parsed, err := time.ParseDuration(timeoutParam)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid timeout", "timeout must be a positive duration")
+			return
+		}
+		timeout = parsed
+	}
+
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.retryTaskUC.Execute(taskID, tenant, timeout)
+	if err != nil {
+		if err == domain.ErrTaskNotFound {
+			respondError(w, http.StatusNotFound, "Task not found", "")
+			return
+		}
+		if err == domain.ErrVersionConflict {
+			respondError(w, http.StatusConflict, "Task is being modified", "try again")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			respondError(w, http.StatusConflict, "Only failed tasks can be retried", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to retry task", err.Error())
+		return
+	}
+
+	logging.ForTask(logging.FromContext(r.Context()), task).Info("task retried")
+	respondJSON(w, http.StatusOK, ToTaskResponse(task))
+}
+
+func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	taskID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+
+	if taskID == "" {
+		respondError(w, http.StatusBadRequest, "Task ID is required", "")
+		return
+	}
+
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.deleteTaskUC.Execute(taskID, tenant)
+	if err != nil {
+		if err == domain.ErrTaskNotFound {
+			respondError(w, http.StatusNotFound, "Task not found", "")
+			return
+		}
+		respondError(w, http.StatusConflict, "Failed to delete task", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Message: "Task deleted successfully"})
+}
+
+func (h *Handler) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	taskID, name := artifactPathParams(r.URL.Path)
+
+	// A valid signed link grants access on its own, whatever the tenant.
+	tenant := ""
+	query := r.URL.Query()
+	if signature := query.Get("signature"); signature != "" {
+		if err := h.urlSigner.Verify(artifactPath(taskID, name), query.Get("expires"), signature); err != nil {
+			respondError(w, http.StatusForbidden, "Invalid download link", err.Error())
+			return
+		}
+	} else {
+		var ok bool
+		if tenant, ok = resolveTenant(w, r); !ok {
+			return
+		}
+	}
+
+	artifact, content, err := h.getArtifactUC.Execute(taskID, name, tenant)
+	if err != nil {
+		if err == domain.ErrTaskNotFound || err == domain.ErrArtifactNotFound {
+			respondError(w, http.StatusNotFound, "Artifact not found", "")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve artifact", err.Error())
+		return
+	}
+	defer content.Close()
+
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(artifact.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Name}))
+	w.Header().Set("ETag", strconv.Quote(artifact.Checksum))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to stream artifact", logging.KeyTaskID, taskID, "artifact", name, "error", err)
+	}
+}
+
+func (h *Handler) CreateArtifactLink(w http.ResponseWriter, r *http.Request) {
+	taskID, name := artifactPathParams(strings.TrimSuffix(r.URL.Path, "/link"))
+
+	ttl := defaultArtifactLinkTTL
+	if ttlParam := r.URL.Query().Get("ttl"); ttlParam != "" {
+		parsed, err := time.ParseDuration(ttlParam)
+		if err != nil || parsed <= 0 || parsed > maxArtifactLinkTTL {
+			respondError(w, http.StatusBadRequest, "Invalid ttl", "ttl must be a duration up to "+maxArtifactLinkTTL.String())
+			return
+		}
+		ttl = parsed
+	}
+
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.getTaskUC.Execute(taskID, tenant)
+	if err != nil {
+		if err == domain.ErrTaskNotFound {
+			respondError(w, http.StatusNotFound, "Artifact not found", "")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve task", err.Error())
+		return
+	}
+
+	if _, ok := task.FindArtifact(name); !ok {
+		respondError(w, http.StatusNotFound, "Artifact not found", "")
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	respondJSON(w, http.StatusOK, ArtifactLinkResponse{
+		URL:       h.urlSigner.Sign(artifactPath(task.ID, name), expiresAt),
+		ExpiresAt: expiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// artifactPathParams extracts the task ID and artifact name from
+// /tasks/{id}/artifacts/{name}.
+func artifactPathParams(path string) (string, string) {
+	rest := strings.TrimPrefix(path, "/tasks/")
+	taskID, name, _ := strings.Cut(rest, "/artifacts/")
+	return taskID, name
+}
+
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.getStatsUC.Execute()
+	tenant, ok := resolveTenant(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.stats(tenant)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to retrieve stats", err.Error())
 		return
 	}
 
-	response := StatsResponse{
-		TotalTasks:      stats.TotalTasks,
-		PendingTasks:    stats.PendingTasks,
-		ProcessingTasks: stats.ProcessingTasks,
-		CompletedTasks:  stats.CompletedTasks,
-		FailedTasks:     stats.FailedTasks,
-		CancelledTasks:  stats.CancelledTasks,
-		QueueSize:       stats.QueueSize,
+	respondJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) stats(tenant string) (*StatsResponse, error) {
+	stats, err := h.getStatsUC.Execute(tenant)
+	if err != nil {
+		return nil, err
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	response := ToStatsResponse(stats)
+	response.Tenant = tenant
+
+	// Callers that see every tenant also get the per-tenant breakdown.
+	if tenant == "" {
+		perTenant, err := h.getStatsUC.ExecutePerTenant()
+		if err != nil {
+			return nil, err
+		}
+
+		response.Tenants = make(map[string]*StatsResponse, len(perTenant))
+		for name, tenantStats := range perTenant {
+			response.Tenants[name] = ToStatsResponse(tenantStats)
+		}
+	}
+
+	return response, nil
 }
 
 func (h *Handler) GetWorkerStatus(w http.ResponseWriter, r *http.Request) {
@@ -175,6 +558,24 @@ func (h *Handler) GetWorkerStatus(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, status)
 }
 
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseVersionETag accepts the ETag returned by GetTask, quoted or not.
+func parseVersionETag(etag string) (int, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if unquoted, err := strconv.Unquote(etag); err == nil {
+		etag = unquoted
+	}
+
+	version, err := strconv.Atoi(etag)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
 func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,327 @@
+package http
+
+import (
+	"encoding/json"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/usecase"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const apiVersion = "1.0.0"
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// apiSpec is the OpenAPI 3.0 document generated from the route table,
+// along with the schemas requests are validated against.
+type apiSpec struct {
+	document    []byte
+	definitions map[string]*jsonschema.Schema
+	// requests holds the JSON body schema of each route by its ServeMux
+	// pattern, method included.
+	requests map[string]*jsonschema.Schema
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+// newAPISpec describes routes. Submitted payloads are described, and
+// validated, per task type with payloadSchemas.
+func newAPISpec(routes []route, payloadSchemas map[domain.TaskType]*jsonschema.Schema) *apiSpec {
+	reflector := jsonschema.NewReflector()
+	reflector.Define(ErrorResponse{})
+	// Sent on the event stream, which the document can only describe as
+	// text.
+	reflector.Define(TaskEventResponse{})
+
+	spec := &apiSpec{
+		definitions: reflector.Definitions,
+		requests:    make(map[string]*jsonschema.Schema),
+	}
+
+	paths := make(map[string]map[string]*openAPIOperation)
+	for _, rt := range routes {
+		if paths[rt.pattern] == nil {
+			paths[rt.pattern] = make(map[string]*openAPIOperation)
+		}
+		paths[rt.pattern][strings.ToLower(rt.method)] = spec.operation(reflector, rt)
+	}
+
+	describeTaskFields(reflector.Definitions, payloadSchemas)
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Task Queue API",
+			"version":     apiVersion,
+			"description": "Submit background tasks, follow their progress and fetch their results. Workers in other processes lease tasks through /worker-api.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": reflector.Definitions,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "An API key, sent as 'Authorization: Bearer <key>'. Not needed when the server runs without API keys.",
+				},
+			},
+		},
+	}
+
+	var err error
+	if spec.document, err = json.MarshalIndent(document, "", "  "); err != nil {
+		panic("http: marshal OpenAPI document: " + err.Error())
+	}
+	return spec
+}
+
+func (s *apiSpec) operation(reflector *jsonschema.Reflector, rt route) *openAPIOperation {
+	op := &openAPIOperation{
+		OperationID: rt.operation,
+		Summary:     rt.summary,
+		Responses:   make(map[string]*openAPIResponse),
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(rt.pattern, -1) {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   jsonschema.String(""),
+		})
+	}
+	for _, p := range rt.params {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:        p.name,
+			In:          p.in,
+			Description: p.description,
+			Required:    p.required,
+			Schema:      p.schema,
+		})
+	}
+	if rt.tenant {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:        tenantHeader,
+			In:          "header",
+			Description: "Tenant to act for; keys bound to a tenant may only name their own",
+			Schema:      jsonschema.String(""),
+		})
+	}
+
+	switch body := rt.request.(type) {
+	case nil:
+	case rawBody:
+		op.RequestBody = &openAPIRequestBody{Required: true, Content: binaryContent(body.contentType)}
+	default:
+		schema := reflector.Reflect(body)
+		// Properties a request body doesn't have are mistakes, such as a
+		// misspelled field, rather than something to ignore.
+		reflector.Close(schema)
+		s.requests[rt.method+" "+rt.pattern] = schema
+		op.RequestBody = &openAPIRequestBody{Required: true, Content: jsonContent(schema)}
+	}
+
+	if rt.scope != "" {
+		op.Description = "Requires an API key with the " + string(rt.scope) + " scope."
+		op.Security = []map[string][]string{{"apiKey": {}}}
+		if rt.signedLinks {
+			op.Description += " A signed link from createArtifactLink works without one."
+			op.Security = append(op.Security, map[string][]string{})
+		}
+	}
+
+	for status, body := range rt.responses {
+		response := &openAPIResponse{Description: http.StatusText(status)}
+		switch body := body.(type) {
+		case nil:
+		case rawBody:
+			response.Content = binaryContent(body.contentType)
+		default:
+			response.Content = jsonContent(reflector.Reflect(body))
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	for _, status := range errorStatuses(rt) {
+		op.Responses[strconv.Itoa(status)] = &openAPIResponse{
+			Description: http.StatusText(status),
+			Content:     jsonContent(jsonschema.Ref("ErrorResponse")),
+		}
+	}
+
+	return op
+}
+
+// errorStatuses lists the error responses a route can return: the ones its
+// kind of route can always return, plus its own.
+func errorStatuses(rt route) []int {
+	statuses := append([]int(nil), rt.errors...)
+	if rt.request != nil || len(rt.params) > 0 || rt.tenant {
+		statuses = append(statuses, http.StatusBadRequest)
+	}
+	if rt.scope != "" {
+		statuses = append(statuses, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError)
+	}
+	if strings.Contains(rt.pattern, "{") {
+		statuses = append(statuses, http.StatusNotFound)
+	}
+	sort.Ints(statuses)
+	return statuses
+}
+
+// describeTaskFields narrows the reflected task DTOs, whose fields are
+// plain strings in Go, to the values the server accepts. SubmitTaskRequest
+// becomes one variant per task type, told apart by its type field, so each
+// carries that type's payload schema.
+func describeTaskFields(definitions map[string]*jsonschema.Schema, payloadSchemas map[domain.TaskType]*jsonschema.Schema) {
+	var types, statuses, priorities, reasons []string
+	for _, taskType := range domain.TaskTypes() {
+		types = append(types, taskType.String())
+	}
+	for _, status := range domain.TaskStatuses() {
+		statuses = append(statuses, status.String())
+	}
+	for _, priority := range domain.TaskPriorities() {
+		priorities = append(priorities, priority.String())
+	}
+	for _, reason := range domain.FailureReasons() {
+		reasons = append(reasons, reason.String())
+	}
+
+	if task := definitions["TaskResponse"]; task != nil {
+		describe(task, "type", jsonschema.Enum("", types...))
+		describe(task, "status", jsonschema.Enum("", statuses...))
+		describe(task, "priority", jsonschema.Enum("", priorities...))
+		describe(task, "failure_reason", jsonschema.Enum("Why the last attempt failed", reasons...))
+		describe(task, "timeout_seconds", jsonschema.Integer("Time limit per attempt"))
+		describe(task, "expires_at", jsonschema.String("When the task expires if it hasn't finished").WithFormat("date-time"))
+		describe(task, "backlogged", jsonschema.Boolean("The task is pending but not yet in the queue, e.g. while it waits for room"))
+		describe(task, "dispatched_at", jsonschema.String("When the pending task was handed to the queue").WithFormat("date-time"))
+		describe(task, "unique_key", jsonschema.String("Identifies the task under its type's uniqueness rule"))
+		describe(task, "parent_id", jsonschema.String("The task this one is a follow-up of"))
+		describe(task, "follow_up_id", jsonschema.String("The follow-up submitted when this task finished"))
+		describe(task, "follow_up_error", jsonschema.String("Why the follow-up due when this task finished couldn't be submitted"))
+	}
+	if followUp := definitions["FollowUpSpec"]; followUp != nil {
+		followUp.Description = "A task to submit when another one finishes. String values in its payload are Go templates that may refer to the finished task as .parent (id, type, status, tenant, payload, result, error, failure_reason), e.g. {{ .parent.result.processed_url }}."
+		describe(followUp, "type", jsonschema.Enum("", types...))
+		describe(followUp, "priority", jsonschema.Enum("Defaults to the priority of the finished task", priorities...))
+	}
+	if dedup := definitions["DeduplicationResponse"]; dedup != nil {
+		describe(dedup, "outcome", jsonschema.Enum("created, merged into duplicate_of, which is the task returned, or replaced duplicate_of, which was cancelled",
+			string(usecase.SubmitOutcomeCreated), string(usecase.SubmitOutcomeMerged), string(usecase.SubmitOutcomeReplaced)))
+	}
+	if attempt := definitions["AttemptResponse"]; attempt != nil {
+		describe(attempt, "failure_reason", jsonschema.Enum("Set on failed attempts", reasons...))
+	}
+	if fail := definitions["FailTaskRequest"]; fail != nil {
+		describe(fail, "reason", jsonschema.Enum("timeout when the task ran out of time, expired when it ran past its expiry",
+			domain.FailureReasonError.String(), domain.FailureReasonTimeout.String(), domain.FailureReasonExpired.String()))
+	}
+	if lease := definitions["LeaseRequest"]; lease != nil {
+		describe(lease, "task_types", jsonschema.Array(jsonschema.Enum("", types...), "Task types this worker can process"))
+	}
+
+	submit := definitions["SubmitTaskRequest"]
+	if submit == nil {
+		return
+	}
+	describe(submit, "timeout_seconds", jsonschema.Integer("Time limit per attempt, capped at the server maximum; defaults to the task type's timeout").WithMinimum(1))
+	describe(submit, "expires_at", jsonschema.String("When the task stops being worth running; a task still pending then expires without running").WithFormat("date-time"))
+	describe(submit, "reject_if_queue_full", jsonschema.Boolean("Fail with 429 and Retry-After when the queue is full, instead of accepting the task into the backlog"))
+	describe(submit, "ttl", jsonschema.String("Alternative to expires_at: how long from now the task may wait and run, as a Go duration such as 15m"))
+
+	oneOf := &jsonschema.Schema{
+		Description:   "A task to run. The payload depends on the task type.",
+		Discriminator: &jsonschema.Discriminator{PropertyName: "type", Mapping: map[string]string{}},
+	}
+	for _, taskType := range domain.TaskTypes() {
+		name := schemaName(taskType.String())
+
+		payload := payloadSchemas[taskType]
+		if payload == nil {
+			payload = jsonschema.Map(&jsonschema.Schema{}, "")
+		}
+		definitions[name+"Payload"] = payload
+
+		variant := submit.Clone()
+		describe(variant, "type", jsonschema.Enum("", taskType.String()))
+		describe(variant, "priority", jsonschema.Enum("", priorities...).WithDefault(domain.GetDefaultPriority().String()))
+		describe(variant, "payload", jsonschema.Ref(name+"Payload"))
+		definitions[name+"TaskRequest"] = variant
+
+		oneOf.OneOf = append(oneOf.OneOf, jsonschema.Ref(name+"TaskRequest"))
+		oneOf.Discriminator.Mapping[taskType.String()] = jsonschema.RefPrefix + name + "TaskRequest"
+	}
+	definitions["SubmitTaskRequest"] = oneOf
+}
+
+// describe replaces the reflected schema of a property of definition with
+// a more precise one. Only properties the DTO has can be described, so the
+// document can't drift from the JSON the server reads and writes.
+func describe(definition *jsonschema.Schema, name string, property *jsonschema.Schema) {
+	reflected, ok := definition.Properties[name]
+	if !ok {
+		panic("http: OpenAPI description of unknown property " + name)
+	}
+	property.Nullable = reflected.Nullable
+	definition.Properties[name] = property
+}
+
+// schemaName turns a task type such as image_processing into
+// ImageProcessing.
+func schemaName(taskType string) string {
+	var name strings.Builder
+	for _, word := range strings.Split(taskType, "_") {
+		if word != "" {
+			name.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return name.String()
+}
+
+func jsonContent(schema *jsonschema.Schema) map[string]*openAPIMediaType {
+	return map[string]*openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+func binaryContent(contentType string) map[string]*openAPIMediaType {
+	return map[string]*openAPIMediaType{contentType: {Schema: jsonschema.String("").WithFormat("binary")}}
+}
+
+func (s *apiSpec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.document)
+}
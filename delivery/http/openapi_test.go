@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/processor"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func testAPISpec(t *testing.T) *apiSpec {
+	t.Helper()
+
+	return newAPISpec(apiRoutes(nil, nil), map[domain.TaskType]*jsonschema.Schema{
+		domain.TaskTypeEmail: processor.NewEmailProcessor().PayloadSchema(),
+	})
+}
+
+// jsonNames returns the JSON property names of the fields of value.
+func jsonNames(value interface{}) []string {
+	var names []string
+	typ := reflect.TypeOf(value)
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func propertyNames(schema *jsonschema.Schema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestAPISpecDescribesTheDTOs(t *testing.T) {
+	spec := testAPISpec(t)
+
+	tests := []struct {
+		definition string
+		dto        interface{}
+	}{
+		{"TaskResponse", TaskResponse{}},
+		{"FollowUpSpec", FollowUpSpec{}},
+		{"FailTaskRequest", FailTaskRequest{}},
+		{"HeartbeatRequest", HeartbeatRequest{}},
+		{"EmailTaskRequest", SubmitTaskRequest{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			definition := spec.definitions[tt.definition]
+			if definition == nil {
+				t.Fatalf("no %s definition", tt.definition)
+			}
+			if got, want := propertyNames(definition), jsonNames(tt.dto); !reflect.DeepEqual(got, want) {
+				t.Errorf("properties = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAPISpecClosesRequestBodies(t *testing.T) {
+	spec := testAPISpec(t)
+
+	if !bytes.Contains(spec.document, []byte(`"additionalProperties": false`)) {
+		t.Error("document has no closed schemas")
+	}
+	if spec.definitions["TaskResponse"].Closed {
+		t.Error("TaskResponse is closed, but clients must accept new response fields")
+	}
+}
+
+func TestValidateSubmitTaskRequest(t *testing.T) {
+	spec := testAPISpec(t)
+	body := spec.requests[http.MethodPost+" /tasks"]
+	if body == nil {
+		t.Fatal("no request body schema for POST /tasks")
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			body: `{"type":"email","payload":{"to":"ops@example.com","subject":"hi"}}`,
+		},
+		{
+			name:    "unknown property",
+			body:    `{"type":"email","payload":{"to":"ops@example.com"},"extra":1}`,
+			wantErr: "extra: is not allowed",
+		},
+		{
+			name:    "invalid email",
+			body:    `{"type":"email","payload":{"to":"not an address"}}`,
+			wantErr: "payload.to: must be a valid email",
+		},
+		{
+			name:    "email with display name",
+			body:    `{"type":"email","payload":{"to":"Ops <ops@example.com>"}}`,
+			wantErr: "payload.to: must be a valid email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document interface{}
+			if err := json.Unmarshal([]byte(tt.body), &document); err != nil {
+				t.Fatal(err)
+			}
+
+			err := body.Validate(document, spec.definitions)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
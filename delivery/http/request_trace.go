@@ -0,0 +1,44 @@
+package http
+
+import (
+	"errors"
+	"go-task-queue-system/infrastructure/tracing"
+	"net/http"
+)
+
+// untracedPaths are polled constantly or, like the event stream, stay open
+// for hours; tracing them would bury the spans that matter. Leased tasks
+// are still traced through the task itself.
+var untracedPaths = map[string]bool{
+	"/health":           true,
+	"/worker-api/lease": true,
+	"/events":           true,
+}
+
+// tracingMiddleware records a server span for every request except polls.
+// It continues the caller's trace when the request has a traceparent
+// header and starts a new one otherwise; handlers find the span in the
+// request context.
+func tracingMiddleware(tracer *tracing.Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if untracedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		parent, _ := tracing.Extract(r.Header)
+		ctx, span := tracer.Start(tracing.ContextWithSpanContext(r.Context(), parent), "HTTP "+r.Method, tracing.SpanKindServer)
+		defer span.Finish()
+
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", recorder.status)
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetError(errors.New(http.StatusText(recorder.status)))
+		}
+	})
+}
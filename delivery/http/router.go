@@ -1,63 +1,57 @@
 package http
 
 import (
-	"log"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/tracing"
 	"net/http"
-	"strings"
 )
 
-func SetupRoutes(handler *Handler) http.Handler {
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/health", handler.Health)
-
-	mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			handler.SubmitTask(w, r)
-		case http.MethodGet:
-			handler.ListTasks(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
+func SetupRoutes(
+	handler *Handler,
+	workerAPI *WorkerAPIHandler,
+	authenticator *Authenticator,
+	tracer *tracing.Tracer,
+	payloadSchemas map[domain.TaskType]*jsonschema.Schema,
+) http.Handler {
+	var spec *apiSpec
+	routes := append(apiRoutes(handler, workerAPI), route{
+		method:    http.MethodGet,
+		pattern:   "/openapi.json",
+		operation: "getOpenAPIDocument",
+		summary:   "This document",
+		responses: map[int]interface{}{http.StatusOK: map[string]interface{}(nil)},
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			spec.ServeHTTP(w, r)
+		},
 	})
+	spec = newAPISpec(routes, payloadSchemas)
 
-	mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/cancel") && r.Method == http.MethodPost {
-			handler.CancelTask(w, r)
-			return
-		}
-
-		if r.Method == http.MethodGet {
-			handler.GetTask(w, r)
-			return
-		}
-
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	})
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.method+" "+rt.pattern, protect(authenticator, rt, spec.validateRequest(rt, rt.handler)))
+	}
 
-	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		handler.GetStats(w, r)
-	})
+	return tracingMiddleware(tracer, loggingMiddleware(mux))
+}
 
-	mux.HandleFunc("/workers/status", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// protect puts the route behind authentication unless it is public.
+func protect(authenticator *Authenticator, rt route, next http.HandlerFunc) http.HandlerFunc {
+	if rt.scope == "" {
+		return next
+	}
+
+	protected := authenticator.Require(rt.scope, next)
+	if !rt.signedLinks {
+		return protected
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Signed links carry their own authorization.
+		if r.URL.Query().Get("signature") != "" {
+			next(w, r)
 			return
 		}
-		handler.GetWorkerStatus(w, r)
-	})
-
-	return loggingMiddleware(mux)
-}
-
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("📥 %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
-	})
+		protected(w, r)
+	}
 }
@@ -0,0 +1,297 @@
+package http
+
+import (
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/auth"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"net/http"
+)
+
+// route describes one endpoint. SetupRoutes registers the routes and the
+// OpenAPI document is generated from the same table, so the two can't
+// drift apart.
+type route struct {
+	method string
+	// pattern is a ServeMux path pattern and doubles as the OpenAPI path.
+	pattern string
+	// operation is the OpenAPI operationId, which client generators turn
+	// into method names.
+	operation string
+	summary   string
+
+	// scope is the API key scope the route requires; empty for public
+	// routes. With signedLinks, a request carrying a signature query
+	// parameter skips authentication and is checked by the handler.
+	scope       auth.Scope
+	signedLinks bool
+
+	// tenant marks routes that act for the tenant in the X-Tenant header.
+	tenant bool
+	params []param
+
+	// request is the DTO decoded from a JSON body, or a rawBody.
+	request   interface{}
+	responses map[int]interface{}
+	// errors lists error statuses beyond the ones every route of its kind
+	// can return; see errorStatuses.
+	errors []int
+
+	handler http.HandlerFunc
+}
+
+// param is a query or header parameter.
+type param struct {
+	name        string
+	in          string
+	description string
+	required    bool
+	schema      *jsonschema.Schema
+}
+
+// rawBody stands in for a request or response body that isn't JSON.
+type rawBody struct {
+	contentType string
+}
+
+// noBody is the response of a status that has no body.
+var noBody interface{}
+
+func apiRoutes(handler *Handler, workerAPI *WorkerAPIHandler) []route {
+	statuses := make([]string, 0, 5)
+	for _, status := range domain.TaskStatuses() {
+		statuses = append(statuses, status.String())
+	}
+
+	return []route{
+		{
+			method:    http.MethodGet,
+			pattern:   "/health",
+			operation: "health",
+			summary:   "Report that the server is up",
+			responses: map[int]interface{}{http.StatusOK: HealthResponse{}},
+			handler:   handler.Health,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/tasks",
+			operation: "submitTask",
+			summary:   "Submit a task",
+			scope:     auth.ScopeSubmit,
+			tenant:    true,
+			request:   SubmitTaskRequest{},
+			responses: map[int]interface{}{
+				http.StatusCreated: TaskResponse{},
+				// A duplicate merged into an existing task.
+				http.StatusOK: TaskResponse{},
+			},
+			errors:  []int{http.StatusConflict},
+			handler: handler.SubmitTask,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/tasks",
+			operation: "listTasks",
+			summary:   "List tasks",
+			scope:     auth.ScopeRead,
+			tenant:    true,
+			params: []param{
+				{name: "status", in: "query", description: "Only list tasks in this status", schema: jsonschema.Enum("", statuses...)},
+			},
+			responses: map[int]interface{}{http.StatusOK: TaskListResponse{}},
+			handler:   handler.ListTasks,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/tasks/{id}",
+			operation: "getTask",
+			summary:   "Get a task; the ETag header carries its version",
+			scope:     auth.ScopeRead,
+			tenant:    true,
+			responses: map[int]interface{}{http.StatusOK: TaskResponse{}},
+			handler:   handler.GetTask,
+		},
+		{
+			method:    http.MethodDelete,
+			pattern:   "/tasks/{id}",
+			operation: "deleteTask",
+			summary:   "Delete a task",
+			scope:     auth.ScopeAdmin,
+			tenant:    true,
+			responses: map[int]interface{}{http.StatusOK: SuccessResponse{}},
+			errors:    []int{http.StatusConflict},
+			handler:   handler.DeleteTask,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/tasks/{id}/cancel",
+			operation: "cancelTask",
+			summary:   "Cancel a pending task",
+			scope:     auth.ScopeCancel,
+			tenant:    true,
+			params: []param{
+				{name: "If-Match", in: "header", description: "Only cancel if the task still has this version ETag", schema: jsonschema.String("")},
+			},
+			responses: map[int]interface{}{http.StatusOK: SuccessResponse{}},
+			errors:    []int{http.StatusConflict, http.StatusPreconditionFailed},
+			handler:   handler.CancelTask,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/tasks/{id}/retry",
+			operation: "retryTask",
+			summary:   "Put a failed task back in the queue, even after its last retry",
+			scope:     auth.ScopeSubmit,
+			tenant:    true,
+			params: []param{
+				{name: "timeout", in: "query", description: "New time limit per attempt, as a Go duration, e.g. for a task that timed out; capped at the server maximum", schema: jsonschema.String("")},
+			},
+			responses: map[int]interface{}{http.StatusOK: TaskResponse{}},
+			errors:    []int{http.StatusConflict},
+			handler:   handler.RetryTask,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/tasks/{id}/attempts",
+			operation: "listTaskAttempts",
+			summary:   "List the attempts made at a task",
+			scope:     auth.ScopeRead,
+			tenant:    true,
+			responses: map[int]interface{}{http.StatusOK: AttemptListResponse{}},
+			handler:   handler.GetTaskAttempts,
+		},
+		{
+			method:      http.MethodGet,
+			pattern:     "/tasks/{id}/artifacts/{name}",
+			operation:   "downloadArtifact",
+			summary:     "Download an artifact, with an API key or a signed link",
+			scope:       auth.ScopeRead,
+			signedLinks: true,
+			tenant:      true,
+			params: []param{
+				{name: "expires", in: "query", description: "Expiry of a signed link (Unix seconds)", schema: jsonschema.String("")},
+				{name: "signature", in: "query", description: "Signature of a signed link", schema: jsonschema.String("")},
+			},
+			responses: map[int]interface{}{http.StatusOK: rawBody{contentType: "application/octet-stream"}},
+			handler:   handler.DownloadArtifact,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/tasks/{id}/artifacts/{name}/link",
+			operation: "createArtifactLink",
+			summary:   "Create a signed download link for an artifact",
+			scope:     auth.ScopeRead,
+			tenant:    true,
+			params: []param{
+				{name: "ttl", in: "query", description: "How long the link is valid, as a Go duration (default 15m, at most 24h)", schema: jsonschema.String("")},
+			},
+			responses: map[int]interface{}{http.StatusOK: ArtifactLinkResponse{}},
+			handler:   handler.CreateArtifactLink,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/stats",
+			operation: "getStats",
+			summary:   "Task counts by status",
+			scope:     auth.ScopeRead,
+			tenant:    true,
+			responses: map[int]interface{}{http.StatusOK: StatsResponse{}},
+			handler:   handler.GetStats,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/events",
+			operation: "streamEvents",
+			summary:   "Live updates as server-sent events: task (a TaskEventResponse), stats and workers",
+			scope:     auth.ScopeRead,
+			tenant:    true,
+			responses: map[int]interface{}{http.StatusOK: rawBody{contentType: "text/event-stream"}},
+			handler:   handler.StreamEvents,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/dashboard",
+			operation: "getDashboard",
+			summary:   "Web dashboard",
+			responses: map[int]interface{}{http.StatusOK: rawBody{contentType: "text/html"}},
+			handler:   handler.Dashboard,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/dashboard/{file}",
+			operation: "getDashboardAsset",
+			summary:   "Script and style sheet of the web dashboard",
+			responses: map[int]interface{}{http.StatusOK: rawBody{contentType: "*/*"}},
+			handler:   handler.DashboardAsset,
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/workers/status",
+			operation: "getWorkerStatus",
+			summary:   "Status of the in-process worker pool",
+			scope:     auth.ScopeRead,
+			responses: map[int]interface{}{http.StatusOK: WorkerStatusResponse{}},
+			handler:   handler.GetWorkerStatus,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/worker-api/lease",
+			operation: "leaseTask",
+			summary:   "Lease the next pending task of the given types",
+			scope:     auth.ScopeWork,
+			tenant:    true,
+			request:   LeaseRequest{},
+			responses: map[int]interface{}{
+				http.StatusOK:        LeaseResponse{},
+				http.StatusNoContent: noBody,
+			},
+			handler: workerAPI.Lease,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/worker-api/tasks/{id}/heartbeat",
+			operation: "renewLease",
+			summary:   "Renew a lease and report progress",
+			scope:     auth.ScopeWork,
+			request:   HeartbeatRequest{},
+			responses: map[int]interface{}{http.StatusOK: HeartbeatResponse{}},
+			errors:    []int{http.StatusConflict},
+			handler:   workerAPI.Heartbeat,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/worker-api/tasks/{id}/complete",
+			operation: "completeLeasedTask",
+			summary:   "Complete a leased task",
+			scope:     auth.ScopeWork,
+			request:   CompleteTaskRequest{},
+			responses: map[int]interface{}{http.StatusOK: TaskResponse{}},
+			errors:    []int{http.StatusConflict},
+			handler:   workerAPI.Complete,
+		},
+		{
+			method:    http.MethodPost,
+			pattern:   "/worker-api/tasks/{id}/fail",
+			operation: "failLeasedTask",
+			summary:   "Fail a leased task",
+			scope:     auth.ScopeWork,
+			request:   FailTaskRequest{},
+			responses: map[int]interface{}{http.StatusOK: TaskResponse{}},
+			errors:    []int{http.StatusConflict},
+			handler:   workerAPI.Fail,
+		},
+		{
+			method:    http.MethodPut,
+			pattern:   "/worker-api/tasks/{id}/artifacts/{name}",
+			operation: "uploadArtifact",
+			summary:   "Upload an artifact of a leased task",
+			scope:     auth.ScopeWork,
+			params: []param{
+				{name: "worker_id", in: "query", description: "The worker holding the lease", required: true, schema: jsonschema.String("")},
+			},
+			request:   rawBody{contentType: "application/octet-stream"},
+			responses: map[int]interface{}{http.StatusCreated: ArtifactResponse{}},
+			errors:    []int{http.StatusConflict},
+			handler:   workerAPI.UploadArtifact,
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrLinkExpired          = errors.New("link has expired")
+	ErrLinkSignatureInvalid = errors.New("link signature is invalid")
+)
+
+// URLSigner creates and checks expiring links, so artifacts can be shared
+// with clients that cannot send credentials.
+type URLSigner struct {
+	key []byte
+}
+
+func NewURLSigner(key []byte) *URLSigner {
+	return &URLSigner{
+		key: key,
+	}
+}
+
+func (s *URLSigner) Sign(path string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(path, expires))
+
+	return path + "?" + query.Encode()
+}
+
+func (s *URLSigner) Verify(path, expires, signature string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrLinkSignatureInvalid
+	}
+
+	actual, _ := hex.DecodeString(s.signature(path, expires))
+	if !hmac.Equal(expected, actual) {
+		return ErrLinkSignatureInvalid
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrLinkSignatureInvalid
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return ErrLinkExpired
+	}
+
+	return nil
+}
+
+func (s *URLSigner) signature(path, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
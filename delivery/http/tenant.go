@@ -0,0 +1,64 @@
+package http
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/auth"
+	"net/http"
+	"strings"
+)
+
+const tenantHeader = "X-Tenant"
+
+var errTenantNotAllowed = errors.New("this API key may not act for another tenant")
+
+// requestTenant works out which tenant a request acts for. Only admin
+// keys, or any caller when authentication is disabled, may pick a tenant
+// with the X-Tenant header; without it they act across all tenants,
+// reported as "". Keys bound to a tenant always act for it, and may only
+// name that tenant in the header. Other keys act for the default tenant
+// and get errTenantNotAllowed if they send the header at all.
+func requestTenant(r *http.Request) (string, error) {
+	header := strings.TrimSpace(r.Header.Get(tenantHeader))
+	if header != "" {
+		if err := domain.ValidateTenant(header); err != nil {
+			return "", err
+		}
+	}
+
+	key, authenticated := apiKeyFromContext(r.Context())
+	if !authenticated {
+		return header, nil
+	}
+
+	if key.Tenant != "" {
+		if header != "" && header != key.Tenant {
+			return "", errTenantNotAllowed
+		}
+		return key.Tenant, nil
+	}
+
+	if key.HasScope(auth.ScopeAdmin) {
+		return header, nil
+	}
+
+	if header != "" {
+		return "", errTenantNotAllowed
+	}
+	return domain.DefaultTenant, nil
+}
+
+// resolveTenant is requestTenant for handlers: it responds with an error
+// and returns false when the tenant can't be used.
+func resolveTenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenant, err := requestTenant(r)
+	if err != nil {
+		if err == errTenantNotAllowed {
+			respondError(w, http.StatusForbidden, "Tenant not allowed", err.Error())
+			return "", false
+		}
+		respondError(w, http.StatusBadRequest, "Invalid tenant", err.Error())
+		return "", false
+	}
+	return tenant, true
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestTenant(t *testing.T) {
+	admin := &auth.APIKey{ID: "ops", Scopes: []auth.Scope{auth.ScopeAdmin}}
+	bound := &auth.APIKey{ID: "acme-app", Tenant: "acme", Scopes: []auth.Scope{auth.ScopeSubmit}}
+	unbound := &auth.APIKey{ID: "app", Scopes: []auth.Scope{auth.ScopeSubmit, auth.ScopeRead}}
+
+	tests := []struct {
+		name    string
+		key     *auth.APIKey
+		header  string
+		want    string
+		wantErr error
+	}{
+		{"unauthenticated without header", nil, "", "", nil},
+		{"unauthenticated picks tenant", nil, "acme", "acme", nil},
+		{"admin without header", admin, "", "", nil},
+		{"admin picks tenant", admin, "acme", "acme", nil},
+		{"bound key", bound, "", "acme", nil},
+		{"bound key names its tenant", bound, "acme", "acme", nil},
+		{"bound key names another tenant", bound, "globex", "", errTenantNotAllowed},
+		{"unbound key", unbound, "", domain.DefaultTenant, nil},
+		{"unbound key picks tenant", unbound, "acme", "", errTenantNotAllowed},
+		{"unbound key names default", unbound, domain.DefaultTenant, "", errTenantNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.header != "" {
+				r.Header.Set(tenantHeader, tt.header)
+			}
+			if tt.key != nil {
+				r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, tt.key))
+			}
+
+			got, err := requestTenant(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("requestTenant() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("requestTenant() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTenantStatus(t *testing.T) {
+	unbound := &auth.APIKey{ID: "app", Scopes: []auth.Scope{auth.ScopeRead}}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"forbidden tenant", "acme", http.StatusForbidden},
+		{"invalid tenant", "not a tenant!", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			r.Header.Set(tenantHeader, tt.header)
+			r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, unbound))
+			rec := httptest.NewRecorder()
+
+			if _, ok := resolveTenant(rec, r); ok {
+				t.Fatal("resolveTenant() accepted the tenant")
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
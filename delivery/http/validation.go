@@ -0,0 +1,69 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"io"
+	"net/http"
+)
+
+// validateRequest rejects requests whose query parameters or JSON body
+// don't match the route's part of the OpenAPI document, before they reach
+// the handler.
+func (s *apiSpec) validateRequest(rt route, next http.HandlerFunc) http.HandlerFunc {
+	body := s.requests[rt.method+" "+rt.pattern]
+
+	query := jsonschema.Object(map[string]*jsonschema.Schema{})
+	for _, p := range rt.params {
+		if p.in != "query" {
+			continue
+		}
+		query.Properties[p.name] = p.schema
+		if p.required {
+			query.Required = append(query.Required, p.name)
+		}
+	}
+
+	if body == nil && len(query.Properties) == 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(query.Properties) > 0 {
+			values := make(map[string]interface{})
+			for name, value := range r.URL.Query() {
+				// Handlers treat an empty parameter as a missing one.
+				if value[0] != "" {
+					values[name] = value[0]
+				}
+			}
+			if err := query.Validate(values, s.definitions); err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid query parameters", err.Error())
+				return
+			}
+		}
+
+		if body != nil {
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+				return
+			}
+
+			var document interface{}
+			if err := json.Unmarshal(data, &document); err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+				return
+			}
+			if err := body.Validate(document, s.definitions); err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(data))
+		}
+
+		next(w, r)
+	}
+}
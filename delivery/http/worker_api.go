@@ -0,0 +1,236 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/tracing"
+	"go-task-queue-system/usecase"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WorkerAPIHandler serves the lease protocol used by workers running in
+// their own processes: a worker leases a task, renews the lease with
+// heartbeats while it works, uploads artifacts and reports the outcome.
+type WorkerAPIHandler struct {
+	leaseTaskUC     *usecase.LeaseTaskUseCase
+	renewLeaseUC    *usecase.RenewLeaseUseCase
+	completeTaskUC  *usecase.CompleteLeasedTaskUseCase
+	failTaskUC      *usecase.FailLeasedTaskUseCase
+	storeArtifactUC *usecase.StoreLeasedArtifactUseCase
+	tracer          *tracing.Tracer
+}
+
+func NewWorkerAPIHandler(
+	leaseTaskUC *usecase.LeaseTaskUseCase,
+	renewLeaseUC *usecase.RenewLeaseUseCase,
+	completeTaskUC *usecase.CompleteLeasedTaskUseCase,
+	failTaskUC *usecase.FailLeasedTaskUseCase,
+	storeArtifactUC *usecase.StoreLeasedArtifactUseCase,
+	tracer *tracing.Tracer,
+) *WorkerAPIHandler {
+	return &WorkerAPIHandler{
+		leaseTaskUC:     leaseTaskUC,
+		renewLeaseUC:    renewLeaseUC,
+		completeTaskUC:  completeTaskUC,
+		failTaskUC:      failTaskUC,
+		storeArtifactUC: storeArtifactUC,
+		tracer:          tracer,
+	}
+}
+
+// Lease responds with a leased task, or 204 No Content if no task of the
+// requested types is pending.
+func (h *WorkerAPIHandler) Lease(w http.ResponseWriter, r *http.Request) {
+	var req LeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if req.WorkerID == "" {
+		respondError(w, http.StatusBadRequest, "worker_id is required", "")
+		return
+	}
+
+	if len(req.TaskTypes) == 0 {
+		respondError(w, http.StatusBadRequest, "task_types is required", "")
+		return
+	}
+
+	taskTypes := make([]domain.TaskType, 0, len(req.TaskTypes))
+	for _, name := range req.TaskTypes {
+		taskType := domain.TaskType(name)
+		if !taskType.IsValid() {
+			respondError(w, http.StatusBadRequest, "Invalid task type", name)
+			return
+		}
+		taskTypes = append(taskTypes, taskType)
+	}
+
+	tenant, ok := workerTenant(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.leaseTaskUC.Execute(leaseHolder(r, req.WorkerID), taskTypes, tenant, seconds(req.LeaseSeconds))
+	if err != nil {
+		if err == domain.ErrNoTaskAvailable {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to lease task", err.Error())
+		return
+	}
+
+	// The server knows when the task was queued, the remote worker traces
+	// its execution.
+	h.tracer.RecordQueueWait(task)
+
+	respondJSON(w, http.StatusOK, LeaseResponse{
+		Task:           ToTaskResponse(task),
+		LeaseExpiresAt: task.LeaseExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *WorkerAPIHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	taskID := leasedTaskID(r.URL.Path, "/heartbeat")
+
+	var req HeartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	var progress *domain.TaskProgress
+	if req.Progress != nil {
+		progress = &domain.TaskProgress{
+			Percent: req.Progress.Percent,
+			Stage:   req.Progress.Stage,
+			Message: req.Progress.Message,
+		}
+	}
+
+	task, err := h.renewLeaseUC.Execute(taskID, leaseHolder(r, req.WorkerID), seconds(req.LeaseSeconds), progress)
+	if err != nil {
+		respondLeaseError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, HeartbeatResponse{
+		LeaseExpiresAt: task.LeaseExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *WorkerAPIHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	taskID := leasedTaskID(r.URL.Path, "/complete")
+
+	var req CompleteTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	task, err := h.completeTaskUC.Execute(taskID, leaseHolder(r, req.WorkerID), req.Result)
+	if err != nil {
+		respondLeaseError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ToTaskResponse(task))
+}
+
+func (h *WorkerAPIHandler) Fail(w http.ResponseWriter, r *http.Request) {
+	taskID := leasedTaskID(r.URL.Path, "/fail")
+
+	var req FailTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	task, err := h.failTaskUC.Execute(taskID, leaseHolder(r, req.WorkerID), req.Error, domain.FailureReason(req.Reason), req.Permanent, req.Result)
+	if err != nil {
+		respondLeaseError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ToTaskResponse(task))
+}
+
+// UploadArtifact stores the request body as an artifact of a leased task.
+// The worker is identified by the worker_id query parameter.
+func (h *WorkerAPIHandler) UploadArtifact(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/worker-api/tasks/")
+	taskID, name, _ := strings.Cut(rest, "/artifacts/")
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	workerID := leaseHolder(r, r.URL.Query().Get("worker_id"))
+	artifact, err := h.storeArtifactUC.Execute(taskID, workerID, name, contentType, r.Body)
+	if err != nil {
+		if err == domain.ErrInvalidArtifactName {
+			respondError(w, http.StatusBadRequest, "Invalid artifact name", name)
+			return
+		}
+		respondLeaseError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, ToArtifactResponse(taskID, *artifact))
+}
+
+func respondLeaseError(w http.ResponseWriter, err error) {
+	switch {
+	case err == domain.ErrTaskNotFound:
+		respondError(w, http.StatusNotFound, "Task not found", "")
+	case err == domain.ErrLeaseNotHeld:
+		respondError(w, http.StatusConflict, "Lease lost", "stop working on this task")
+	case err == domain.ErrVersionConflict:
+		respondError(w, http.StatusConflict, "Task is being modified", "try again")
+	case errors.Is(err, domain.ErrInvalidTransition):
+		respondError(w, http.StatusConflict, "Task cannot be updated", err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to update task", err.Error())
+	}
+}
+
+func leasedTaskID(path, action string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(path, "/worker-api/tasks/"), action)
+}
+
+// leaseHolder scopes the worker ID to the API key, so one key can't renew
+// or finish tasks leased through another.
+func leaseHolder(r *http.Request, workerID string) string {
+	if key, ok := apiKeyFromContext(r.Context()); ok {
+		return key.ID + "/" + workerID
+	}
+	return workerID
+}
+
+// workerTenant is the tenant a worker leases tasks from. Unlike API
+// clients, workers without a tenant-bound key serve every tenant unless
+// they ask for one with the X-Tenant header.
+func workerTenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if key, ok := apiKeyFromContext(r.Context()); ok && key.Tenant != "" {
+		return resolveTenant(w, r)
+	}
+
+	tenant := strings.TrimSpace(r.Header.Get(tenantHeader))
+	if tenant != "" {
+		if err := domain.ValidateTenant(tenant); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid tenant", err.Error())
+			return "", false
+		}
+	}
+	return tenant, true
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
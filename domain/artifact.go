@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrArtifactNotFound    = errors.New("artifact not found")
+	ErrInvalidArtifactName = errors.New("invalid artifact name")
+)
+
+type Artifact struct {
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ValidateArtifactName(name string) error {
+	if name == "" || name == "." || name == ".." || len(name) > 255 {
+		return ErrInvalidArtifactName
+	}
+
+	if strings.ContainsAny(name, "/\\\x00") {
+		return ErrInvalidArtifactName
+	}
+
+	return nil
+}
+
+func ArtifactKey(taskID, name string) string {
+	return taskID + "/" + name
+}
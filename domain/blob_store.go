@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the bytes of task artifacts. Keys are slash separated,
+// e.g. "<task id>/<artifact name>".
+type BlobStore interface {
+	Put(key string, r io.Reader) (int64, error)
+
+	Get(key string) (io.ReadCloser, error)
+
+	Delete(key string) error
+
+	DeletePrefix(prefix string) error
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidTaskType = errors.New("invalid task type")
@@ -15,5 +18,78 @@ var (
 
 	ErrTaskAlreadyCompleted = errors.New("task is already completed")
 
+	ErrTaskAlreadyCancelled = errors.New("task is already cancelled")
+
+	ErrTaskAlreadyExpired = errors.New("task has expired")
+
+	ErrInvalidTransition = errors.New("invalid task status transition")
+
 	ErrEmptyPayload = errors.New("task payload cannot be empty")
+
+	ErrInvalidTimeout = errors.New("timeout must be positive")
+
+	ErrTaskTimedOut = errors.New("task timed out")
+
+	ErrTaskExpired = errors.New("task expired")
+
+	ErrInvalidExpiry = errors.New("expiry must be in the future")
+
+	ErrQueueFull = errors.New("queue is full")
+
+	ErrDuplicateTask = errors.New("duplicate task")
+
+	ErrInvalidFollowUp = errors.New("invalid follow-up task")
+
+	ErrLeaseExpired = errors.New("lease expired")
 )
+
+// TransitionError is returned when a task is asked to move to a status that
+// is not reachable from its current one. It matches ErrInvalidTransition and,
+// where one applies, the more specific error for the current status.
+type TransitionError struct {
+	From TaskStatus
+	To   TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move task from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+func (e *TransitionError) Unwrap() error {
+	switch e.From {
+	case TaskStatusCompleted:
+		return ErrTaskAlreadyCompleted
+	case TaskStatusCancelled:
+		return ErrTaskAlreadyCancelled
+	case TaskStatusExpired:
+		return ErrTaskAlreadyExpired
+	case TaskStatusProcessing:
+		if e.To == TaskStatusProcessing {
+			return ErrTaskAlreadyProcessing
+		}
+	case TaskStatusFailed:
+		if e.To == TaskStatusProcessing {
+			return ErrTaskCannotBeRetried
+		}
+	}
+	return nil
+}
+
+// DuplicateTaskError is returned when a submission duplicates an existing
+// task under its type's uniqueness rule. It matches ErrDuplicateTask.
+type DuplicateTaskError struct {
+	TaskID    string
+	UniqueKey string
+}
+
+func (e *DuplicateTaskError) Error() string {
+	return fmt.Sprintf("duplicate of task %s (%s)", e.TaskID, e.UniqueKey)
+}
+
+func (e *DuplicateTaskError) Unwrap() error {
+	return ErrDuplicateTask
+}
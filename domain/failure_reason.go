@@ -0,0 +1,38 @@
+package domain
+
+import "errors"
+
+// FailureReason tells apart the ways an attempt can fail, so that, say, a
+// task that ran out of time can be retried with a longer timeout instead of
+// being treated like one that hit a bug.
+type FailureReason string
+
+const (
+	FailureReasonError        FailureReason = "error"
+	FailureReasonTimeout      FailureReason = "timeout"
+	FailureReasonLeaseExpired FailureReason = "lease_expired"
+	FailureReasonExpired      FailureReason = "expired"
+)
+
+// FailureReasons returns every failure reason.
+func FailureReasons() []FailureReason {
+	return []FailureReason{FailureReasonError, FailureReasonTimeout, FailureReasonLeaseExpired, FailureReasonExpired}
+}
+
+func (r FailureReason) String() string {
+	return string(r)
+}
+
+// FailureReasonOf classifies the error an attempt failed with.
+func FailureReasonOf(err error) FailureReason {
+	switch {
+	case errors.Is(err, ErrTaskTimedOut):
+		return FailureReasonTimeout
+	case errors.Is(err, ErrLeaseExpired):
+		return FailureReasonLeaseExpired
+	case errors.Is(err, ErrTaskExpired):
+		return FailureReasonExpired
+	default:
+		return FailureReasonError
+	}
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/google/uuid"
+)
+
+// FollowUp is a task to submit when another one finishes. String values in
+// its payload are templates, rendered with the finished task as .parent,
+// e.g. {{ .parent.result.processed_url }}. A follow-up can have follow-ups
+// of its own, whose templates see it as their parent.
+type FollowUp struct {
+	Type      TaskType               `json:"type"`
+	Priority  TaskPriority           `json:"priority,omitempty"`
+	Payload   map[string]interface{} `json:"payload"`
+	OnSuccess *FollowUp              `json:"on_success,omitempty"`
+	OnFailure *FollowUp              `json:"on_failure,omitempty"`
+}
+
+// Validate checks the follow-up and its own follow-ups, templates included,
+// before anything runs.
+func (f *FollowUp) Validate() error {
+	if !f.Type.IsValid() {
+		return fmt.Errorf("%w: %w", ErrInvalidFollowUp, ErrInvalidTaskType)
+	}
+	if f.Priority != "" && !f.Priority.IsValid() {
+		return fmt.Errorf("%w: %w", ErrInvalidFollowUp, ErrInvalidTaskPriority)
+	}
+	if len(f.Payload) == 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidFollowUp, ErrEmptyPayload)
+	}
+	if _, err := renderValue(f.Payload, nil, false); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFollowUp, err)
+	}
+
+	for _, next := range []*FollowUp{f.OnSuccess, f.OnFailure} {
+		if next != nil {
+			if err := next.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderPayload returns the payload of the follow-up of parent with its
+// templates filled in. Referring to something parent doesn't have, such as
+// a result field it didn't produce, is an error.
+func (f *FollowUp) RenderPayload(parent *Task) (map[string]interface{}, error) {
+	data := map[string]interface{}{
+		"parent": map[string]interface{}{
+			"id":             parent.ID,
+			"type":           parent.Type.String(),
+			"status":         parent.Status.String(),
+			"tenant":         parent.Tenant,
+			"payload":        parent.Payload,
+			"result":         parent.Result,
+			"error":          parent.Error,
+			"failure_reason": parent.FailureReason.String(),
+		},
+	}
+
+	rendered, err := renderValue(f.Payload, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.(map[string]interface{}), nil
+}
+
+// renderValue renders the strings in a JSON value as templates, or only
+// parses them unless execute is set.
+func renderValue(value interface{}, data map[string]interface{}, execute bool) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		tmpl, err := template.New("payload").Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		if !execute {
+			return value, nil
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, err
+		}
+		return out.String(), nil
+
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			var err error
+			if rendered[key], err = renderValue(item, data, execute); err != nil {
+				return nil, err
+			}
+		}
+		return rendered, nil
+
+	case []interface{}:
+		rendered := make([]interface{}, len(value))
+		for i, item := range value {
+			var err error
+			if rendered[i], err = renderValue(item, data, execute); err != nil {
+				return nil, err
+			}
+		}
+		return rendered, nil
+
+	default:
+		return value, nil
+	}
+}
+
+// DueFollowUp returns the follow-up the task is due to start: OnSuccess
+// once it has completed, OnFailure once it has expired or failed for good,
+// with no retries left. A task starts at most one follow-up, so retrying a
+// failed task whose OnFailure already started doesn't start another.
+func (t *Task) DueFollowUp() *FollowUp {
+	if t.FollowUpID != "" || t.FollowUpError != "" {
+		return nil
+	}
+
+	switch {
+	case t.Status == TaskStatusCompleted:
+		return t.OnSuccess
+	case t.Status == TaskStatusExpired, t.IsInDeadLetterQueue():
+		return t.OnFailure
+	}
+	return nil
+}
+
+// FollowUpTaskID is the ID the task's follow-up gets. It is derived from
+// the task's own ID, so starting the follow-up twice can't create two.
+func (t *Task) FollowUpTaskID() string {
+	namespace, err := uuid.Parse(t.ID)
+	if err != nil {
+		namespace = uuid.NameSpaceURL
+	}
+	return uuid.NewSHA1(namespace, []byte("follow-up/"+t.ID)).String()
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFollowUpRenderPayload(t *testing.T) {
+	parent := taskIn(t, TaskStatusProcessing)
+	parent.Tenant = "acme"
+	if err := parent.MarkAsCompleted(map[string]interface{}{"processed_url": "/data/images/out.png"}); err != nil {
+		t.Fatal(err)
+	}
+
+	followUp := &FollowUp{
+		Type: TaskTypeEmail,
+		Payload: map[string]interface{}{
+			"to":          "ops@example.com",
+			"subject":     "{{ .parent.type }} {{ .parent.status }}",
+			"attachments": []interface{}{"{{ .parent.result.processed_url }}", 3},
+			"meta":        map[string]interface{}{"parent": "{{ .parent.id }}", "urgent": true},
+		},
+	}
+
+	payload, err := followUp.RenderPayload(parent)
+	if err != nil {
+		t.Fatalf("RenderPayload() error = %v", err)
+	}
+	if got := payload["subject"]; got != "email completed" {
+		t.Errorf("subject = %v", got)
+	}
+	attachments := payload["attachments"].([]interface{})
+	if attachments[0] != "/data/images/out.png" || attachments[1] != 3 {
+		t.Errorf("attachments = %v", attachments)
+	}
+	meta := payload["meta"].(map[string]interface{})
+	if meta["parent"] != parent.ID || meta["urgent"] != true {
+		t.Errorf("meta = %v", meta)
+	}
+
+	// The follow-up's own payload is left as it was.
+	if followUp.Payload["subject"] != "{{ .parent.type }} {{ .parent.status }}" {
+		t.Error("RenderPayload() changed the template")
+	}
+}
+
+func TestFollowUpRenderPayloadMissingField(t *testing.T) {
+	parent := taskIn(t, TaskStatusFailed)
+	followUp := &FollowUp{Type: TaskTypeEmail, Payload: map[string]interface{}{"to": "{{ .parent.result.owner }}"}}
+
+	if _, err := followUp.RenderPayload(parent); err == nil {
+		t.Error("RenderPayload() of a result field the parent doesn't have succeeded")
+	}
+}
+
+func TestFollowUpValidate(t *testing.T) {
+	valid := FollowUp{Type: TaskTypeEmail, Payload: map[string]interface{}{"to": "{{ .parent.id }}"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		followUp FollowUp
+	}{
+		{"unknown type", FollowUp{Type: "fax", Payload: map[string]interface{}{"to": "x"}}},
+		{"unknown priority", FollowUp{Type: TaskTypeEmail, Priority: "urgent", Payload: map[string]interface{}{"to": "x"}}},
+		{"empty payload", FollowUp{Type: TaskTypeEmail}},
+		{"broken template", FollowUp{Type: TaskTypeEmail, Payload: map[string]interface{}{"to": "{{ .parent.id"}}},
+		{"broken nested follow-up", FollowUp{Type: TaskTypeEmail, Payload: map[string]interface{}{"to": "x"}, OnFailure: &FollowUp{Type: "fax"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.followUp.Validate(); !errors.Is(err, ErrInvalidFollowUp) {
+				t.Errorf("Validate() error = %v, want ErrInvalidFollowUp", err)
+			}
+		})
+	}
+}
+
+func TestTaskDueFollowUp(t *testing.T) {
+	onSuccess := &FollowUp{Type: TaskTypeEmail}
+	onFailure := &FollowUp{Type: TaskTypeCommand}
+
+	tests := []struct {
+		status TaskStatus
+		want   *FollowUp
+	}{
+		{TaskStatusPending, nil},
+		{TaskStatusProcessing, nil},
+		{TaskStatusCompleted, onSuccess},
+		{TaskStatusFailed, nil},
+		{TaskStatusExpired, onFailure},
+		{TaskStatusCancelled, nil},
+	}
+	for _, tt := range tests {
+		task := taskIn(t, tt.status)
+		task.OnSuccess, task.OnFailure = onSuccess, onFailure
+		if got := task.DueFollowUp(); got != tt.want {
+			t.Errorf("%s: DueFollowUp() = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+
+	// A failed task with retries left goes again before giving up.
+	deadLetter := taskIn(t, TaskStatusFailed)
+	deadLetter.OnFailure = onFailure
+	deadLetter.RetryCount = deadLetter.MaxRetries
+	if got := deadLetter.DueFollowUp(); got != onFailure {
+		t.Errorf("dead-lettered task: DueFollowUp() = %v, want on_failure", got)
+	}
+
+	started := taskIn(t, TaskStatusCompleted)
+	started.OnSuccess = onSuccess
+	started.FollowUpID = "started"
+	if started.DueFollowUp() != nil {
+		t.Error("follow-up is due again after it started")
+	}
+}
+
+func TestTaskFollowUpTaskID(t *testing.T) {
+	task, other := newTestTask(t), newTestTask(t)
+
+	id := task.FollowUpTaskID()
+	if id != task.FollowUpTaskID() {
+		t.Error("FollowUpTaskID() isn't deterministic")
+	}
+	if id == task.ID || id == other.FollowUpTaskID() {
+		t.Errorf("FollowUpTaskID() = %s isn't unique to its task", id)
+	}
+}
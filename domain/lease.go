@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNoTaskAvailable = errors.New("no task available")
+
+	ErrLeaseNotHeld = errors.New("task is not leased by this worker")
+)
+
+// Lease hands the task to a remote worker until the given time. The worker
+// has to renew the lease before it runs out, or the task is taken back.
+func (t *Task) Lease(workerID string, until time.Time) error {
+	if err := t.MarkAsProcessing(); err != nil {
+		return err
+	}
+	t.LeasedBy = workerID
+	t.LeaseExpiresAt = &until
+	return nil
+}
+
+func (t *Task) RenewLease(workerID string, until time.Time) error {
+	if !t.HoldsLease(workerID) {
+		return ErrLeaseNotHeld
+	}
+	t.LeaseExpiresAt = &until
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// HoldsLease reports whether workerID currently holds the task.
+func (t *Task) HoldsLease(workerID string) bool {
+	return t.Status == TaskStatusProcessing && t.LeasedBy != "" && t.LeasedBy == workerID
+}
+
+func (t *Task) LeaseExpired(now time.Time) bool {
+	return t.Status == TaskStatusProcessing && t.LeaseExpiresAt != nil && now.After(*t.LeaseExpiresAt)
+}
@@ -5,11 +5,17 @@ import "errors"
 var (
 	ErrTaskNotFound      = errors.New("task not found")
 	ErrTaskAlreadyExists = errors.New("task already exists")
+	ErrVersionConflict   = errors.New("task was modified concurrently")
 )
 
 type TaskRepository interface {
 	Save(task *Task) error
 
+	// Update stores the task only if its Version matches the stored one,
+	// and returns ErrVersionConflict otherwise. On success the task's
+	// Version is incremented to the newly stored value. Dispatch claims and
+	// worker leases rely on this: of the callers that update the same
+	// version of a task, only the first succeeds.
 	Update(task *Task) error
 
 	FindByID(id string) (*Task, error)
@@ -18,9 +24,22 @@ type TaskRepository interface {
 
 	FindByStatus(status TaskStatus) ([]*Task, error)
 
-	Delete(id string) error
+	// Delete removes the task and its attempts only if its Version matches
+	// the stored one, and returns ErrVersionConflict otherwise, so a task
+	// isn't deleted from under a writer that changed it meanwhile.
+	Delete(task *Task) error
 
 	Count() (int, error)
 
 	CountByStatus(status TaskStatus) (int, error)
+
+	CountByTenantAndStatus(tenant string, status TaskStatus) (int, error)
+
+	// FindByUniqueKey returns the tenant's tasks with the given UniqueKey,
+	// whatever their status.
+	FindByUniqueKey(tenant, uniqueKey string) ([]*Task, error)
+
+	SaveAttempt(attempt *TaskAttempt) error
+
+	FindAttemptsByTaskID(taskID string) ([]*TaskAttempt, error)
 }
@@ -0,0 +1,9 @@
+package domain
+
+import "time"
+
+type StatusTransition struct {
+	From TaskStatus `json:"from"`
+	To   TaskStatus `json:"to"`
+	At   time.Time  `json:"at"`
+}
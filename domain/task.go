@@ -15,12 +15,60 @@ type Task struct {
 	Payload     map[string]interface{} `json:"payload"`
 	Result      map[string]interface{} `json:"result,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	Artifacts   []Artifact             `json:"artifacts,omitempty"`
+	Progress    *TaskProgress          `json:"progress,omitempty"`
 	MaxRetries  int                    `json:"max_retries"`
 	RetryCount  int                    `json:"retry_count"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	Transitions []StatusTransition     `json:"transitions,omitempty"`
+	Version     int                    `json:"version"`
+	SubmittedBy string                 `json:"submitted_by,omitempty"`
+	Tenant      string                 `json:"tenant"`
+
+	// FailureReason classifies Error.
+	FailureReason FailureReason `json:"failure_reason,omitempty"`
+
+	// Timeout limits how long each attempt may run. Zero leaves it to the
+	// worker.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// DispatchedAt is set when a pending task is handed to the queue.
+	// Pending tasks without it wait in the repository, the source of
+	// truth, until they are dispatched; see usecase.DispatchTasksUseCase.
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty"`
+
+	// DispatchedBy identifies the dispatcher that set DispatchedAt.
+	DispatchedBy string `json:"dispatched_by,omitempty"`
+
+	// UniqueKey identifies the task under its type's uniqueness rule; see
+	// UniquenessRule.
+	UniqueKey string `json:"unique_key,omitempty"`
+
+	// OnSuccess and OnFailure are submitted when the task finishes; see
+	// FollowUp. ParentID links a follow-up to the task that started it,
+	// FollowUpID the other way. FollowUpError says why a due follow-up
+	// couldn't be started.
+	OnSuccess     *FollowUp `json:"on_success,omitempty"`
+	OnFailure     *FollowUp `json:"on_failure,omitempty"`
+	ParentID      string    `json:"parent_id,omitempty"`
+	FollowUpID    string    `json:"follow_up_id,omitempty"`
+	FollowUpError string    `json:"follow_up_error,omitempty"`
+
+	// ExpiresAt is when the task stops being worth running. A task that
+	// hasn't finished by then expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// TraceParent is the W3C traceparent of the request that submitted the
+	// task; work done for the task is traced as its children.
+	TraceParent string `json:"trace_parent,omitempty"`
+
+	// LeasedBy and LeaseExpiresAt are set while a remote worker holds the
+	// task; see Lease.
+	LeasedBy       string     `json:"leased_by,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
 }
 
 func NewTask(taskType TaskType, priority TaskPriority, payload map[string]interface{}) (*Task, error) {
@@ -44,6 +92,7 @@ func NewTask(taskType TaskType, priority TaskPriority, payload map[string]interf
 		Status:     TaskStatusPending,
 		Priority:   priority,
 		Payload:    payload,
+		Tenant:     DefaultTenant,
 		MaxRetries: 3,
 		RetryCount: 0,
 		CreatedAt:  now,
@@ -51,34 +100,206 @@ func NewTask(taskType TaskType, priority TaskPriority, payload map[string]interf
 	}, nil
 }
 
-func (t *Task) MarkAsProcessing() {
-	t.Status = TaskStatusProcessing
+// VisibleTo reports whether a caller acting for tenant may see the task. An
+// empty tenant stands for a caller that may see every tenant.
+func (t *Task) VisibleTo(tenant string) bool {
+	return tenant == "" || t.Tenant == tenant
+}
+
+// PendingSince returns when the task last became pending: when it was
+// created or, after a retry, when it was put back in line.
+func (t *Task) PendingSince() time.Time {
+	for i := len(t.Transitions) - 1; i >= 0; i-- {
+		if t.Transitions[i].To == TaskStatusPending {
+			return t.Transitions[i].At
+		}
+	}
+	return t.CreatedAt
+}
+
+// FirstStartedAt returns when the first attempt started, or nil if the task
+// never ran. Unlike StartedAt it isn't moved by retries.
+func (t *Task) FirstStartedAt() *time.Time {
+	for _, transition := range t.Transitions {
+		if transition.To == TaskStatusProcessing {
+			at := transition.At
+			return &at
+		}
+	}
+	return t.StartedAt
+}
+
+func (t *Task) MarkAsProcessing() error {
 	now := time.Now()
+	if err := t.transitionTo(TaskStatusProcessing, now); err != nil {
+		return err
+	}
 	t.StartedAt = &now
-	t.UpdatedAt = now
+	return nil
 }
 
-func (t *Task) MarkAsCompleted(result map[string]interface{}) {
-	t.Status = TaskStatusCompleted
-	t.Result = result
+func (t *Task) MarkAsCompleted(result map[string]interface{}) error {
 	now := time.Now()
+	if err := t.transitionTo(TaskStatusCompleted, now); err != nil {
+		return err
+	}
+	t.Result = result
+	if t.Progress != nil {
+		t.Progress = &TaskProgress{Percent: 100, Stage: "completed", UpdatedAt: now}
+	}
 	t.CompletedAt = &now
-	t.UpdatedAt = now
+	return nil
 }
 
-func (t *Task) MarkAsFailed(err error) {
-	t.Status = TaskStatusFailed
+func (t *Task) MarkAsFailed(err error) error {
+	if transitionErr := t.transitionTo(TaskStatusFailed, time.Now()); transitionErr != nil {
+		return transitionErr
+	}
 	if err != nil {
 		t.Error = err.Error()
 	}
-	t.UpdatedAt = time.Now()
+	t.FailureReason = FailureReasonOf(err)
+	return nil
+}
+
+// MarkAsPermanentlyFailed fails the task and uses up its remaining retries,
+// for errors that would fail the same way on every attempt.
+func (t *Task) MarkAsPermanentlyFailed(err error) error {
+	if transitionErr := t.MarkAsFailed(err); transitionErr != nil {
+		return transitionErr
+	}
+	t.RetryCount = t.MaxRetries
+	return nil
+}
+
+// MarkAttemptFailed records a failed attempt that may succeed if tried
+// again. While the task has retries left it goes straight back to pending,
+// otherwise it stays failed in the dead letter queue.
+func (t *Task) MarkAttemptFailed(err error) error {
+	if transitionErr := t.MarkAsFailed(err); transitionErr != nil {
+		return transitionErr
+	}
+	t.IncrementRetry()
+	if t.ShouldRetry() {
+		return t.MarkAsPending()
+	}
+	return nil
 }
 
-func (t *Task) MarkAsCancelled() {
-	t.Status = TaskStatusCancelled
+// MarkAsPending puts a failed task back in line for another attempt.
+func (t *Task) MarkAsPending() error {
+	if t.Status == TaskStatusFailed && !t.CanRetry() {
+		return ErrTaskCannotBeRetried
+	}
+	return t.transitionTo(TaskStatusPending, time.Now())
+}
+
+// RetryManually puts a failed task back in line at a user's request. A
+// task that used up its retries gets one more attempt.
+func (t *Task) RetryManually() error {
+	if t.Status != TaskStatusFailed {
+		return &TransitionError{From: t.Status, To: TaskStatusPending}
+	}
+	if !t.CanRetry() {
+		t.MaxRetries = t.RetryCount + 1
+	}
+	return t.transitionTo(TaskStatusPending, time.Now())
+}
+
+func (t *Task) MarkAsCancelled() error {
+	return t.transitionTo(TaskStatusCancelled, time.Now())
+}
+
+// AwaitingDispatch reports whether the task is pending but hasn't been
+// handed to the queue.
+func (t *Task) AwaitingDispatch() bool {
+	return t.Status == TaskStatusPending && t.DispatchedAt == nil
+}
+
+// Expired reports whether the task's deadline has passed at now.
+func (t *Task) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// MarkAsExpired ends a task whose deadline passed before it could finish,
+// without further attempts. err is what a running attempt failed with,
+// if there was one.
+func (t *Task) MarkAsExpired(err error) error {
+	if transitionErr := t.transitionTo(TaskStatusExpired, time.Now()); transitionErr != nil {
+		return transitionErr
+	}
+	if err != nil {
+		t.Error = err.Error()
+		t.FailureReason = FailureReasonExpired
+	}
+	return nil
+}
+
+// transitionTo moves the task to next if the state machine allows it and
+// records the change in the task's status history.
+func (t *Task) transitionTo(next TaskStatus, at time.Time) error {
+	if !t.Status.CanTransitionTo(next) {
+		return &TransitionError{From: t.Status, To: next}
+	}
+
+	t.Transitions = append(t.Transitions, StatusTransition{
+		From: t.Status,
+		To:   next,
+		At:   at,
+	})
+	t.Status = next
+	t.UpdatedAt = at
+
+	if next != TaskStatusProcessing {
+		t.LeasedBy = ""
+		t.LeaseExpiresAt = nil
+	}
+	if next == TaskStatusPending {
+		// Back in line: the task has to be dispatched again.
+		t.DispatchedAt = nil
+		t.DispatchedBy = ""
+	}
+	return nil
+}
+
+// UpdateProgress records how far processing has got. Percent is clamped to
+// 0-100.
+func (t *Task) UpdateProgress(percent int, stage, message string) {
+	percent = max(0, min(100, percent))
+	now := time.Now()
+	t.Progress = &TaskProgress{
+		Percent:   percent,
+		Stage:     stage,
+		Message:   message,
+		UpdatedAt: now,
+	}
+	t.UpdatedAt = now
+}
+
+// AddArtifact records an artifact on the task, replacing any earlier
+// artifact with the same name.
+func (t *Task) AddArtifact(artifact Artifact) {
+	for i, existing := range t.Artifacts {
+		if existing.Name == artifact.Name {
+			t.Artifacts[i] = artifact
+			t.UpdatedAt = time.Now()
+			return
+		}
+	}
+
+	t.Artifacts = append(t.Artifacts, artifact)
 	t.UpdatedAt = time.Now()
 }
 
+func (t *Task) FindArtifact(name string) (*Artifact, bool) {
+	for i := range t.Artifacts {
+		if t.Artifacts[i].Name == name {
+			return &t.Artifacts[i], true
+		}
+	}
+	return nil, false
+}
+
 func (t *Task) IncrementRetry() {
 	t.RetryCount++
 	t.UpdatedAt = time.Now()
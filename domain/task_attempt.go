@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+type AttemptOutcome string
+
+const (
+	AttemptOutcomeSucceeded AttemptOutcome = "succeeded"
+	AttemptOutcomeFailed    AttemptOutcome = "failed"
+)
+
+func (o AttemptOutcome) String() string {
+	return string(o)
+}
+
+// TaskAttempt records one execution of a task. Attempts are numbered from 1.
+type TaskAttempt struct {
+	TaskID     string         `json:"task_id"`
+	Attempt    int            `json:"attempt"`
+	WorkerID   string         `json:"worker_id"`
+	StartedAt  time.Time      `json:"started_at"`
+	EndedAt    time.Time      `json:"ended_at"`
+	Duration   time.Duration  `json:"duration"`
+	Outcome    AttemptOutcome `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	ResultSize int            `json:"result_size"`
+
+	// FailureReason is set on failed attempts.
+	FailureReason FailureReason `json:"failure_reason,omitempty"`
+}
+
+func NewTaskAttempt(task *Task, workerID string) *TaskAttempt {
+	return &TaskAttempt{
+		TaskID:    task.ID,
+		Attempt:   task.RetryCount + 1,
+		WorkerID:  workerID,
+		StartedAt: time.Now(),
+	}
+}
+
+func (a *TaskAttempt) Finish(outcome AttemptOutcome, err error, resultSize int) {
+	a.EndedAt = time.Now()
+	a.Duration = a.EndedAt.Sub(a.StartedAt)
+	a.Outcome = outcome
+	a.ResultSize = resultSize
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if outcome == AttemptOutcomeFailed {
+		a.FailureReason = FailureReasonOf(err)
+	}
+}
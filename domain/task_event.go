@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+type TaskEventKind string
+
+const (
+	TaskEventCreated TaskEventKind = "created"
+	TaskEventUpdated TaskEventKind = "updated"
+	TaskEventDeleted TaskEventKind = "deleted"
+)
+
+func (k TaskEventKind) String() string {
+	return string(k)
+}
+
+// TaskEvent reports a change to a stored task. Task is a snapshot taken
+// right after the change; for deletions, the last stored state.
+type TaskEvent struct {
+	Kind TaskEventKind
+	Task *Task
+	At   time.Time
+}
@@ -8,6 +8,11 @@ const (
 	TaskPriorityLow    TaskPriority = "low"
 )
 
+// TaskPriorities returns every valid priority, highest first.
+func TaskPriorities() []TaskPriority {
+	return []TaskPriority{TaskPriorityHigh, TaskPriorityMedium, TaskPriorityLow}
+}
+
 func (p TaskPriority) IsValid() bool {
 	switch p {
 	case TaskPriorityHigh, TaskPriorityMedium, TaskPriorityLow:
@@ -17,6 +22,20 @@ func (p TaskPriority) IsValid() bool {
 	}
 }
 
+// Rank orders priorities for scheduling; higher ranks go first.
+func (p TaskPriority) Rank() int {
+	switch p {
+	case TaskPriorityHigh:
+		return 3
+	case TaskPriorityMedium:
+		return 2
+	case TaskPriorityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
 func (p TaskPriority) String() string {
 	return string(p)
 }
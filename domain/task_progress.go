@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+type TaskProgress struct {
+	Percent   int       `json:"percent"`
+	Stage     string    `json:"stage,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -8,11 +8,17 @@ const (
 	TaskStatusCompleted  TaskStatus = "completed"
 	TaskStatusFailed     TaskStatus = "failed"
 	TaskStatusCancelled  TaskStatus = "cancelled"
+	TaskStatusExpired    TaskStatus = "expired"
 )
 
+// TaskStatuses returns every valid task status.
+func TaskStatuses() []TaskStatus {
+	return []TaskStatus{TaskStatusPending, TaskStatusProcessing, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled, TaskStatusExpired}
+}
+
 func (s TaskStatus) IsValid() bool {
 	switch s {
-	case TaskStatusPending, TaskStatusProcessing, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled:
+	case TaskStatusPending, TaskStatusProcessing, TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled, TaskStatusExpired:
 		return true
 	default:
 		return false
@@ -24,9 +30,30 @@ func (s TaskStatus) String() string {
 }
 
 func (s TaskStatus) IsFinal() bool {
-	return s == TaskStatusCompleted || s == TaskStatusCancelled
+	return s == TaskStatusCompleted || s == TaskStatusCancelled || s == TaskStatusExpired
 }
 
 func (s TaskStatus) CanRetry() bool {
 	return s == TaskStatusFailed
 }
+
+// statusTransitions lists the statuses a task may move to from each status.
+// Completed, cancelled and expired tasks are final; failed tasks go back to
+// pending when they are retried.
+var statusTransitions = map[TaskStatus][]TaskStatus{
+	TaskStatusPending:    {TaskStatusProcessing, TaskStatusCancelled, TaskStatusExpired},
+	TaskStatusProcessing: {TaskStatusCompleted, TaskStatusFailed, TaskStatusExpired},
+	TaskStatusFailed:     {TaskStatusPending},
+	TaskStatusCompleted:  {},
+	TaskStatusCancelled:  {},
+	TaskStatusExpired:    {},
+}
+
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
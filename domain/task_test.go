@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestTask(t *testing.T) *Task {
+	t.Helper()
+
+	task, err := NewTask(TaskTypeEmail, TaskPriorityMedium, map[string]interface{}{"to": "ops@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// taskIn returns a task that went through the state machine to status.
+func taskIn(t *testing.T, status TaskStatus) *Task {
+	t.Helper()
+
+	task := newTestTask(t)
+	var err error
+	switch status {
+	case TaskStatusPending:
+	case TaskStatusProcessing:
+		err = task.MarkAsProcessing()
+	case TaskStatusCompleted:
+		if err = task.MarkAsProcessing(); err == nil {
+			err = task.MarkAsCompleted(nil)
+		}
+	case TaskStatusFailed:
+		if err = task.MarkAsProcessing(); err == nil {
+			err = task.MarkAsFailed(errors.New("boom"))
+		}
+	case TaskStatusCancelled:
+		err = task.MarkAsCancelled()
+	case TaskStatusExpired:
+		err = task.MarkAsExpired(nil)
+	}
+	if err != nil {
+		t.Fatalf("moving task to %s: %v", status, err)
+	}
+	return task
+}
+
+func TestTaskStatusTransitions(t *testing.T) {
+	allowed := map[[2]TaskStatus]bool{
+		{TaskStatusPending, TaskStatusProcessing}:   true,
+		{TaskStatusPending, TaskStatusCancelled}:    true,
+		{TaskStatusPending, TaskStatusExpired}:      true,
+		{TaskStatusProcessing, TaskStatusCompleted}: true,
+		{TaskStatusProcessing, TaskStatusFailed}:    true,
+		{TaskStatusProcessing, TaskStatusExpired}:   true,
+		{TaskStatusFailed, TaskStatusPending}:       true,
+	}
+
+	for _, from := range TaskStatuses() {
+		for _, to := range TaskStatuses() {
+			if got, want := from.CanTransitionTo(to), allowed[[2]TaskStatus{from, to}]; got != want {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestTaskRecordsStatusHistory(t *testing.T) {
+	task := taskIn(t, TaskStatusFailed)
+	if err := task.MarkAsPending(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []StatusTransition{
+		{From: TaskStatusPending, To: TaskStatusProcessing},
+		{From: TaskStatusProcessing, To: TaskStatusFailed},
+		{From: TaskStatusFailed, To: TaskStatusPending},
+	}
+	if len(task.Transitions) != len(want) {
+		t.Fatalf("Transitions = %v, want %v", task.Transitions, want)
+	}
+	for i, transition := range task.Transitions {
+		if transition.From != want[i].From || transition.To != want[i].To {
+			t.Errorf("Transitions[%d] = %s → %s, want %s → %s", i, transition.From, transition.To, want[i].From, want[i].To)
+		}
+		if transition.At.IsZero() {
+			t.Errorf("Transitions[%d] has no time", i)
+		}
+	}
+}
+
+func TestTaskRejectsIllegalTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     TaskStatus
+		apply    func(*Task) error
+		specific error
+	}{
+		{"complete pending task", TaskStatusPending, func(t *Task) error { return t.MarkAsCompleted(nil) }, nil},
+		{"process twice", TaskStatusProcessing, (*Task).MarkAsProcessing, ErrTaskAlreadyProcessing},
+		{"cancel running task", TaskStatusProcessing, (*Task).MarkAsCancelled, nil},
+		{"restart completed task", TaskStatusCompleted, (*Task).MarkAsProcessing, ErrTaskAlreadyCompleted},
+		{"cancel completed task", TaskStatusCompleted, (*Task).MarkAsCancelled, ErrTaskAlreadyCompleted},
+		{"process cancelled task", TaskStatusCancelled, (*Task).MarkAsProcessing, ErrTaskAlreadyCancelled},
+		{"process failed task", TaskStatusFailed, (*Task).MarkAsProcessing, ErrTaskCannotBeRetried},
+		{"process expired task", TaskStatusExpired, (*Task).MarkAsProcessing, ErrTaskAlreadyExpired},
+		{"expire completed task", TaskStatusCompleted, func(t *Task) error { return t.MarkAsExpired(nil) }, ErrTaskAlreadyCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := taskIn(t, tt.from)
+			history := len(task.Transitions)
+
+			err := tt.apply(task)
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Fatalf("error = %v, want a *TransitionError", err)
+			}
+			if transitionErr.From != tt.from {
+				t.Errorf("From = %s, want %s", transitionErr.From, tt.from)
+			}
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("error %v doesn't match ErrInvalidTransition", err)
+			}
+			if tt.specific != nil && !errors.Is(err, tt.specific) {
+				t.Errorf("error %v doesn't match %v", err, tt.specific)
+			}
+
+			if task.Status != tt.from || len(task.Transitions) != history {
+				t.Errorf("rejected transition changed the task to %s with %d transitions", task.Status, len(task.Transitions))
+			}
+		})
+	}
+}
+
+func TestMarkAttemptFailed(t *testing.T) {
+	task := newTestTask(t)
+	task.MaxRetries = 2
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := task.MarkAsProcessing(); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if err := task.MarkAttemptFailed(errors.New("temporary")); err != nil {
+			t.Fatalf("attempt %d: MarkAttemptFailed() error = %v", attempt, err)
+		}
+		if task.RetryCount != attempt {
+			t.Errorf("attempt %d: RetryCount = %d", attempt, task.RetryCount)
+		}
+	}
+
+	// The first failure has a retry left, the second doesn't.
+	if got := task.Transitions[2]; got.From != TaskStatusFailed || got.To != TaskStatusPending {
+		t.Errorf("first failure went %s → %s, want failed → pending", got.From, got.To)
+	}
+	if task.Status != TaskStatusFailed || !task.IsInDeadLetterQueue() {
+		t.Errorf("after the last retry status = %s, want failed in the dead letter queue", task.Status)
+	}
+	if err := task.MarkAsPending(); !errors.Is(err, ErrTaskCannotBeRetried) {
+		t.Errorf("MarkAsPending() without retries left = %v, want ErrTaskCannotBeRetried", err)
+	}
+}
+
+func TestMarkAsPermanentlyFailedUsesUpRetries(t *testing.T) {
+	task := taskIn(t, TaskStatusProcessing)
+
+	if err := task.MarkAsPermanentlyFailed(errors.New("bad input")); err != nil {
+		t.Fatal(err)
+	}
+	if !task.IsInDeadLetterQueue() || task.Error != "bad input" {
+		t.Errorf("task = %s, retries %d/%d, error %q; want it in the dead letter queue", task.Status, task.RetryCount, task.MaxRetries, task.Error)
+	}
+}
@@ -6,11 +6,18 @@ const (
 	TaskTypeEmail            TaskType = "email"
 	TaskTypeImageProcessing  TaskType = "image_processing"
 	TaskTypeReportGeneration TaskType = "report_generation"
+	TaskTypeCommand          TaskType = "command"
+	TaskTypeHTTPRequest      TaskType = "http_request"
 )
 
+// TaskTypes returns every valid task type.
+func TaskTypes() []TaskType {
+	return []TaskType{TaskTypeEmail, TaskTypeImageProcessing, TaskTypeReportGeneration, TaskTypeCommand, TaskTypeHTTPRequest}
+}
+
 func (t TaskType) IsValid() bool {
 	switch t {
-	case TaskTypeEmail, TaskTypeImageProcessing, TaskTypeReportGeneration:
+	case TaskTypeEmail, TaskTypeImageProcessing, TaskTypeReportGeneration, TaskTypeCommand, TaskTypeHTTPRequest:
 		return true
 	default:
 		return false
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultTenant owns tasks submitted without a tenant.
+const DefaultTenant = "default"
+
+var (
+	ErrInvalidTenant = errors.New("invalid tenant")
+
+	ErrQuotaExceeded = errors.New("tenant quota exceeded")
+)
+
+var tenantPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
+func ValidateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
+
+// QuotaExceededError reports which quota a tenant ran into. It matches
+// ErrQuotaExceeded.
+type QuotaExceededError struct {
+	Tenant     string
+	Quota      string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("tenant %s exceeded its %s quota of %d", e.Tenant, e.Quota, e.Limit)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
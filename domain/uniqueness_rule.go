@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DuplicatePolicy says what happens to a submission that duplicates an
+// existing task.
+type DuplicatePolicy string
+
+const (
+	// DuplicatePolicyReject refuses the submission.
+	DuplicatePolicyReject DuplicatePolicy = "reject"
+	// DuplicatePolicyMerge drops the submission in favour of the existing
+	// task.
+	DuplicatePolicyMerge DuplicatePolicy = "merge"
+	// DuplicatePolicyReplace cancels the existing task, if it is still
+	// pending, and accepts the submission in its place.
+	DuplicatePolicyReplace DuplicatePolicy = "replace"
+)
+
+// DuplicatePolicies returns every duplicate policy.
+func DuplicatePolicies() []DuplicatePolicy {
+	return []DuplicatePolicy{DuplicatePolicyReject, DuplicatePolicyMerge, DuplicatePolicyReplace}
+}
+
+func (p DuplicatePolicy) IsValid() bool {
+	switch p {
+	case DuplicatePolicyReject, DuplicatePolicyMerge, DuplicatePolicyReplace:
+		return true
+	}
+	return false
+}
+
+func (p DuplicatePolicy) String() string {
+	return string(p)
+}
+
+// UniquenessRule makes the tasks of a type unique by some of their payload
+// fields: tasks of one tenant whose payloads agree on Fields, submitted
+// within Window of each other, are duplicates.
+type UniquenessRule struct {
+	Fields      []string
+	Window      time.Duration
+	OnDuplicate DuplicatePolicy
+}
+
+// Key returns the unique key of a task of taskType with payload, such as
+// report_generation:end_date="2024-01-31",report_type="sales". A field
+// missing from the payload counts as null.
+func (r UniquenessRule) Key(taskType TaskType, payload map[string]interface{}) string {
+	fields := append([]string(nil), r.Fields...)
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		value, err := json.Marshal(payload[field])
+		if err != nil {
+			value = []byte("null")
+		}
+		parts[i] = field + "=" + string(value)
+	}
+	return taskType.String() + ":" + strings.Join(parts, ",")
+}
+
+// Duplicates reports whether existing, a task with the same unique key, is
+// still a duplicate of a task submitted at now. Tasks that failed, were
+// cancelled or expired don't stand in the way of a new one.
+func (r UniquenessRule) Duplicates(existing *Task, now time.Time) bool {
+	switch existing.Status {
+	case TaskStatusFailed, TaskStatusCancelled, TaskStatusExpired:
+		return false
+	}
+	return existing.CreatedAt.After(now.Add(-r.Window))
+}
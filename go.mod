@@ -2,4 +2,4 @@ module go-task-queue-system
 
 go 1.25.3
 
-require github.com/google/uuid v1.6.0 // indirect
+require github.com/google/uuid v1.6.0
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+type Scope string
+
+const (
+	ScopeSubmit Scope = "tasks:submit"
+	ScopeRead   Scope = "tasks:read"
+	ScopeCancel Scope = "tasks:cancel"
+	ScopeWork   Scope = "tasks:work"
+	ScopeAdmin  Scope = "admin"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeSubmit, ScopeRead, ScopeCancel, ScopeWork, ScopeAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+type APIKey struct {
+	ID string `json:"id"`
+
+	// Key is the secret in plain text. KeySHA256 can be used instead so the
+	// file doesn't contain usable secrets.
+	Key       string `json:"key,omitempty"`
+	KeySHA256 string `json:"key_sha256,omitempty"`
+
+	Scopes []Scope `json:"scopes"`
+
+	// Tenant binds the key to one tenant. Keys without a tenant act for
+	// the default tenant, or for any tenant if they have the admin scope.
+	Tenant string `json:"tenant,omitempty"`
+
+	// AllowedTaskTypes restricts which task types the key may submit. Empty
+	// means all types.
+	AllowedTaskTypes []string `json:"allowed_task_types,omitempty"`
+
+	// RateLimit is the sustained number of requests per second, with bursts
+	// of up to Burst requests. Zero means unlimited.
+	RateLimit float64 `json:"rate_limit,omitempty"`
+	Burst     int     `json:"burst,omitempty"`
+}
+
+// HasScope reports whether the key grants scope. Admin keys have every scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) CanSubmit(taskType string) bool {
+	return len(k.AllowedTaskTypes) == 0 || slices.Contains(k.AllowedTaskTypes, taskType)
+}
+
+type KeyStore struct {
+	byHash   map[string]*APIKey
+	limiters map[string]*RateLimiter
+}
+
+func NewKeyStore(keys []APIKey) (*KeyStore, error) {
+	store := &KeyStore{
+		byHash:   make(map[string]*APIKey, len(keys)),
+		limiters: make(map[string]*RateLimiter),
+	}
+
+	ids := make(map[string]bool, len(keys))
+	for i := range keys {
+		key := keys[i]
+
+		if key.ID == "" {
+			return nil, fmt.Errorf("key %d: id is required", i)
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("key %q: duplicate id", key.ID)
+		}
+		ids[key.ID] = true
+
+		hash := strings.ToLower(key.KeySHA256)
+		if key.Key != "" {
+			hash = hashKey(key.Key)
+		}
+		if len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("key %q: key or a hex key_sha256 is required", key.ID)
+		}
+		if _, exists := store.byHash[hash]; exists {
+			return nil, fmt.Errorf("key %q: secret is already used by another key", key.ID)
+		}
+
+		for _, scope := range key.Scopes {
+			if !scope.IsValid() {
+				return nil, fmt.Errorf("key %q: unknown scope %q", key.ID, scope)
+			}
+		}
+
+		key.Key = ""
+		store.byHash[hash] = &key
+
+		if key.RateLimit > 0 {
+			store.limiters[key.ID] = NewRateLimiter(key.RateLimit, key.Burst)
+		}
+	}
+
+	return store, nil
+}
+
+// LoadKeyStore reads keys from a JSON file of the form {"keys": [...]}.
+func LoadKeyStore(path string) (*KeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Keys []APIKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return NewKeyStore(file.Keys)
+}
+
+func (s *KeyStore) Authenticate(token string) (*APIKey, error) {
+	key, ok := s.byHash[hashKey(token)]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// Allow takes one request from the key's rate limit. When the limit is
+// exhausted it returns false and how long to wait before retrying.
+func (s *KeyStore) Allow(key *APIKey) (bool, time.Duration) {
+	limiter, ok := s.limiters[key.ID]
+	if !ok {
+		return true, 0
+	}
+	return limiter.Allow()
+}
+
+func (s *KeyStore) Len() int {
+	return len(s.byHash)
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
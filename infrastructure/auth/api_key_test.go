@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	reader := &APIKey{ID: "reader", Scopes: []Scope{ScopeRead}}
+	admin := &APIKey{ID: "ops", Scopes: []Scope{ScopeAdmin}}
+
+	tests := []struct {
+		key   *APIKey
+		scope Scope
+		want  bool
+	}{
+		{reader, ScopeRead, true},
+		{reader, ScopeSubmit, false},
+		{reader, ScopeCancel, false},
+		{reader, ScopeAdmin, false},
+		{admin, ScopeRead, true},
+		{admin, ScopeSubmit, true},
+		{admin, ScopeCancel, true},
+		{admin, ScopeAdmin, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("%s.HasScope(%s) = %v, want %v", tt.key.ID, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyCanSubmit(t *testing.T) {
+	unrestricted := &APIKey{ID: "any"}
+	mailer := &APIKey{ID: "mailer", AllowedTaskTypes: []string{"email"}}
+
+	if !unrestricted.CanSubmit("command") {
+		t.Error("key without allowed_task_types can't submit every type")
+	}
+	if !mailer.CanSubmit("email") || mailer.CanSubmit("command") {
+		t.Error("allowed_task_types isn't enforced")
+	}
+}
+
+func TestKeyStoreAuthenticate(t *testing.T) {
+	store, err := NewKeyStore([]APIKey{
+		{ID: "plain", Key: "plain-secret", Scopes: []Scope{ScopeRead}},
+		{ID: "hashed", KeySHA256: strings.ToUpper(hashKey("hashed-secret")), Scopes: []Scope{ScopeSubmit}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for token, id := range map[string]string{"plain-secret": "plain", "hashed-secret": "hashed"} {
+		key, err := store.Authenticate(token)
+		if err != nil {
+			t.Errorf("Authenticate(%q) error = %v", token, err)
+			continue
+		}
+		if key.ID != id {
+			t.Errorf("Authenticate(%q) = %s, want %s", token, key.ID, id)
+		}
+		if key.Key != "" {
+			t.Errorf("key %s keeps its plain-text secret", key.ID)
+		}
+	}
+
+	if _, err := store.Authenticate("wrong"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate(wrong) error = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestNewKeyStoreRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []APIKey
+		want string
+	}{
+		{"missing id", []APIKey{{Key: "a"}}, "id is required"},
+		{"duplicate id", []APIKey{{ID: "a", Key: "a"}, {ID: "a", Key: "b"}}, "duplicate id"},
+		{"missing secret", []APIKey{{ID: "a"}}, "key or a hex key_sha256 is required"},
+		{"short hash", []APIKey{{ID: "a", KeySHA256: "abc"}}, "key or a hex key_sha256 is required"},
+		{"shared secret", []APIKey{{ID: "a", Key: "same"}, {ID: "b", Key: "same"}}, "already used"},
+		{"unknown scope", []APIKey{{ID: "a", Key: "a", Scopes: []Scope{"tasks:everything"}}}, "unknown scope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyStore(tt.keys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewKeyStore() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyStore(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "api_keys.json")
+	if err := os.WriteFile(path, []byte(`{"keys": [{"id": "ops", "key": "secret", "scopes": ["admin"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := LoadKeyStore(path)
+	if err != nil {
+		t.Fatalf("LoadKeyStore() error = %v", err)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", store.Len())
+	}
+
+	if _, err := LoadKeyStore(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadKeyStore(missing) error = %v, want ErrNotExist", err)
+	}
+
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"keys": [`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeyStore(broken); err == nil {
+		t.Error("LoadKeyStore() accepted invalid JSON")
+	}
+}
+
+func TestKeyStoreAllowAppliesRateLimit(t *testing.T) {
+	store, err := NewKeyStore([]APIKey{
+		{ID: "limited", Key: "limited", RateLimit: 1, Burst: 2},
+		{ID: "unlimited", Key: "unlimited"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited, _ := store.Authenticate("limited")
+	unlimited, _ := store.Authenticate("unlimited")
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := store.Allow(limited); !allowed {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+	if allowed, retryAfter := store.Allow(limited); allowed || retryAfter <= 0 {
+		t.Errorf("Allow() past the burst = %v, %v; want refused with a wait", allowed, retryAfter)
+	}
+
+	for i := 0; i < 100; i++ {
+		if allowed, _ := store.Allow(unlimited); !allowed {
+			t.Fatal("key without a rate limit was refused")
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	limiter := NewRateLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.Allow(); !allowed {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+
+	allowed, retryAfter := limiter.Allow()
+	if allowed {
+		t.Fatal("request past the burst was allowed")
+	}
+	if retryAfter <= 0 || retryAfter > 500*time.Millisecond {
+		t.Errorf("retry after %v, want at most the 500ms one token takes at 2/s", retryAfter)
+	}
+
+	// Half a second refills one token at 2/s.
+	limiter.mu.Lock()
+	limiter.lastFill = limiter.lastFill.Add(-500 * time.Millisecond)
+	limiter.mu.Unlock()
+
+	if allowed, _ := limiter.Allow(); !allowed {
+		t.Error("request after the refill was refused")
+	}
+	if allowed, _ := limiter.Allow(); allowed {
+		t.Error("refill added more than one token")
+	}
+}
+
+func TestRateLimiterNeverExceedsBurst(t *testing.T) {
+	limiter := NewRateLimiter(10, 2)
+
+	limiter.mu.Lock()
+	limiter.lastFill = limiter.lastFill.Add(-time.Hour)
+	limiter.mu.Unlock()
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if ok, _ := limiter.Allow(); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d requests after an idle hour, want the burst of 2", allowed)
+	}
+}
+
+func TestRateLimiterDefaultBurst(t *testing.T) {
+	// Without a burst, a limiter allows a second's worth of requests.
+	limiter := NewRateLimiter(2.5, 0)
+	if limiter.burst != 3 {
+		t.Errorf("burst = %v, want 3", limiter.burst)
+	}
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"go-task-queue-system/domain"
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const subscriberBuffer = 256
+
+// Broker fans task events out to subscribers. Publishing never blocks: a
+// subscriber that doesn't keep up misses events rather than slowing down
+// the writers that publish them.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan domain.TaskEvent]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[chan domain.TaskEvent]struct{}),
+	}
+}
+
+func (b *Broker) Publish(event domain.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of events published from now on and a
+// function that ends the subscription. The channel is closed when the
+// subscription ends or the broker is closed.
+func (b *Broker) Subscribe() (<-chan domain.TaskEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan domain.TaskEvent, subscriberBuffer)
+	if b.closed {
+		close(events)
+		return events, func() {}
+	}
+	b.subscribers[events] = struct{}{}
+
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[events]; ok {
+			delete(b.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// Close ends every subscription, e.g. so long-lived streams let the server
+// shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for subscriber := range b.subscribers {
+		delete(b.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,156 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Reflector builds schemas from Go types the way encoding/json would
+// marshal them. Named struct types become shared definitions referenced
+// with $ref.
+type Reflector struct {
+	Definitions map[string]*Schema
+}
+
+func NewReflector() *Reflector {
+	return &Reflector{Definitions: map[string]*Schema{}}
+}
+
+// Reflect returns the schema for the type of value, adding the struct
+// types it uses to r.Definitions.
+func (r *Reflector) Reflect(value interface{}) *Schema {
+	return r.schemaFor(reflect.TypeOf(value))
+}
+
+// Define adds a schema for the type of value under its type name and
+// returns that name.
+func (r *Reflector) Define(value interface{}) string {
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	r.schemaFor(t)
+	return t.Name()
+}
+
+// Close marks the object schemas schema uses, itself included, as Closed,
+// following references into r.Definitions. Maps are left open.
+func (r *Reflector) Close(schema *Schema) {
+	r.close(schema, map[string]bool{})
+}
+
+func (r *Reflector) close(schema *Schema, seen map[string]bool) {
+	if schema == nil {
+		return
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, RefPrefix)
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		r.close(r.Definitions[name], seen)
+		return
+	}
+
+	if schema.Type == "object" && schema.AdditionalProperties == nil {
+		schema.Closed = true
+	}
+	for _, property := range schema.Properties {
+		r.close(property, seen)
+	}
+	for _, variant := range schema.OneOf {
+		r.close(variant, seen)
+	}
+	r.close(schema.Items, seen)
+}
+
+func (r *Reflector) schemaFor(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := r.schemaFor(t.Elem())
+		if schema.Ref != "" {
+			// $ref siblings are ignored in OpenAPI 3.0, so nullable
+			// references are left as they are.
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return r.structSchema(t)
+		}
+		if _, defined := r.Definitions[name]; !defined {
+			r.Definitions[name] = &Schema{} // placeholder for recursive types
+			r.Definitions[name] = r.structSchema(t)
+		}
+		return Ref(name)
+
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem()), Nullable: true}
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		// interface{} and anything else accepts any JSON value.
+		return &Schema{}
+	}
+}
+
+func (r *Reflector) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		schema.Properties[name] = r.schemaFor(field.Type)
+
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+func jsonName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
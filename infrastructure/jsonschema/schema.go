@@ -0,0 +1,143 @@
+package jsonschema
+
+import "encoding/json"
+
+// Schema is the subset of the OpenAPI 3.0 Schema Object the server uses to
+// describe and validate JSON documents.
+type Schema struct {
+	Ref           string             `json:"$ref,omitempty"`
+	Type          string             `json:"type,omitempty"`
+	Format        string             `json:"format,omitempty"`
+	Description   string             `json:"description,omitempty"`
+	Enum          []interface{}      `json:"enum,omitempty"`
+	Default       interface{}        `json:"default,omitempty"`
+	Minimum       *float64           `json:"minimum,omitempty"`
+	Maximum       *float64           `json:"maximum,omitempty"`
+	MinLength     *int               `json:"minLength,omitempty"`
+	MaxLength     *int               `json:"maxLength,omitempty"`
+	Pattern       string             `json:"pattern,omitempty"`
+	Items         *Schema            `json:"items,omitempty"`
+	Properties    map[string]*Schema `json:"properties,omitempty"`
+	Required      []string           `json:"required,omitempty"`
+	Nullable      bool               `json:"nullable,omitempty"`
+	OneOf         []*Schema          `json:"oneOf,omitempty"`
+	Discriminator *Discriminator     `json:"discriminator,omitempty"`
+
+	// AdditionalProperties describes the values of a map-like object.
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+
+	// Closed rejects properties an object schema doesn't list. It is
+	// written as additionalProperties: false.
+	Closed bool `json:"-"`
+}
+
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.Closed {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		plain
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{plain(s), false})
+}
+
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+}
+
+// RefPrefix is where named schemas live in an OpenAPI document.
+const RefPrefix = "#/components/schemas/"
+
+func Ref(name string) *Schema {
+	return &Schema{Ref: RefPrefix + name}
+}
+
+func String(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+func Integer(description string) *Schema {
+	return &Schema{Type: "integer", Description: description}
+}
+
+func Number(description string) *Schema {
+	return &Schema{Type: "number", Description: description}
+}
+
+func Boolean(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+func Array(items *Schema, description string) *Schema {
+	return &Schema{Type: "array", Items: items, Description: description}
+}
+
+// Object returns an object schema; the listed properties are required.
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// Map returns an object schema whose values all match values.
+func Map(values *Schema, description string) *Schema {
+	return &Schema{Type: "object", AdditionalProperties: values, Description: description}
+}
+
+// Enum returns a string schema that only accepts values.
+func Enum(description string, values ...string) *Schema {
+	enum := make([]interface{}, len(values))
+	for i, value := range values {
+		enum[i] = value
+	}
+	return &Schema{Type: "string", Enum: enum, Description: description}
+}
+
+// WithRange sets inclusive bounds on a numeric schema.
+func (s *Schema) WithRange(minimum, maximum float64) *Schema {
+	s.Minimum, s.Maximum = &minimum, &maximum
+	return s
+}
+
+// WithMinimum sets an inclusive lower bound on a numeric schema.
+func (s *Schema) WithMinimum(minimum float64) *Schema {
+	s.Minimum = &minimum
+	return s
+}
+
+func (s *Schema) WithFormat(format string) *Schema {
+	s.Format = format
+	return s
+}
+
+func (s *Schema) WithDefault(value interface{}) *Schema {
+	s.Default = value
+	return s
+}
+
+// Clone returns a deep copy of s, so a shared schema can be adjusted for
+// one use.
+func (s *Schema) Clone() *Schema {
+	if s == nil {
+		return nil
+	}
+
+	clone := *s
+	clone.Enum = append([]interface{}(nil), s.Enum...)
+	clone.Required = append([]string(nil), s.Required...)
+	clone.Items = s.Items.Clone()
+	clone.AdditionalProperties = s.AdditionalProperties.Clone()
+	if s.Properties != nil {
+		clone.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, property := range s.Properties {
+			clone.Properties[name] = property.Clone()
+		}
+	}
+	if s.OneOf != nil {
+		clone.OneOf = make([]*Schema, len(s.OneOf))
+		for i, variant := range s.OneOf {
+			clone.OneOf[i] = variant.Clone()
+		}
+	}
+	return &clone
+}
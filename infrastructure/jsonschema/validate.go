@@ -0,0 +1,248 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every way a document failed to match its schema.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json into
+// an interface{}) against the schema. References are looked up in
+// definitions. It returns a *ValidationError.
+func (s *Schema) Validate(value interface{}, definitions map[string]*Schema) error {
+	v := &validator{definitions: definitions}
+	v.validate(s, value, "")
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	definitions map[string]*Schema
+	problems    []string
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "body"
+	}
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		resolved, ok := v.definitions[strings.TrimPrefix(s.Ref, RefPrefix)]
+		if !ok {
+			return &Schema{}
+		}
+		s = resolved
+	}
+	return s
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path string) {
+	s = v.resolve(s)
+
+	if value == nil {
+		if s.Type != "" && !s.Nullable {
+			v.fail(path, "must not be null")
+		}
+		return
+	}
+
+	if len(s.OneOf) > 0 {
+		v.validateOneOf(s, value, path)
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "must be an object")
+			return
+		}
+		v.validateObject(s, object, path)
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.fail(path, "must be an array")
+			return
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.fail(path, "must be a string")
+			return
+		}
+		v.validateString(s, str, path)
+
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			v.fail(path, "must be a number")
+			return
+		}
+		if s.Type == "integer" && number != math.Trunc(number) {
+			v.fail(path, "must be an integer")
+			return
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			v.fail(path, "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			v.fail(path, "must be at most %v", *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "must be a boolean")
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, object map[string]interface{}, path string) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(join(path, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := s.Properties[name]; ok {
+			v.validate(property, object[name], join(path, name))
+		} else if s.AdditionalProperties != nil {
+			v.validate(s.AdditionalProperties, object[name], join(path, name))
+		} else if s.Closed {
+			v.fail(join(path, name), "is not allowed")
+		}
+	}
+}
+
+func (v *validator) validateString(s *Schema, str, path string) {
+	if len(s.Enum) > 0 {
+		for _, allowed := range s.Enum {
+			if allowed == str {
+				return
+			}
+		}
+		v.fail(path, "must be one of %s", enumList(s.Enum))
+		return
+	}
+
+	length := len([]rune(str))
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(path, "must be at least %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(path, "must be at most %d characters", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+			v.fail(path, "must match %s", s.Pattern)
+		}
+	}
+	if !matchesFormat(s.Format, str) {
+		v.fail(path, "must be a valid %s", s.Format)
+	}
+}
+
+// matchesFormat checks the string formats the server's schemas use. Other
+// formats are only descriptive.
+func matchesFormat(format, str string) bool {
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(str)
+		return err == nil && address.Address == str
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, str)
+		return err == nil
+	case "uri":
+		parsed, err := url.Parse(str)
+		return err == nil && parsed.IsAbs()
+	default:
+		return true
+	}
+}
+
+// validateOneOf picks the variant named by the discriminator when there is
+// one, which gives far better messages than trying every variant.
+func (v *validator) validateOneOf(s *Schema, value interface{}, path string) {
+	if s.Discriminator != nil {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "must be an object")
+			return
+		}
+
+		property := s.Discriminator.PropertyName
+		key, _ := object[property].(string)
+		ref, ok := s.Discriminator.Mapping[key]
+		if !ok {
+			allowed := make([]interface{}, 0, len(s.Discriminator.Mapping))
+			for name := range s.Discriminator.Mapping {
+				allowed = append(allowed, name)
+			}
+			v.fail(join(path, property), "must be one of %s", enumList(allowed))
+			return
+		}
+		v.validate(&Schema{Ref: ref}, value, path)
+		return
+	}
+
+	matches := 0
+	for _, variant := range s.OneOf {
+		attempt := &validator{definitions: v.definitions}
+		attempt.validate(variant, value, path)
+		if len(attempt.problems) == 0 {
+			matches++
+		}
+	}
+	if matches != 1 {
+		v.fail(path, "must match exactly one of %d alternatives", len(s.OneOf))
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func enumList(values []interface{}) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = fmt.Sprint(value)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"go-task-queue-system/domain"
+	"io"
+	"log/slog"
+)
+
+// Attribute keys shared by every component, so log lines about the same
+// task, worker or request can be correlated.
+const (
+	KeyTaskID    = "task_id"
+	KeyTaskType  = "task_type"
+	KeyWorkerID  = "worker_id"
+	KeyAttempt   = "attempt"
+	KeyRequestID = "request_id"
+	KeyTenant    = "tenant"
+	KeyTraceID   = "trace_id"
+)
+
+// New returns a logger writing format ("text" or "json") to w at the given
+// level. The level may be a *slog.LevelVar so it can change at runtime.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case "text", "":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (use text or json)", format)
+	}
+}
+
+// ForTask adds the task's ID, type and tenant to logger.
+func ForTask(logger *slog.Logger, task *domain.Task) *slog.Logger {
+	return logger.With(KeyTaskID, task.ID, KeyTaskType, task.Type.String(), KeyTenant, task.Tenant)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger, e.g. a logger already
+// scoped to a task or request.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-task-queue-system/domain"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArtifactWriter stores files produced by a processor and records them on
+// the task being processed.
+type ArtifactWriter interface {
+	WriteArtifact(name, contentType string, data []byte) (domain.Artifact, error)
+}
+
+type artifactWriterKey struct{}
+
+func WithArtifactWriter(ctx context.Context, writer ArtifactWriter) context.Context {
+	return context.WithValue(ctx, artifactWriterKey{}, writer)
+}
+
+func ArtifactWriterFromContext(ctx context.Context) (ArtifactWriter, bool) {
+	writer, ok := ctx.Value(artifactWriterKey{}).(ArtifactWriter)
+	return writer, ok
+}
+
+type TaskArtifactWriter struct {
+	store domain.BlobStore
+	task  *domain.Task
+}
+
+func NewTaskArtifactWriter(store domain.BlobStore, task *domain.Task) *TaskArtifactWriter {
+	return &TaskArtifactWriter{
+		store: store,
+		task:  task,
+	}
+}
+
+func (w *TaskArtifactWriter) WriteArtifact(name, contentType string, data []byte) (domain.Artifact, error) {
+	if err := domain.ValidateArtifactName(name); err != nil {
+		return domain.Artifact{}, err
+	}
+
+	size, err := w.store.Put(domain.ArtifactKey(w.task.ID, name), bytes.NewReader(data))
+	if err != nil {
+		return domain.Artifact{}, err
+	}
+
+	checksum := sha256.Sum256(data)
+	artifact := domain.Artifact{
+		Name:        name,
+		Size:        size,
+		ContentType: contentType,
+		Checksum:    "sha256:" + hex.EncodeToString(checksum[:]),
+		CreatedAt:   time.Now(),
+	}
+
+	w.task.AddArtifact(artifact)
+	return artifact, nil
+}
+
+// writeOutput stores a processor's output file as a task artifact when an
+// ArtifactWriter is available, and falls back to writing it below outputDir.
+// It returns a URL the output can be fetched from.
+func writeOutput(ctx context.Context, task *domain.Task, outputDir, name, contentType string, data []byte) (string, error) {
+	if writer, ok := ArtifactWriterFromContext(ctx); ok {
+		if _, err := writer.WriteArtifact(name, contentType, data); err != nil {
+			return "", err
+		}
+		return ArtifactPath(task.ID, name), nil
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(outputDir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	return "file://" + filepath.ToSlash(absPath), nil
+}
+
+func ArtifactPath(taskID, name string) string {
+	return "/tasks/" + taskID + "/artifacts/" + name
+}
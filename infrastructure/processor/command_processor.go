@@ -0,0 +1,324 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/logging"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultCommandOutputLimit = 64 << 10 // 64 KB
+
+type CommandConfig struct {
+	// AllowedCommands maps the command names tasks may use to the
+	// executable that is run for them. Anything else is rejected.
+	AllowedCommands map[string]string
+
+	// WorkDirRoot is the directory commands run in. A task's working_dir
+	// must resolve to a path below it.
+	WorkDirRoot string
+
+	// BaseEnv is passed to every command. The server's own environment is
+	// never inherited.
+	BaseEnv map[string]string
+
+	// AllowedEnv lists the environment variables a task may set. PATH and
+	// the dynamic loader's LD_* variables are never allowed, since they
+	// would let a task run something other than the allowed executable.
+	AllowedEnv []string
+
+	// MaxOutputBytes caps how much of stdout and stderr is kept each.
+	MaxOutputBytes int
+
+	// RetryableExitCodes lists non-zero exit codes worth retrying. Any
+	// other non-zero exit fails the task permanently.
+	RetryableExitCodes []int
+}
+
+type CommandProcessor struct {
+	config CommandConfig
+}
+
+func NewCommandProcessor(config CommandConfig) *CommandProcessor {
+	if config.MaxOutputBytes <= 0 {
+		config.MaxOutputBytes = defaultCommandOutputLimit
+	}
+
+	return &CommandProcessor{
+		config: config,
+	}
+}
+
+func (p *CommandProcessor) PayloadSchema() *jsonschema.Schema {
+	names := make([]string, 0, len(p.config.AllowedCommands))
+	for name := range p.config.AllowedCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return jsonschema.Object(map[string]*jsonschema.Schema{
+		"command":     jsonschema.Enum("Name of an allowed command", names...),
+		"args":        jsonschema.Array(jsonschema.String(""), "Arguments, passed without a shell"),
+		"env":         jsonschema.Map(jsonschema.String(""), "Extra environment variables, from the configured allowlist"),
+		"working_dir": jsonschema.String("Directory below the configured work directory root"),
+	}, "command")
+}
+
+func (p *CommandProcessor) Process(ctx context.Context, task *domain.Task) (map[string]interface{}, error) {
+	payload := task.Payload
+
+	name, _ := payload["command"].(string)
+	workingDir, _ := payload["working_dir"].(string)
+
+	executable, ok := p.config.AllowedCommands[name]
+	if !ok {
+		return nil, Permanent(fmt.Errorf("command not allowed: %q", name))
+	}
+
+	args, err := stringSlice(payload["args"])
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid args: %w", err))
+	}
+
+	env, err := stringMap(payload["env"])
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid env: %w", err))
+	}
+	for key := range env {
+		if !p.envAllowed(key) {
+			return nil, Permanent(fmt.Errorf("env variable not allowed: %q", key))
+		}
+	}
+
+	dir, err := p.resolveWorkingDir(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logging.FromContext(ctx)
+	logger.Info("running command", "command", name, "args", len(args))
+
+	stdout := &limitedBuffer{limit: p.config.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: p.config.MaxOutputBytes}
+
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Dir = dir
+	cmd.Env = p.buildEnv(env)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Don't wait forever on pipes held open by children after a kill.
+	cmd.WaitDelay = 2 * time.Second
+
+	ReportProgress(ctx, 10, "running", name)
+	startedAt := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(startedAt)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("task cancelled while running command: %v", ctxErr)
+	}
+
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("failed to run command: %w", runErr)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	if exitCode != 0 {
+		err := fmt.Errorf("command exited with code %d: %s", exitCode, lastLine(stderr.String()))
+		logger.Warn("command failed", "error", err)
+
+		if slices.Contains(p.config.RetryableExitCodes, exitCode) {
+			return nil, err
+		}
+		return nil, Permanent(err)
+	}
+
+	logger.Info("command finished", "duration_ms", duration.Milliseconds())
+
+	result := map[string]interface{}{
+		"command":          name,
+		"exit_code":        exitCode,
+		"stdout":           stdout.String(),
+		"stderr":           stderr.String(),
+		"stdout_truncated": stdout.truncated,
+		"stderr_truncated": stderr.truncated,
+		"duration_ms":      duration.Milliseconds(),
+		"finished_at":      time.Now().Format(time.RFC3339),
+	}
+
+	return result, nil
+}
+
+func (p *CommandProcessor) CanProcess(taskType domain.TaskType) bool {
+	return taskType == domain.TaskTypeCommand
+}
+
+// resolveWorkingDir confines working_dir to the work directory root, like
+// resolveSource does for image paths: symlinks are followed before
+// checking, and the directory must exist. A working_dir that is missing or
+// escapes the root fails permanently.
+func (p *CommandProcessor) resolveWorkingDir(workingDir string) (string, error) {
+	root := p.config.WorkDirRoot
+	if root == "" {
+		if workingDir != "" {
+			return "", Permanent(fmt.Errorf("working_dir is not allowed"))
+		}
+		return "", nil
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", fmt.Errorf("command work directory: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, workingDir))
+	if err != nil {
+		return "", Permanent(fmt.Errorf("working_dir: %w", err))
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", Permanent(fmt.Errorf("working_dir must stay inside %s", p.config.WorkDirRoot))
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", Permanent(fmt.Errorf("working_dir: %w", err))
+	}
+	if !info.IsDir() {
+		return "", Permanent(fmt.Errorf("working_dir is not a directory: %s", workingDir))
+	}
+	return resolved, nil
+}
+
+func (p *CommandProcessor) envAllowed(name string) bool {
+	return !IsUnsafeEnv(name) && slices.Contains(p.config.AllowedEnv, name)
+}
+
+// IsUnsafeEnv reports whether setting the environment variable name could
+// change which code a command runs.
+func IsUnsafeEnv(name string) bool {
+	return name == "PATH" || strings.HasPrefix(name, "LD_")
+}
+
+func (p *CommandProcessor) buildEnv(taskEnv map[string]string) []string {
+	merged := make(map[string]string, len(p.config.BaseEnv)+len(taskEnv))
+	for key, value := range p.config.BaseEnv {
+		merged[key] = value
+	}
+	for key, value := range taskEnv {
+		merged[key] = value
+	}
+
+	env := make([]string, 0, len(merged))
+	for key, value := range merged {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	return env
+}
+
+// limitedBuffer keeps the first limit bytes written to it and silently drops
+// the rest, so a chatty command can't exhaust memory.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if remaining <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return len(p), nil
+	}
+
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+func lastLine(output string) string {
+	output = strings.TrimSpace(output)
+	if index := strings.LastIndexByte(output, '\n'); index >= 0 {
+		return output[index+1:]
+	}
+	return output
+}
+
+func stringSlice(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of strings")
+	}
+
+	result := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("item %d is not a string", i)
+		}
+		result[i] = s
+	}
+
+	return result, nil
+}
+
+func stringMap(value interface{}) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	items, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object of strings")
+	}
+
+	result := make(map[string]string, len(items))
+	for key, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %q is not a string", key)
+		}
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return nil, fmt.Errorf("invalid variable name %q", key)
+		}
+		result[key] = s
+	}
+
+	return result, nil
+}
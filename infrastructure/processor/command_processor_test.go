@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandProcessorResolveWorkingDir(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "build"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		workingDir string
+		wantErr    bool
+	}{
+		{name: "root", workingDir: ""},
+		{name: "subdirectory", workingDir: "build"},
+		{name: "missing", workingDir: "missing", wantErr: true},
+		{name: "file", workingDir: "notes.txt", wantErr: true},
+		{name: "parent", workingDir: "../", wantErr: true},
+		{name: "symlink out of the root", workingDir: "escape", wantErr: true},
+	}
+
+	p := NewCommandProcessor(CommandConfig{WorkDirRoot: root})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := p.resolveWorkingDir(tt.workingDir)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("resolveWorkingDir(%q) error = %v", tt.workingDir, err)
+				}
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					t.Errorf("resolveWorkingDir(%q) = %q, not a directory", tt.workingDir, dir)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("resolveWorkingDir(%q) = %q, want an error", tt.workingDir, dir)
+			}
+			if !IsPermanent(err) {
+				t.Errorf("resolveWorkingDir(%q) error %v is retryable, want permanent", tt.workingDir, err)
+			}
+		})
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"context"
 	"fmt"
 	"go-task-queue-system/domain"
-	"log"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/logging"
 	"math/rand"
 	"time"
 )
@@ -22,10 +23,11 @@ func (p *EmailProcessor) Process(ctx context.Context, task *domain.Task) (map[st
 	subject, _ := payload["subject"].(string)
 	body, _ := payload["body"].(string)
 
-	log.Printf("📧 [Email Processor] Processing task %s", task.ID)
-	log.Printf("   To: %s", to)
-	log.Printf("   Subject: %s", subject)
-	log.Printf("   Body: %s", body)
+	logger := logging.FromContext(ctx)
+	// Only the size of the message is logged; its content may be private.
+	logger.Info("sending email", "recipients", 1, "subject_length", len(subject), "body_length", len(body))
+
+	ReportProgress(ctx, 10, "sending", "Connecting to mail server")
 
 	// Simulate email sending (2-3 seconds)
 	processingTime := 2 + rand.Intn(2) // 2-3 seconds
@@ -39,11 +41,11 @@ func (p *EmailProcessor) Process(ctx context.Context, task *domain.Task) (map[st
 
 	// Simulate 10% failure rate for testing retry mechanism
 	if rand.Float32() < 0.10 {
-		log.Printf("❌ [Email Processor] Failed to send email to %s", to)
+		logger.Warn("email delivery failed", "error", "SMTP connection timeout")
 		return nil, fmt.Errorf("failed to send email: SMTP connection timeout")
 	}
 
-	log.Printf("✅ [Email Processor] Email sent successfully to %s", to)
+	logger.Info("email sent")
 
 	result := map[string]interface{}{
 		"message_id": fmt.Sprintf("msg-%d", time.Now().Unix()),
@@ -54,6 +56,14 @@ func (p *EmailProcessor) Process(ctx context.Context, task *domain.Task) (map[st
 	return result, nil
 }
 
+func (p *EmailProcessor) PayloadSchema() *jsonschema.Schema {
+	return jsonschema.Object(map[string]*jsonschema.Schema{
+		"to":      jsonschema.String("Recipient address").WithFormat("email"),
+		"subject": jsonschema.String("Subject line"),
+		"body":    jsonschema.String("Message body; never logged"),
+	}, "to")
+}
+
 func (p *EmailProcessor) CanProcess(taskType domain.TaskType) bool {
 	return taskType == domain.TaskTypeEmail
 }
@@ -0,0 +1,232 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/logging"
+	"go-task-queue-system/infrastructure/tracing"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultHTTPResponseLimit = 64 << 10 // 64 KB
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type HTTPRequestConfig struct {
+	// Client performs the requests. Tests can pass the client of an
+	// httptest.Server here.
+	Client *http.Client
+
+	// MaxResponseBytes caps how much of the response body is stored in the
+	// task result.
+	MaxResponseBytes int
+
+	// RetryableStatusCodes are used when a task doesn't set its own
+	// retryable_codes.
+	RetryableStatusCodes []int
+}
+
+type HTTPRequestProcessor struct {
+	config HTTPRequestConfig
+}
+
+func NewHTTPRequestProcessor(config HTTPRequestConfig) *HTTPRequestProcessor {
+	if config.Client == nil {
+		config.Client = &http.Client{}
+	}
+	if config.MaxResponseBytes <= 0 {
+		config.MaxResponseBytes = defaultHTTPResponseLimit
+	}
+	if config.RetryableStatusCodes == nil {
+		config.RetryableStatusCodes = defaultRetryableStatusCodes
+	}
+
+	return &HTTPRequestProcessor{
+		config: config,
+	}
+}
+
+func (p *HTTPRequestProcessor) PayloadSchema() *jsonschema.Schema {
+	statusCode := jsonschema.Integer("").WithRange(100, 599)
+
+	return jsonschema.Object(map[string]*jsonschema.Schema{
+		"url":             jsonschema.String("http or https URL to call").WithFormat("uri"),
+		"method":          jsonschema.String("HTTP method").WithDefault(http.MethodGet),
+		"headers":         jsonschema.Map(jsonschema.String(""), "Request headers"),
+		"body":            {Description: "Request body; strings are sent as they are, anything else as JSON"},
+		"success_codes":   jsonschema.Array(statusCode, "Statuses that complete the task (default: any 2xx)"),
+		"retryable_codes": jsonschema.Array(statusCode.Clone(), "Statuses that fail the task for a retry"),
+	}, "url")
+}
+
+// Process performs the request described by the payload. Statuses listed in
+// success_codes (default: any 2xx) complete the task, statuses listed in
+// retryable_codes fail it for a retry, and anything else fails it for good.
+func (p *HTTPRequestProcessor) Process(ctx context.Context, task *domain.Task) (map[string]interface{}, error) {
+	payload := task.Payload
+
+	method, _ := payload["method"].(string)
+	rawURL, _ := payload["url"].(string)
+
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, Permanent(fmt.Errorf("invalid url: %q", rawURL))
+	}
+
+	headers, err := stringMap(payload["headers"])
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid headers: %w", err))
+	}
+
+	body, contentType, err := requestBody(payload["body"])
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid body: %w", err))
+	}
+
+	successCodes, err := intSlice(payload["success_codes"])
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid success_codes: %w", err))
+	}
+
+	retryableCodes, err := intSlice(payload["retryable_codes"])
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid retryable_codes: %w", err))
+	}
+	if retryableCodes == nil {
+		retryableCodes = p.config.RetryableStatusCodes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid request: %w", err))
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	// Let the receiver continue the task's trace.
+	tracing.Inject(ctx, req.Header)
+
+	logger := logging.FromContext(ctx).With("method", method, "url", target.Redacted())
+	logger.Info("sending request")
+
+	ReportProgress(ctx, 10, "requesting", method+" "+target.Redacted())
+	startedAt := time.Now()
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("task cancelled during request: %v", ctxErr)
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	ReportProgress(ctx, 60, "reading", fmt.Sprintf("status %d", resp.StatusCode))
+	responseBody := &limitedBuffer{limit: p.config.MaxResponseBytes}
+	if _, err := io.Copy(responseBody, resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	duration := time.Since(startedAt)
+
+	responseHeaders := make(map[string]interface{}, len(resp.Header))
+	for key, values := range resp.Header {
+		responseHeaders[key] = strings.Join(values, ", ")
+	}
+
+	result := map[string]interface{}{
+		"status_code":    resp.StatusCode,
+		"headers":        responseHeaders,
+		"body":           responseBody.String(),
+		"body_truncated": responseBody.truncated,
+		"duration_ms":    duration.Milliseconds(),
+		"completed_at":   time.Now().Format(time.RFC3339),
+	}
+
+	// The response to a failed request is returned with the error, so the
+	// failed task shows what the server said.
+	if !isSuccessStatus(resp.StatusCode, successCodes) {
+		err := fmt.Errorf("request returned status %d", resp.StatusCode)
+		logger.Warn("request failed", "status", resp.StatusCode, "duration_ms", duration.Milliseconds())
+
+		if slices.Contains(retryableCodes, resp.StatusCode) {
+			return result, err
+		}
+		return result, Permanent(err)
+	}
+
+	logger.Info("request succeeded", "status", resp.StatusCode, "duration_ms", duration.Milliseconds())
+	return result, nil
+}
+
+func (p *HTTPRequestProcessor) CanProcess(taskType domain.TaskType) bool {
+	return taskType == domain.TaskTypeHTTPRequest
+}
+
+func isSuccessStatus(status int, successCodes []int) bool {
+	if successCodes == nil {
+		return status >= 200 && status < 300
+	}
+	return slices.Contains(successCodes, status)
+}
+
+// requestBody sends strings as-is and encodes anything else as JSON.
+func requestBody(value interface{}) (io.Reader, string, error) {
+	switch body := value.(type) {
+	case nil:
+		return nil, "", nil
+	case string:
+		return strings.NewReader(body), "", nil
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(data), "application/json", nil
+	}
+}
+
+func intSlice(value interface{}) ([]int, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of numbers")
+	}
+
+	result := make([]int, len(items))
+	for i, item := range items {
+		n, ok := item.(float64)
+		if !ok || n != float64(int(n)) {
+			return nil, fmt.Errorf("item %d is not an integer", i)
+		}
+		result[i] = int(n)
+	}
+
+	return result, nil
+}
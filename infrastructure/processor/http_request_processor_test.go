@@ -0,0 +1,210 @@
+package processor
+
+import (
+	"context"
+	"go-task-queue-system/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func httpRequestTask(url string, extra map[string]interface{}) *domain.Task {
+	payload := map[string]interface{}{"url": url}
+	for key, value := range extra {
+		payload[key] = value
+	}
+	return &domain.Task{ID: "task-1", Type: domain.TaskTypeHTTPRequest, Payload: payload}
+}
+
+func codes(statuses ...int) []interface{} {
+	values := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		values[i] = float64(status)
+	}
+	return values
+}
+
+func TestHTTPRequestProcessorStatuses(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		payload       map[string]interface{}
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent},
+		{name: "service unavailable is retried", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "too many requests is retried", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "not found is permanent", status: http.StatusNotFound, wantErr: true, wantPermanent: true},
+		{name: "bad request is permanent", status: http.StatusBadRequest, wantErr: true, wantPermanent: true},
+		{
+			name:    "custom success code",
+			status:  http.StatusNotFound,
+			payload: map[string]interface{}{"success_codes": codes(http.StatusNotFound)},
+		},
+		{
+			name:          "2xx outside custom success codes",
+			status:        http.StatusOK,
+			payload:       map[string]interface{}{"success_codes": codes(http.StatusCreated)},
+			wantErr:       true,
+			wantPermanent: true,
+		},
+		{
+			name:    "custom retryable code",
+			status:  http.StatusConflict,
+			payload: map[string]interface{}{"retryable_codes": codes(http.StatusConflict)},
+			wantErr: true,
+		},
+		{
+			name:          "custom retryable codes replace the defaults",
+			status:        http.StatusServiceUnavailable,
+			payload:       map[string]interface{}{"retryable_codes": codes(http.StatusConflict)},
+			wantErr:       true,
+			wantPermanent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			p := NewHTTPRequestProcessor(HTTPRequestConfig{Client: server.Client()})
+			result, err := p.Process(context.Background(), httpRequestTask(server.URL, tt.payload))
+
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Process() succeeded, want an error")
+				}
+				if got := IsPermanent(err); got != tt.wantPermanent {
+					t.Errorf("IsPermanent(%v) = %v, want %v", err, got, tt.wantPermanent)
+				}
+			}
+			// Failed requests return the response too.
+			if got := result["status_code"]; got != tt.status {
+				t.Errorf("status_code = %v, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestHTTPRequestProcessorKeepsFailedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Error-Code", "quota")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error":"quota exceeded"}`))
+	}))
+	defer server.Close()
+
+	p := NewHTTPRequestProcessor(HTTPRequestConfig{Client: server.Client()})
+	result, err := p.Process(context.Background(), httpRequestTask(server.URL, nil))
+	if err == nil {
+		t.Fatal("Process() succeeded, want an error")
+	}
+
+	if got := result["body"]; got != `{"error":"quota exceeded"}` {
+		t.Errorf("body = %v", got)
+	}
+	headers, _ := result["headers"].(map[string]interface{})
+	if got := headers["X-Error-Code"]; got != "quota" {
+		t.Errorf("headers[X-Error-Code] = %v, want quota", got)
+	}
+}
+
+func TestHTTPRequestProcessorInvalidURL(t *testing.T) {
+	p := NewHTTPRequestProcessor(HTTPRequestConfig{})
+
+	for _, url := range []string{"", "ftp://example.com/file", "http://", "not a url"} {
+		_, err := p.Process(context.Background(), httpRequestTask(url, nil))
+		if err == nil || !IsPermanent(err) {
+			t.Errorf("Process(%q) error = %v, want a permanent error", url, err)
+		}
+	}
+}
+
+func TestHTTPRequestProcessorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	p := NewHTTPRequestProcessor(HTTPRequestConfig{Client: server.Client()})
+	_, err := p.Process(ctx, httpRequestTask(server.URL, nil))
+	if err == nil {
+		t.Fatal("Process() succeeded, want a timeout")
+	}
+	if IsPermanent(err) {
+		t.Errorf("timeout %v is permanent, want it retried", err)
+	}
+}
+
+func TestHTTPRequestProcessorRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("moved"))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("followed", func(t *testing.T) {
+		p := NewHTTPRequestProcessor(HTTPRequestConfig{Client: server.Client()})
+		result, err := p.Process(context.Background(), httpRequestTask(server.URL+"/old", nil))
+		if err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		if result["status_code"] != http.StatusOK || result["body"] != "moved" {
+			t.Errorf("result = %v, want the redirect target's response", result)
+		}
+	})
+
+	t.Run("not followed", func(t *testing.T) {
+		client := server.Client()
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		p := NewHTTPRequestProcessor(HTTPRequestConfig{Client: client})
+
+		_, err := p.Process(context.Background(), httpRequestTask(server.URL+"/old", nil))
+		if err == nil || !IsPermanent(err) {
+			t.Errorf("Process() error = %v, want a permanent error for the 302", err)
+		}
+
+		result, err := p.Process(context.Background(), httpRequestTask(server.URL+"/old", map[string]interface{}{
+			"success_codes": codes(http.StatusFound),
+		}))
+		if err != nil {
+			t.Fatalf("Process() with 302 in success_codes error = %v", err)
+		}
+		if result["status_code"] != http.StatusFound {
+			t.Errorf("status_code = %v, want %d", result["status_code"], http.StatusFound)
+		}
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		p := NewHTTPRequestProcessor(HTTPRequestConfig{Client: server.Client()})
+		if _, err := p.Process(context.Background(), httpRequestTask(server.URL+"/loop", nil)); err == nil {
+			t.Error("Process() succeeded on a redirect loop")
+		}
+	})
+}
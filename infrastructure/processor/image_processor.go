@@ -1,18 +1,52 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go-task-queue-system/domain"
-	"log"
-	"math/rand"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/logging"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
-type ImageProcessor struct{}
+const (
+	maxSourceImageBytes = 32 << 20 // 32 MB
+	defaultJPEGQuality  = 85
 
-func NewImageProcessor() *ImageProcessor {
-	return &ImageProcessor{}
+	// maxImageDimension caps the requested width and height, and
+	// maxImagePixels any image held in memory: the decoded source and
+	// every resized copy.
+	maxImageDimension = 10000
+	maxImagePixels    = 50_000_000
+)
+
+type ImageProcessor struct {
+	outputDir string
+	// sourceDir is the only directory local image_url paths may point
+	// into; when empty, only http(s) URLs are accepted.
+	sourceDir  string
+	httpClient *http.Client
+}
+
+func NewImageProcessor(outputDir, sourceDir string) *ImageProcessor {
+	return &ImageProcessor{
+		outputDir:  outputDir,
+		sourceDir:  sourceDir,
+		httpClient: &http.Client{},
+	}
 }
 
 func (p *ImageProcessor) Process(ctx context.Context, task *domain.Task) (map[string]interface{}, error) {
@@ -22,52 +56,257 @@ func (p *ImageProcessor) Process(ctx context.Context, task *domain.Task) (map[st
 	width, _ := payload["width"].(float64)
 	height, _ := payload["height"].(float64)
 	format, _ := payload["format"].(string)
+	mode, _ := payload["mode"].(string)
+	quality, _ := payload["quality"].(float64)
+
+	if imageURL == "" {
+		return nil, Permanent(fmt.Errorf("image_url is required"))
+	}
+	if width < 0 || height < 0 || width > maxImageDimension || height > maxImageDimension {
+		return nil, Permanent(fmt.Errorf("width and height must be between 0 and %d", maxImageDimension))
+	}
+
+	resizeMode := ResizeMode(strings.ToLower(mode))
+	if resizeMode == "" {
+		resizeMode = ResizeModeFit
+	}
+	if !resizeMode.IsValid() {
+		return nil, Permanent(fmt.Errorf("unsupported resize mode: %s", mode))
+	}
 
-	log.Printf("🖼️  [Image Processor] Processing task %s", task.ID)
-	log.Printf("   Image URL: %s", imageURL)
-	log.Printf("   Target Size: %.0fx%.0f", width, height)
-	log.Printf("   Format: %s", format)
+	// A requested format is checked before the download; without one the
+	// source format is kept, and every format we decode can be encoded.
+	format = normalizeImageFormat(format)
+	if format != "" && !isSupportedImageFormat(format) {
+		return nil, Permanent(fmt.Errorf("unsupported output format: %s", format))
+	}
 
-	// Simulate downloading image (1 second)
-	log.Printf("   ⬇️  Downloading image...")
-	select {
-	case <-time.After(1 * time.Second):
-	case <-ctx.Done():
-		return nil, fmt.Errorf("task cancelled during download: %v", ctx.Err())
+	logger := logging.FromContext(ctx)
+	logger.Info("processing image", "width", int(width), "height", int(height), "mode", string(resizeMode))
+
+	logger.Debug("loading image", "source", redactSource(imageURL))
+	ReportProgress(ctx, 5, "downloading", redactSource(imageURL))
+	data, err := p.load(ctx, imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
 	}
 
-	// Simulate image processing (3-5 seconds)
-	processingTime := 3 + rand.Intn(3) // 3-5 seconds
-	log.Printf("   ⚙️  Processing image...")
+	ReportProgress(ctx, 35, "decoding", fmt.Sprintf("%d bytes", len(data)))
+	// A small file can decode to a huge image, so check its size first.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to decode image: %w", err))
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, Permanent(fmt.Errorf("source image of %dx%d exceeds %d pixels", config.Width, config.Height, maxImagePixels))
+	}
+	src, sourceFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to decode image: %w", err))
+	}
 
-	select {
-	case <-time.After(time.Duration(processingTime) * time.Second):
-		// Processing completed
-	case <-ctx.Done():
-		return nil, fmt.Errorf("task cancelled during processing: %v", ctx.Err())
+	if format == "" {
+		format = normalizeImageFormat(sourceFormat)
 	}
 
-	// Simulate 15% failure rate for testing retry mechanism
-	if rand.Float32() < 0.15 {
-		log.Printf("❌ [Image Processor] Failed to process image")
-		return nil, fmt.Errorf("image processing failed: invalid image format")
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("task cancelled before processing: %v", err)
 	}
 
-	log.Printf("✅ [Image Processor] Image processed successfully")
+	logger.Debug("resizing image", "source_width", src.Bounds().Dx(), "source_height", src.Bounds().Dy())
+	ReportProgress(ctx, 50, "resizing", fmt.Sprintf("%dx%d to %.0fx%.0f (%s)", src.Bounds().Dx(), src.Bounds().Dy(), width, height, resizeMode))
+	resized, err := resizeImage(src, int(width), int(height), resizeMode)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("image processing failed: %w", err))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("task cancelled during processing: %v", err)
+	}
+
+	ReportProgress(ctx, 75, "encoding", format)
+	var encoded bytes.Buffer
+	if err := encodeImage(&encoded, resized, format, int(quality)); err != nil {
+		return nil, Permanent(fmt.Errorf("image processing failed: %w", err))
+	}
+
+	ReportProgress(ctx, 90, "storing", "")
+	fileName := task.ID + "." + imageExtension(format)
+	processedURL, err := writeOutput(ctx, task, p.outputDir, fileName, "image/"+format, encoded.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to write processed image: %w", err)
+	}
+
+	checksum := sha256.Sum256(encoded.Bytes())
+	bounds := resized.Bounds()
+
+	logger.Info("image processed", "width", bounds.Dx(), "height", bounds.Dy(), "bytes", encoded.Len())
 
-	processedURL := fmt.Sprintf("https://cdn.example.com/processed/%d.%s", time.Now().Unix(), format)
 	result := map[string]interface{}{
-		"processed_url": processedURL,
-		"width":         width,
-		"height":        height,
-		"format":        format,
-		"file_size":     rand.Intn(500) + 100, // 100-600 KB
-		"processed_at":  time.Now().Format(time.RFC3339),
+		"processed_url":  processedURL,
+		"file_name":      fileName,
+		"width":          bounds.Dx(),
+		"height":         bounds.Dy(),
+		"source_width":   src.Bounds().Dx(),
+		"source_height":  src.Bounds().Dy(),
+		"source_format":  sourceFormat,
+		"format":         format,
+		"mode":           string(resizeMode),
+		"file_size":      encoded.Len(),
+		"content_sha256": hex.EncodeToString(checksum[:]),
+		"processed_at":   time.Now().Format(time.RFC3339),
 	}
 
 	return result, nil
 }
 
+func (p *ImageProcessor) PayloadSchema() *jsonschema.Schema {
+	return jsonschema.Object(map[string]*jsonschema.Schema{
+		"image_url": jsonschema.String("Source image: an http(s) URL, or a file: URL or path inside the server's image source directory"),
+		"width":     jsonschema.Integer("Target width in pixels; 0 keeps the aspect ratio").WithRange(0, maxImageDimension),
+		"height":    jsonschema.Integer("Target height in pixels; 0 keeps the aspect ratio").WithRange(0, maxImageDimension),
+		"format":    jsonschema.String("Output format: png, jpeg or gif (default: the source format)"),
+		"mode":      jsonschema.String("Resize mode: fit, fill or crop").WithDefault(string(ResizeModeFit)),
+		"quality":   jsonschema.Integer("JPEG quality").WithRange(1, 100),
+	}, "image_url")
+}
+
 func (p *ImageProcessor) CanProcess(taskType domain.TaskType) bool {
 	return taskType == domain.TaskTypeImageProcessing
 }
+
+// load reads the source image from an http(s) URL, a file:// URL or a plain
+// local path inside the source directory.
+func (p *ImageProcessor) load(ctx context.Context, source string) ([]byte, error) {
+	parsed, err := url.Parse(source)
+	if err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, source)
+		}
+
+		return readLimited(resp.Body, maxSourceImageBytes)
+	}
+
+	path := source
+	if err == nil && parsed.Scheme == "file" {
+		path = parsed.Path
+	}
+	path, err = p.resolveSource(path)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readLimited(file, maxSourceImageBytes)
+}
+
+// resolveSource confines a local path to the source directory. Relative
+// paths are taken relative to it; symlinks are followed before checking.
+func (p *ImageProcessor) resolveSource(path string) (string, error) {
+	if p.sourceDir == "" {
+		return "", fmt.Errorf("local image paths are not allowed")
+	}
+
+	root, err := filepath.Abs(p.sourceDir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", fmt.Errorf("image source directory: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("local image paths must be inside %s", p.sourceDir)
+	}
+	return resolved, nil
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("image exceeds %d bytes", limit)
+	}
+	return data, nil
+}
+
+func normalizeImageFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "jpg" {
+		return "jpeg"
+	}
+	return format
+}
+
+func isSupportedImageFormat(format string) bool {
+	switch format {
+	case "png", "jpeg", "gif":
+		return true
+	}
+	return false
+}
+
+func imageExtension(format string) string {
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
+
+func encodeImage(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg":
+		if quality <= 0 || quality > 100 {
+			quality = defaultJPEGQuality
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "gif":
+		return gif.Encode(w, img, &gif.Options{NumColors: 256})
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// redactSource strips credentials and query strings from an image URL
+// before it is logged or shown in progress.
+func redactSource(source string) string {
+	parsed, err := url.Parse(source)
+	if err != nil || parsed.Scheme == "" {
+		return source
+	}
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.Redacted()
+}
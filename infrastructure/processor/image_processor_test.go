@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"context"
+	"go-task-queue-system/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImageProcessorPermanentErrors(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write([]byte("not an image"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		wantDownload bool
+	}{
+		{name: "missing image_url", payload: map[string]interface{}{}},
+		{name: "unsupported mode", payload: map[string]interface{}{"image_url": server.URL, "mode": "stretch"}},
+		{name: "unsupported format", payload: map[string]interface{}{"image_url": server.URL, "format": "bmp"}},
+		{name: "undecodable image", payload: map[string]interface{}{"image_url": server.URL, "format": "png"}, wantDownload: true},
+	}
+
+	p := NewImageProcessor(t.TempDir(), "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downloads = 0
+			task := &domain.Task{ID: "task-1", Type: domain.TaskTypeImageProcessing, Payload: tt.payload}
+
+			_, err := p.Process(context.Background(), task)
+			if err == nil {
+				t.Fatal("Process() error = nil")
+			}
+			if !IsPermanent(err) {
+				t.Errorf("Process() error %v is retryable, want permanent", err)
+			}
+			if got := downloads > 0; got != tt.wantDownload {
+				t.Errorf("downloaded = %v, want %v", got, tt.wantDownload)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+type ResizeMode string
+
+const (
+	ResizeModeFit  ResizeMode = "fit"
+	ResizeModeFill ResizeMode = "fill"
+	ResizeModeCrop ResizeMode = "crop"
+)
+
+func (m ResizeMode) IsValid() bool {
+	switch m {
+	case ResizeModeFit, ResizeModeFill, ResizeModeCrop:
+		return true
+	default:
+		return false
+	}
+}
+
+// resizeImage scales src to the requested bounds. A zero width or height is
+// derived from the source aspect ratio; both zero keeps the original size.
+//   - fit:  scale down/up to fit inside width x height, keeping aspect ratio
+//   - fill: stretch to exactly width x height
+//   - crop: scale to cover width x height, then center-crop the overflow
+func resizeImage(src image.Image, width, height int, mode ResizeMode) (image.Image, error) {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return nil, fmt.Errorf("source image has no pixels")
+	}
+
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("width and height must not be negative")
+	}
+
+	if width == 0 && height == 0 {
+		return src, nil
+	}
+
+	if width == 0 {
+		width = max(1, srcW*height/srcH)
+	}
+	if height == 0 {
+		height = max(1, srcH*width/srcW)
+	}
+
+	if int64(width)*int64(height) > maxImagePixels {
+		return nil, fmt.Errorf("%dx%d exceeds %d pixels", width, height, maxImagePixels)
+	}
+
+	switch mode {
+	case ResizeModeFill:
+		return scaleBilinear(src, width, height), nil
+
+	case ResizeModeCrop:
+		scale := max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+		scaledW := max(width, int(float64(srcW)*scale+0.5))
+		scaledH := max(height, int(float64(srcH)*scale+0.5))
+		if int64(scaledW)*int64(scaledH) > maxImagePixels {
+			return nil, fmt.Errorf("cropping %dx%d to %dx%d needs more than %d pixels", srcW, srcH, width, height, maxImagePixels)
+		}
+		scaled := scaleBilinear(src, scaledW, scaledH)
+
+		offsetX := (scaledW - width) / 2
+		offsetY := (scaledH - height) / 2
+		cropped := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(cropped, cropped.Bounds(), scaled, image.Pt(offsetX, offsetY), draw.Src)
+		return cropped, nil
+
+	case ResizeModeFit, "":
+		scale := min(float64(width)/float64(srcW), float64(height)/float64(srcH))
+		fitW := max(1, int(float64(srcW)*scale+0.5))
+		fitH := max(1, int(float64(srcH)*scale+0.5))
+		return scaleBilinear(src, fitW, fitH), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported resize mode: %s", mode)
+	}
+}
+
+func scaleBilinear(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	// Work on an RGBA copy so pixel reads don't go through the color model
+	// conversion of paletted or YCbCr sources on every sample.
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+		draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	} else if bounds.Min != (image.Point{}) {
+		shifted := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+		draw.Draw(shifted, shifted.Bounds(), src, bounds.Min, draw.Src)
+		rgba = shifted
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xRatio := float64(srcW) / float64(width)
+	yRatio := float64(srcH) / float64(height)
+
+	for y := 0; y < height; y++ {
+		sy := (float64(y)+0.5)*yRatio - 0.5
+		y0 := clampInt(int(sy), 0, srcH-1)
+		y1 := clampInt(y0+1, 0, srcH-1)
+		fy := sy - float64(y0)
+		if fy < 0 {
+			fy = 0
+		}
+
+		for x := 0; x < width; x++ {
+			sx := (float64(x)+0.5)*xRatio - 0.5
+			x0 := clampInt(int(sx), 0, srcW-1)
+			x1 := clampInt(x0+1, 0, srcW-1)
+			fx := sx - float64(x0)
+			if fx < 0 {
+				fx = 0
+			}
+
+			c00 := rgba.RGBAAt(x0, y0)
+			c10 := rgba.RGBAAt(x1, y0)
+			c01 := rgba.RGBAAt(x0, y1)
+			c11 := rgba.RGBAAt(x1, y1)
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: lerpChannel(c00.R, c10.R, c01.R, c11.R, fx, fy),
+				G: lerpChannel(c00.G, c10.G, c01.G, c11.G, fx, fy),
+				B: lerpChannel(c00.B, c10.B, c01.B, c11.B, fx, fy),
+				A: lerpChannel(c00.A, c10.A, c01.A, c11.A, fx, fy),
+			})
+		}
+	}
+
+	return dst
+}
+
+func lerpChannel(c00, c10, c01, c11 uint8, fx, fy float64) uint8 {
+	top := float64(c00)*(1-fx) + float64(c10)*fx
+	bottom := float64(c01)*(1-fx) + float64(c11)*fx
+	return uint8(top*(1-fy) + bottom*fy + 0.5)
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
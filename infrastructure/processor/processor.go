@@ -3,14 +3,24 @@ package processor
 import (
 	"context"
 	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/jsonschema"
 )
 
 type TaskProcessor interface {
+	// Process runs the task. A processor may return a result along with an
+	// error, e.g. the response to a failed request; it is kept on the
+	// failed task.
 	Process(ctx context.Context, task *domain.Task) (map[string]interface{}, error)
 
 	CanProcess(taskType domain.TaskType) bool
 }
 
+// PayloadSchemaProvider is implemented by processors that describe the
+// payload they accept. Submitted payloads are validated against it.
+type PayloadSchemaProvider interface {
+	PayloadSchema() *jsonschema.Schema
+}
+
 type ProcessorRegistry struct {
 	processors map[domain.TaskType]TaskProcessor
 }
@@ -34,3 +44,25 @@ func (r *ProcessorRegistry) HasProcessor(taskType domain.TaskType) bool {
 	_, exists := r.processors[taskType]
 	return exists
 }
+
+// TaskTypes returns the task types that have a processor, in no particular
+// order.
+func (r *ProcessorRegistry) TaskTypes() []domain.TaskType {
+	taskTypes := make([]domain.TaskType, 0, len(r.processors))
+	for taskType := range r.processors {
+		taskTypes = append(taskTypes, taskType)
+	}
+	return taskTypes
+}
+
+// PayloadSchemas returns the payload schema of every registered processor
+// that provides one.
+func (r *ProcessorRegistry) PayloadSchemas() map[domain.TaskType]*jsonschema.Schema {
+	schemas := make(map[domain.TaskType]*jsonschema.Schema, len(r.processors))
+	for taskType, processor := range r.processors {
+		if provider, ok := processor.(PayloadSchemaProvider); ok {
+			schemas[taskType] = provider.PayloadSchema()
+		}
+	}
+	return schemas
+}
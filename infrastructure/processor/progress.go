@@ -0,0 +1,26 @@
+package processor
+
+import "context"
+
+// ProgressReporter receives progress updates from a running processor.
+type ProgressReporter interface {
+	ReportProgress(percent int, stage, message string)
+}
+
+type progressReporterKey struct{}
+
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+func ProgressReporterFromContext(ctx context.Context) (ProgressReporter, bool) {
+	reporter, ok := ctx.Value(progressReporterKey{}).(ProgressReporter)
+	return reporter, ok
+}
+
+// ReportProgress forwards an update to the reporter in ctx, if there is one.
+func ReportProgress(ctx context.Context, percent int, stage, message string) {
+	if reporter, ok := ProgressReporterFromContext(ctx); ok {
+		reporter.ReportProgress(percent, stage, message)
+	}
+}
@@ -0,0 +1,246 @@
+package processor
+
+import (
+	"fmt"
+	"go-task-queue-system/domain"
+	"math"
+	"sort"
+	"time"
+)
+
+type ReportType string
+
+const (
+	ReportTypeThroughput ReportType = "throughput"
+	ReportTypeFailures   ReportType = "failures"
+	ReportTypeLatency    ReportType = "latency"
+)
+
+func (t ReportType) IsValid() bool {
+	switch t {
+	case ReportTypeThroughput, ReportTypeFailures, ReportTypeLatency:
+		return true
+	default:
+		return false
+	}
+}
+
+type reportTable struct {
+	Title   string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// buildReport aggregates the given tasks, already filtered to the report
+// period, into a table for the requested report type.
+func buildReport(reportType ReportType, tasks []*domain.Task) (*reportTable, error) {
+	switch reportType {
+	case ReportTypeThroughput:
+		return buildThroughputReport(tasks), nil
+	case ReportTypeFailures:
+		return buildFailuresReport(tasks), nil
+	case ReportTypeLatency:
+		return buildLatencyReport(tasks), nil
+	default:
+		return nil, fmt.Errorf("unsupported report type: %s", reportType)
+	}
+}
+
+func buildThroughputReport(tasks []*domain.Task) *reportTable {
+	type dayCounts struct {
+		submitted, completed, failed, cancelled, expired int
+	}
+
+	days := make(map[string]*dayCounts)
+	for _, task := range tasks {
+		day := task.CreatedAt.Format("2006-01-02")
+		counts, ok := days[day]
+		if !ok {
+			counts = &dayCounts{}
+			days[day] = counts
+		}
+
+		counts.submitted++
+		switch task.Status {
+		case domain.TaskStatusCompleted:
+			counts.completed++
+		case domain.TaskStatusFailed:
+			counts.failed++
+		case domain.TaskStatusCancelled:
+			counts.cancelled++
+		case domain.TaskStatusExpired:
+			counts.expired++
+		}
+	}
+
+	table := &reportTable{
+		Title:   "Task throughput per day",
+		Columns: []string{"date", "submitted", "completed", "failed", "cancelled", "expired"},
+	}
+
+	for _, day := range sortedKeys(days) {
+		counts := days[day]
+		table.Rows = append(table.Rows, []interface{}{
+			day, counts.submitted, counts.completed, counts.failed, counts.cancelled, counts.expired,
+		})
+	}
+
+	return table
+}
+
+func buildFailuresReport(tasks []*domain.Task) *reportTable {
+	type typeFailures struct {
+		total, failed, deadLettered int
+		errors                      map[string]int
+	}
+
+	byType := make(map[string]*typeFailures)
+	for _, task := range tasks {
+		key := task.Type.String()
+		entry, ok := byType[key]
+		if !ok {
+			entry = &typeFailures{errors: make(map[string]int)}
+			byType[key] = entry
+		}
+
+		entry.total++
+		if task.Status != domain.TaskStatusFailed {
+			continue
+		}
+
+		entry.failed++
+		if task.IsInDeadLetterQueue() {
+			entry.deadLettered++
+		}
+		if task.Error != "" {
+			entry.errors[task.Error]++
+		}
+	}
+
+	table := &reportTable{
+		Title:   "Failures by task type",
+		Columns: []string{"task_type", "total", "failed", "failure_rate", "dead_lettered", "top_error"},
+	}
+
+	for _, taskType := range sortedKeys(byType) {
+		entry := byType[taskType]
+
+		topError, topCount := "", 0
+		for _, message := range sortedKeys(entry.errors) {
+			if entry.errors[message] > topCount {
+				topError, topCount = message, entry.errors[message]
+			}
+		}
+
+		rate := 0.0
+		if entry.total > 0 {
+			rate = float64(entry.failed) / float64(entry.total)
+		}
+
+		table.Rows = append(table.Rows, []interface{}{
+			taskType, entry.total, entry.failed, roundTo(rate, 4), entry.deadLettered, topError,
+		})
+	}
+
+	return table
+}
+
+func buildLatencyReport(tasks []*domain.Task) *reportTable {
+	type latencies struct {
+		wait, run []float64
+	}
+
+	// Wait runs from submission to the first attempt, so retries don't
+	// hide it; run is the attempt that completed the task.
+	byType := make(map[string]*latencies)
+	for _, task := range tasks {
+		firstStarted := task.FirstStartedAt()
+		if firstStarted == nil {
+			continue
+		}
+
+		key := task.Type.String()
+		entry, ok := byType[key]
+		if !ok {
+			entry = &latencies{}
+			byType[key] = entry
+		}
+
+		entry.wait = append(entry.wait, durationMillis(firstStarted.Sub(task.CreatedAt)))
+		if task.CompletedAt != nil && task.StartedAt != nil {
+			entry.run = append(entry.run, durationMillis(task.CompletedAt.Sub(*task.StartedAt)))
+		}
+	}
+
+	table := &reportTable{
+		Title: "Latency summary by task type (milliseconds)",
+		Columns: []string{
+			"task_type", "started", "completed",
+			"wait_avg_ms", "wait_p50_ms", "wait_p95_ms", "wait_max_ms",
+			"run_avg_ms", "run_p50_ms", "run_p95_ms", "run_max_ms",
+		},
+	}
+
+	for _, taskType := range sortedKeys(byType) {
+		entry := byType[taskType]
+		wait := summarize(entry.wait)
+		run := summarize(entry.run)
+
+		table.Rows = append(table.Rows, []interface{}{
+			taskType, len(entry.wait), len(entry.run),
+			wait.avg, wait.p50, wait.p95, wait.max,
+			run.avg, run.p50, run.p95, run.max,
+		})
+	}
+
+	return table
+}
+
+type latencySummary struct {
+	avg, p50, p95, max float64
+}
+
+func summarize(values []float64) latencySummary {
+	if len(values) == 0 {
+		return latencySummary{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	total := 0.0
+	for _, v := range sorted {
+		total += v
+	}
+
+	return latencySummary{
+		avg: roundTo(total/float64(len(sorted)), 2),
+		p50: roundTo(percentile(sorted, 0.50), 2),
+		p95: roundTo(percentile(sorted, 0.95), 2),
+		max: roundTo(sorted[len(sorted)-1], 2),
+	}
+}
+
+// percentile uses the nearest-rank method on an already sorted slice.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[clampInt(rank, 0, len(sorted)-1)]
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func roundTo(v float64, places int) float64 {
+	factor := math.Pow10(places)
+	return math.Round(v*factor) / factor
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,21 +1,32 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"go-task-queue-system/domain"
-	"log"
-	"math/rand"
+	"go-task-queue-system/infrastructure/jsonschema"
+	"go-task-queue-system/infrastructure/logging"
+	"html/template"
+	"strings"
 	"time"
 )
 
-type ReportProcessor struct{}
+type ReportProcessor struct {
+	repository domain.TaskRepository
+	outputDir  string
+}
 
-func NewReportProcessor() *ReportProcessor {
-	return &ReportProcessor{}
+func NewReportProcessor(repository domain.TaskRepository, outputDir string) *ReportProcessor {
+	return &ReportProcessor{
+		repository: repository,
+		outputDir:  outputDir,
+	}
 }
 
-// Process simulates generating a report (PDF, CSV, etc.)
+// Process generates a report over the task history and stores the rendered file.
 func (p *ReportProcessor) Process(ctx context.Context, task *domain.Task) (map[string]interface{}, error) {
 	payload := task.Payload
 
@@ -24,51 +35,227 @@ func (p *ReportProcessor) Process(ctx context.Context, task *domain.Task) (map[s
 	endDate, _ := payload["end_date"].(string)
 	format, _ := payload["format"].(string)
 
-	log.Printf("📊 [Report Processor] Processing task %s", task.ID)
-	log.Printf("   Report Type: %s", reportType)
-	log.Printf("   Period: %s to %s", startDate, endDate)
-	log.Printf("   Format: %s", format)
+	if reportType == "" {
+		reportType = string(ReportTypeThroughput)
+	}
+	if !ReportType(reportType).IsValid() {
+		return nil, Permanent(fmt.Errorf("unsupported report type: %s", reportType))
+	}
+
+	format = strings.ToLower(format)
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" && format != "html" {
+		return nil, Permanent(fmt.Errorf("unsupported report format: %s", format))
+	}
+
+	start, err := parseReportDate(startDate, false)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid start_date: %w", err))
+	}
+	end, err := parseReportDate(endDate, true)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid end_date: %w", err))
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return nil, Permanent(fmt.Errorf("end_date is before start_date"))
+	}
+
+	logger := logging.FromContext(ctx)
+	logger.Info("generating report", "report_type", reportType, "start_date", startDate, "end_date", endDate, "format", format)
+	ReportProgress(ctx, 10, "fetching", "Loading task history")
+	allTasks, err := p.repository.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("report generation failed: %w", err)
+	}
+
+	tasks := make([]*domain.Task, 0, len(allTasks))
+	for _, t := range allTasks {
+		if t.ID == task.ID || t.Tenant != task.Tenant {
+			continue
+		}
+		if !start.IsZero() && t.CreatedAt.Before(start) {
+			continue
+		}
+		if !end.IsZero() && !t.CreatedAt.Before(end) {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
 
-	// Simulate data fetching (2 seconds)
-	log.Printf("   📥 Fetching data...")
-	select {
-	case <-time.After(2 * time.Second):
-	case <-ctx.Done():
-		return nil, fmt.Errorf("task cancelled during data fetch: %v", ctx.Err())
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("task cancelled during data fetch: %v", err)
 	}
 
-	// Simulate report generation (4-6 seconds)
-	processingTime := 4 + rand.Intn(3) // 4-6 seconds
-	log.Printf("   📝 Generating report...")
+	logger.Debug("aggregating tasks", "tasks", len(tasks))
+	ReportProgress(ctx, 40, "generating", fmt.Sprintf("%d tasks in period", len(tasks)))
+	table, err := buildReport(ReportType(reportType), tasks)
+	if err != nil {
+		return nil, fmt.Errorf("report generation failed: %w", err)
+	}
 
-	select {
-	case <-time.After(time.Duration(processingTime) * time.Second):
-		// Processing completed
-	case <-ctx.Done():
-		return nil, fmt.Errorf("task cancelled during generation: %v", ctx.Err())
+	generatedAt := time.Now()
+	meta := reportMeta{
+		ReportType:  reportType,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		GeneratedAt: generatedAt.Format(time.RFC3339),
+		TaskCount:   len(tasks),
 	}
 
-	// Simulate 5% failure rate for testing retry mechanism
-	if rand.Float32() < 0.05 {
-		log.Printf("❌ [Report Processor] Failed to generate report")
-		return nil, fmt.Errorf("report generation failed: insufficient data")
+	var rendered bytes.Buffer
+	if err := renderReport(&rendered, format, meta, table); err != nil {
+		return nil, fmt.Errorf("report generation failed: %w", err)
 	}
 
-	log.Printf("✅ [Report Processor] Report generated successfully")
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("task cancelled during generation: %v", err)
+	}
+
+	ReportProgress(ctx, 85, "storing", fmt.Sprintf("%d bytes", rendered.Len()))
+	fileName := fmt.Sprintf("%s-%s.%s", reportType, task.ID, format)
+	reportURL, err := writeOutput(ctx, task, p.outputDir, fileName, reportContentTypes[format], rendered.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to write report: %w", err)
+	}
+
+	logger.Info("report generated", "rows", len(table.Rows), "bytes", rendered.Len())
 
-	reportURL := fmt.Sprintf("https://reports.example.com/%s-%d.%s", reportType, time.Now().Unix(), format)
 	result := map[string]interface{}{
 		"report_url":    reportURL,
+		"file_name":     fileName,
 		"report_type":   reportType,
 		"format":        format,
-		"total_records": rand.Intn(10000) + 1000, // 1000-11000 records
-		"file_size":     rand.Intn(5000) + 500,   // 500-5500 KB
-		"generated_at":  time.Now().Format(time.RFC3339),
+		"total_records": len(tasks),
+		"row_count":     len(table.Rows),
+		"file_size":     rendered.Len(),
+		"generated_at":  generatedAt.Format(time.RFC3339),
 	}
 
 	return result, nil
 }
 
+func (p *ReportProcessor) PayloadSchema() *jsonschema.Schema {
+	return jsonschema.Object(map[string]*jsonschema.Schema{
+		"report_type": jsonschema.Enum("What to report on",
+			string(ReportTypeThroughput), string(ReportTypeFailures), string(ReportTypeLatency)).WithDefault(string(ReportTypeThroughput)),
+		"start_date": jsonschema.String("Start of the period, YYYY-MM-DD or RFC 3339"),
+		"end_date":   jsonschema.String("End of the period, YYYY-MM-DD or RFC 3339"),
+		"format":     jsonschema.String("Output format: csv, json or html").WithDefault("csv"),
+	})
+}
+
 func (p *ReportProcessor) CanProcess(taskType domain.TaskType) bool {
 	return taskType == domain.TaskTypeReportGeneration
 }
+
+// parseReportDate accepts RFC3339 timestamps or plain dates. A plain end
+// date is inclusive, so it is moved to the start of the following day.
+func parseReportDate(value string, endOfRange bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339, got %q", value)
+	}
+
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+var reportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json",
+	"html": "text/html; charset=utf-8",
+}
+
+type reportMeta struct {
+	ReportType  string `json:"report_type"`
+	StartDate   string `json:"start_date,omitempty"`
+	EndDate     string `json:"end_date,omitempty"`
+	GeneratedAt string `json:"generated_at"`
+	TaskCount   int    `json:"task_count"`
+}
+
+func renderReport(buf *bytes.Buffer, format string, meta reportMeta, table *reportTable) error {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(buf)
+		if err := writer.Write(table.Columns); err != nil {
+			return err
+		}
+		for _, row := range table.Rows {
+			record := make([]string, len(row))
+			for i, value := range row {
+				record[i] = fmt.Sprint(value)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case "json":
+		rows := make([]map[string]interface{}, len(table.Rows))
+		for i, row := range table.Rows {
+			rows[i] = make(map[string]interface{}, len(row))
+			for j, value := range row {
+				rows[i][table.Columns[j]] = value
+			}
+		}
+
+		encoder := json.NewEncoder(buf)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"report": meta,
+			"title":  table.Title,
+			"rows":   rows,
+		})
+
+	case "html":
+		return reportHTMLTemplate.Execute(buf, map[string]interface{}{
+			"Meta":  meta,
+			"Table": table,
+		})
+
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Table.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+</style>
+</head>
+<body>
+<h1>{{.Table.Title}}</h1>
+<p>Report type: {{.Meta.ReportType}}{{if .Meta.StartDate}} &middot; from {{.Meta.StartDate}}{{end}}{{if .Meta.EndDate}} &middot; to {{.Meta.EndDate}}{{end}}</p>
+<p>Generated at {{.Meta.GeneratedAt}} from {{.Meta.TaskCount}} tasks</p>
+<table>
+<thead><tr>{{range .Table.Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Table.Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"context"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"testing"
+	"time"
+)
+
+func TestReportProcessorPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+	}{
+		{name: "unsupported type", payload: map[string]interface{}{"report_type": "revenue"}},
+		{name: "unsupported format", payload: map[string]interface{}{"format": "pdf"}},
+		{name: "bad start date", payload: map[string]interface{}{"start_date": "yesterday"}},
+		{name: "bad end date", payload: map[string]interface{}{"end_date": "2024-13-01"}},
+		{name: "reversed range", payload: map[string]interface{}{"start_date": "2024-02-01", "end_date": "2024-01-01"}},
+	}
+
+	p := NewReportProcessor(repository.NewMemoryRepository(), t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &domain.Task{ID: "task-1", Type: domain.TaskTypeReportGeneration, Payload: tt.payload}
+
+			_, err := p.Process(context.Background(), task)
+			if err == nil {
+				t.Fatal("Process() error = nil")
+			}
+			if !IsPermanent(err) {
+				t.Errorf("Process() error %v is retryable, want permanent", err)
+			}
+		})
+	}
+}
+
+func TestLatencyReportWaitsUntilFirstAttempt(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	firstStart := created.Add(2 * time.Second)
+	retryStart := created.Add(time.Minute)
+	completed := retryStart.Add(3 * time.Second)
+
+	task := &domain.Task{
+		ID:          "task-1",
+		Type:        domain.TaskTypeEmail,
+		Status:      domain.TaskStatusCompleted,
+		CreatedAt:   created,
+		StartedAt:   &retryStart,
+		CompletedAt: &completed,
+		Transitions: []domain.StatusTransition{
+			{From: domain.TaskStatusPending, To: domain.TaskStatusProcessing, At: firstStart},
+			{From: domain.TaskStatusProcessing, To: domain.TaskStatusFailed, At: firstStart.Add(time.Second)},
+			{From: domain.TaskStatusFailed, To: domain.TaskStatusPending, At: firstStart.Add(time.Second)},
+			{From: domain.TaskStatusPending, To: domain.TaskStatusProcessing, At: retryStart},
+			{From: domain.TaskStatusProcessing, To: domain.TaskStatusCompleted, At: completed},
+		},
+	}
+
+	row := buildLatencyReport([]*domain.Task{task}).Rows[0]
+	if waitMax := row[6]; waitMax != 2000.0 {
+		t.Errorf("wait_max_ms = %v, want 2000 (until the first attempt)", waitMax)
+	}
+	if runMax := row[10]; runMax != 3000.0 {
+		t.Errorf("run_max_ms = %v, want 3000 (the completing attempt)", runMax)
+	}
+}
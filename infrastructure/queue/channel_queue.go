@@ -22,7 +22,7 @@ func (q *ChannelQueue) Enqueue(task *domain.Task) error {
 	case q.taskChan <- task:
 		return nil
 	default:
-		return errors.New("queue is full")
+		return domain.ErrQueueFull
 	}
 }
 
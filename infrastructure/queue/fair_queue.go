@@ -0,0 +1,317 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/usecase"
+	"sort"
+	"sync"
+)
+
+// shareWindow is how many recent dispatches the reported shares cover.
+const shareWindow = 1000
+
+// GroupKeyFunc decides which group a task is scheduled in.
+type GroupKeyFunc func(task *domain.Task) string
+
+func GroupByTenant(task *domain.Task) string {
+	return task.Tenant
+}
+
+func GroupByType(task *domain.Task) string {
+	return task.Type.String()
+}
+
+func GroupBySubmitter(task *domain.Task) string {
+	return task.SubmittedBy
+}
+
+func GroupKeyFuncByName(name string) (GroupKeyFunc, error) {
+	switch name {
+	case "tenant", "":
+		return GroupByTenant, nil
+	case "type":
+		return GroupByType, nil
+	case "submitted_by":
+		return GroupBySubmitter, nil
+	default:
+		return nil, fmt.Errorf("unknown fair-share group key: %q", name)
+	}
+}
+
+type taskGroup struct {
+	key           string
+	tasks         []*domain.Task
+	currentWeight int
+}
+
+// FairQueue keeps a FIFO per group of tasks and hands tasks to workers
+// using smooth weighted round-robin between the groups that have work, so
+// one busy group can't starve the others. Groups without a configured
+// weight get weight 1.
+type FairQueue struct {
+	capacity int
+	groupKey GroupKeyFunc
+	weights  map[string]int
+
+	groups map[string]*taskGroup
+	order  []string
+	queued int
+
+	// recent holds the groups of the last shareWindow dispatches.
+	recent     []string
+	recentNext int
+
+	out    chan *domain.Task
+	closed bool
+	mu     sync.Mutex
+	cond   *sync.Cond
+}
+
+func NewFairQueue(capacity int, groupKey GroupKeyFunc, weights map[string]int) *FairQueue {
+	if groupKey == nil {
+		groupKey = GroupByTenant
+	}
+
+	q := &FairQueue{
+		capacity: capacity,
+		groupKey: groupKey,
+		weights:  copyWeights(weights),
+		groups:   make(map[string]*taskGroup),
+		out:      make(chan *domain.Task),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	go q.dispatch()
+
+	return q
+}
+
+func (q *FairQueue) Enqueue(task *domain.Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return errors.New("queue is closed")
+	}
+
+	if q.queued >= q.capacity {
+		return domain.ErrQueueFull
+	}
+
+	key := q.groupKey(task)
+	group, ok := q.groups[key]
+	if !ok {
+		group = &taskGroup{key: key}
+		q.groups[key] = group
+		q.order = append(q.order, key)
+	}
+
+	group.tasks = append(group.tasks, task)
+	q.queued++
+	q.cond.Signal()
+
+	return nil
+}
+
+func (q *FairQueue) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queued
+}
+
+func (q *FairQueue) GetChannel() <-chan *domain.Task {
+	return q.out
+}
+
+// Close stops accepting tasks. Tasks already queued are still handed out,
+// then the channel is closed.
+func (q *FairQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *FairQueue) Capacity() int {
+	return q.capacity
+}
+
+func (q *FairQueue) IsFull() bool {
+	return q.Size() >= q.capacity
+}
+
+func (q *FairQueue) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// GroupOf returns the group task is scheduled in.
+func (q *FairQueue) GroupOf(task *domain.Task) string {
+	return q.groupKey(task)
+}
+
+// WeightOf returns the weight of group.
+func (q *FairQueue) WeightOf(group string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.weightOf(group)
+}
+
+// SetWeights replaces the group weights, e.g. after a configuration reload.
+func (q *FairQueue) SetWeights(weights map[string]int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.weights = copyWeights(weights)
+}
+
+func (q *FairQueue) Shares() []usecase.QueueShare {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dispatched := make(map[string]int)
+	for _, key := range q.recent {
+		dispatched[key]++
+	}
+
+	activeWeight := 0
+	for _, group := range q.groups {
+		if len(group.tasks) > 0 {
+			activeWeight += q.weightOf(group.key)
+		}
+	}
+
+	keys := make(map[string]bool, len(q.groups)+len(dispatched))
+	for key, group := range q.groups {
+		if len(group.tasks) > 0 {
+			keys[key] = true
+		}
+	}
+	for key := range dispatched {
+		keys[key] = true
+	}
+
+	shares := make([]usecase.QueueShare, 0, len(keys))
+	for key := range keys {
+		share := usecase.QueueShare{
+			Group:      key,
+			Weight:     q.weightOf(key),
+			Dispatched: dispatched[key],
+		}
+		if group, ok := q.groups[key]; ok {
+			share.Queued = len(group.tasks)
+		}
+		if len(q.recent) > 0 {
+			share.Share = float64(share.Dispatched) / float64(len(q.recent))
+		}
+		if share.Queued > 0 && activeWeight > 0 {
+			share.TargetShare = float64(share.Weight) / float64(activeWeight)
+		}
+		shares = append(shares, share)
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].Group < shares[j].Group
+	})
+
+	return shares
+}
+
+func (q *FairQueue) dispatch() {
+	for {
+		q.mu.Lock()
+		for q.queued == 0 && !q.closed {
+			q.cond.Wait()
+		}
+
+		if q.queued == 0 && q.closed {
+			q.mu.Unlock()
+			close(q.out)
+			return
+		}
+
+		task := q.next()
+		q.mu.Unlock()
+
+		q.out <- task
+	}
+}
+
+// next pops the head of the group picked by smooth weighted round-robin:
+// every group with work gains its weight, the richest group is picked and
+// pays back the total. Must be called with mu held and queued > 0.
+func (q *FairQueue) next() *domain.Task {
+	var picked *taskGroup
+	totalWeight := 0
+
+	for _, key := range q.order {
+		group := q.groups[key]
+		if len(group.tasks) == 0 {
+			continue
+		}
+
+		weight := q.weightOf(key)
+		group.currentWeight += weight
+		totalWeight += weight
+
+		if picked == nil || group.currentWeight > picked.currentWeight {
+			picked = group
+		}
+	}
+
+	picked.currentWeight -= totalWeight
+
+	task := picked.tasks[0]
+	picked.tasks[0] = nil
+	picked.tasks = picked.tasks[1:]
+	q.queued--
+
+	if len(picked.tasks) == 0 {
+		// Idle groups start from scratch instead of banking credit.
+		picked.currentWeight = 0
+		q.removeGroup(picked.key)
+	}
+
+	q.recordDispatch(picked.key)
+
+	return task
+}
+
+func (q *FairQueue) removeGroup(key string) {
+	delete(q.groups, key)
+	for i, existing := range q.order {
+		if existing == key {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *FairQueue) recordDispatch(key string) {
+	if len(q.recent) < shareWindow {
+		q.recent = append(q.recent, key)
+		return
+	}
+
+	q.recent[q.recentNext] = key
+	q.recentNext = (q.recentNext + 1) % shareWindow
+}
+
+func (q *FairQueue) weightOf(key string) int {
+	if weight, ok := q.weights[key]; ok && weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+func copyWeights(weights map[string]int) map[string]int {
+	copied := make(map[string]int, len(weights))
+	for key, weight := range weights {
+		copied[key] = weight
+	}
+	return copied
+}
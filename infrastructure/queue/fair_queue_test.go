@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"go-task-queue-system/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStoppedFairQueue returns a FairQueue without its dispatch goroutine,
+// so tests can call next and see the exact order.
+func newStoppedFairQueue(capacity int, weights map[string]int) *FairQueue {
+	q := &FairQueue{
+		capacity: capacity,
+		groupKey: GroupByTenant,
+		weights:  copyWeights(weights),
+		groups:   make(map[string]*taskGroup),
+		out:      make(chan *domain.Task),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func enqueueFor(t *testing.T, q *FairQueue, tenant string, count int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		task, err := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, map[string]interface{}{"to": "ops@example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		task.Tenant = tenant
+		if err := q.Enqueue(task); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+}
+
+func dispatchOrder(q *FairQueue, count int) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var order []string
+	for i := 0; i < count && q.queued > 0; i++ {
+		order = append(order, q.next().Tenant)
+	}
+	return order
+}
+
+func assertOrder(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("dispatch order = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dispatch order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFairQueueAlternatesEqualWeights(t *testing.T) {
+	q := newStoppedFairQueue(10, nil)
+	enqueueFor(t, q, "acme", 3)
+	enqueueFor(t, q, "globex", 3)
+
+	assertOrder(t, dispatchOrder(q, 6), "acme", "globex", "acme", "globex", "acme", "globex")
+}
+
+func TestFairQueueFollowsWeights(t *testing.T) {
+	q := newStoppedFairQueue(10, map[string]int{"acme": 3})
+	enqueueFor(t, q, "acme", 4)
+	enqueueFor(t, q, "globex", 4)
+
+	// Smooth weighted round-robin spreads globex's turn out instead of
+	// giving acme three turns in a row.
+	assertOrder(t, dispatchOrder(q, 8), "acme", "acme", "globex", "acme", "acme", "globex", "globex", "globex")
+}
+
+func TestFairQueueIdleGroupStartsFromScratch(t *testing.T) {
+	q := newStoppedFairQueue(10, nil)
+	enqueueFor(t, q, "acme", 1)
+	enqueueFor(t, q, "globex", 3)
+
+	assertOrder(t, dispatchOrder(q, 2), "acme", "globex")
+
+	// acme went idle; coming back it doesn't get turns it missed.
+	enqueueFor(t, q, "acme", 2)
+	assertOrder(t, dispatchOrder(q, 4), "globex", "acme", "globex", "acme")
+}
+
+func TestFairQueueRejectsWhenFull(t *testing.T) {
+	q := newStoppedFairQueue(2, nil)
+	enqueueFor(t, q, "acme", 2)
+
+	task, _ := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, map[string]interface{}{"to": "ops@example.com"})
+	if err := q.Enqueue(task); err == nil {
+		t.Error("Enqueue() past capacity succeeded")
+	}
+	if !q.IsFull() {
+		t.Error("IsFull() = false at capacity")
+	}
+}
+
+func TestFairQueueShares(t *testing.T) {
+	q := newStoppedFairQueue(10, map[string]int{"acme": 3})
+	enqueueFor(t, q, "acme", 4)
+	enqueueFor(t, q, "globex", 4)
+	dispatchOrder(q, 4)
+
+	shares := q.Shares()
+	if len(shares) != 2 {
+		t.Fatalf("Shares() = %+v, want acme and globex", shares)
+	}
+
+	acme, globex := shares[0], shares[1]
+	if acme.Group != "acme" || globex.Group != "globex" {
+		t.Fatalf("Shares() aren't sorted by group: %+v", shares)
+	}
+	if acme.Weight != 3 || acme.Queued != 1 || acme.Dispatched != 3 || acme.Share != 0.75 || acme.TargetShare != 0.75 {
+		t.Errorf("acme share = %+v", acme)
+	}
+	if globex.Weight != 1 || globex.Queued != 3 || globex.Dispatched != 1 || globex.Share != 0.25 || globex.TargetShare != 0.25 {
+		t.Errorf("globex share = %+v", globex)
+	}
+
+	// A group without queued tasks keeps its dispatch history but has no
+	// target share.
+	dispatchOrder(q, 1)
+	for _, share := range q.Shares() {
+		if share.Group == "acme" && (share.Queued != 0 || share.TargetShare != 0 || share.Dispatched != 4) {
+			t.Errorf("drained acme share = %+v", share)
+		}
+	}
+}
+
+func TestFairQueueCloseDrainsQueuedTasks(t *testing.T) {
+	q := NewFairQueue(10, GroupByTenant, nil)
+	enqueueFor(t, q, "acme", 2)
+	q.Close()
+
+	task, _ := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, map[string]interface{}{"to": "ops@example.com"})
+	if err := q.Enqueue(task); err == nil {
+		t.Error("Enqueue() after Close succeeded")
+	}
+
+	received := 0
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-q.GetChannel():
+			if !ok {
+				if received != 2 {
+					t.Errorf("received %d tasks before the channel closed, want 2", received)
+				}
+				return
+			}
+			received++
+		case <-timeout:
+			t.Fatal("channel wasn't closed after the queue drained")
+		}
+	}
+}
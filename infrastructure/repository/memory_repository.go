@@ -6,13 +6,25 @@ import (
 )
 
 type MemoryRepository struct {
-	tasks map[string]*domain.Task
-	mu    sync.RWMutex
+	tasks    map[string]*domain.Task
+	attempts map[string][]*domain.TaskAttempt
+	mu       sync.RWMutex
+
+	// byUniqueKey indexes the IDs of tasks with a UniqueKey by tenant and
+	// key, so duplicate checks don't scan every task.
+	byUniqueKey map[uniqueKeyIndex]map[string]struct{}
+}
+
+type uniqueKeyIndex struct {
+	tenant    string
+	uniqueKey string
 }
 
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		tasks: make(map[string]*domain.Task),
+		tasks:       make(map[string]*domain.Task),
+		attempts:    make(map[string][]*domain.TaskAttempt),
+		byUniqueKey: make(map[uniqueKeyIndex]map[string]struct{}),
 	}
 }
 
@@ -24,8 +36,9 @@ func (r *MemoryRepository) Save(task *domain.Task) error {
 		return domain.ErrTaskAlreadyExists
 	}
 
-	taskCopy := *task
-	r.tasks[task.ID] = &taskCopy
+	task.Version = 1
+	r.tasks[task.ID] = copyTask(task)
+	r.index(task)
 
 	return nil
 }
@@ -34,12 +47,19 @@ func (r *MemoryRepository) Update(task *domain.Task) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tasks[task.ID]; !exists {
+	stored, exists := r.tasks[task.ID]
+	if !exists {
 		return domain.ErrTaskNotFound
 	}
 
-	taskCopy := *task
-	r.tasks[task.ID] = &taskCopy
+	if stored.Version != task.Version {
+		return domain.ErrVersionConflict
+	}
+
+	task.Version++
+	r.unindex(stored)
+	r.tasks[task.ID] = copyTask(task)
+	r.index(task)
 
 	return nil
 }
@@ -53,8 +73,7 @@ func (r *MemoryRepository) FindByID(id string) (*domain.Task, error) {
 		return nil, domain.ErrTaskNotFound
 	}
 
-	taskCopy := *task
-	return &taskCopy, nil
+	return copyTask(task), nil
 }
 
 func (r *MemoryRepository) FindAll() ([]*domain.Task, error) {
@@ -63,8 +82,7 @@ func (r *MemoryRepository) FindAll() ([]*domain.Task, error) {
 
 	tasks := make([]*domain.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		taskCopy := *task
-		tasks = append(tasks, &taskCopy)
+		tasks = append(tasks, copyTask(task))
 	}
 
 	return tasks, nil
@@ -77,23 +95,29 @@ func (r *MemoryRepository) FindByStatus(status domain.TaskStatus) ([]*domain.Tas
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
 		if task.Status == status {
-			taskCopy := *task
-			tasks = append(tasks, &taskCopy)
+			tasks = append(tasks, copyTask(task))
 		}
 	}
 
 	return tasks, nil
 }
 
-func (r *MemoryRepository) Delete(id string) error {
+func (r *MemoryRepository) Delete(task *domain.Task) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tasks[id]; !exists {
+	stored, exists := r.tasks[task.ID]
+	if !exists {
 		return domain.ErrTaskNotFound
 	}
 
-	delete(r.tasks, id)
+	if stored.Version != task.Version {
+		return domain.ErrVersionConflict
+	}
+
+	r.unindex(stored)
+	delete(r.tasks, task.ID)
+	delete(r.attempts, task.ID)
 	return nil
 }
 
@@ -117,3 +141,92 @@ func (r *MemoryRepository) CountByStatus(status domain.TaskStatus) (int, error)
 
 	return count, nil
 }
+
+func (r *MemoryRepository) CountByTenantAndStatus(tenant string, status domain.TaskStatus) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, task := range r.tasks {
+		if task.Tenant == tenant && task.Status == status {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *MemoryRepository) FindByUniqueKey(tenant, uniqueKey string) ([]*domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := r.byUniqueKey[uniqueKeyIndex{tenant, uniqueKey}]
+	tasks := make([]*domain.Task, 0, len(ids))
+	for id := range ids {
+		tasks = append(tasks, copyTask(r.tasks[id]))
+	}
+
+	return tasks, nil
+}
+
+func (r *MemoryRepository) index(task *domain.Task) {
+	if task.UniqueKey == "" {
+		return
+	}
+	key := uniqueKeyIndex{task.Tenant, task.UniqueKey}
+	if r.byUniqueKey[key] == nil {
+		r.byUniqueKey[key] = make(map[string]struct{})
+	}
+	r.byUniqueKey[key][task.ID] = struct{}{}
+}
+
+func (r *MemoryRepository) unindex(task *domain.Task) {
+	if task.UniqueKey == "" {
+		return
+	}
+	key := uniqueKeyIndex{task.Tenant, task.UniqueKey}
+	delete(r.byUniqueKey[key], task.ID)
+	if len(r.byUniqueKey[key]) == 0 {
+		delete(r.byUniqueKey, key)
+	}
+}
+
+func (r *MemoryRepository) SaveAttempt(attempt *domain.TaskAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[attempt.TaskID]; !exists {
+		return domain.ErrTaskNotFound
+	}
+
+	attemptCopy := *attempt
+	r.attempts[attempt.TaskID] = append(r.attempts[attempt.TaskID], &attemptCopy)
+
+	return nil
+}
+
+func (r *MemoryRepository) FindAttemptsByTaskID(taskID string) ([]*domain.TaskAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attempts := make([]*domain.TaskAttempt, 0, len(r.attempts[taskID]))
+	for _, attempt := range r.attempts[taskID] {
+		attemptCopy := *attempt
+		attempts = append(attempts, &attemptCopy)
+	}
+
+	return attempts, nil
+}
+
+// copyTask returns a copy that shares no slices or pointers with the
+// original, so callers can't modify stored tasks through them.
+func copyTask(task *domain.Task) *domain.Task {
+	taskCopy := *task
+	taskCopy.Artifacts = append([]domain.Artifact(nil), task.Artifacts...)
+	taskCopy.Transitions = append([]domain.StatusTransition(nil), task.Transitions...)
+	if task.Progress != nil {
+		progress := *task.Progress
+		taskCopy.Progress = &progress
+	}
+	return &taskCopy
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"go-task-queue-system/domain"
+	"testing"
+)
+
+func newStoredTask(t *testing.T, r *MemoryRepository) *domain.Task {
+	t.Helper()
+
+	task, err := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, map[string]interface{}{"to": "ops@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestMemoryRepositoryUpdateBumpsVersion(t *testing.T) {
+	r := NewMemoryRepository()
+	task := newStoredTask(t, r)
+	if task.Version != 1 {
+		t.Fatalf("Version after Save = %d, want 1", task.Version)
+	}
+
+	task.Priority = domain.TaskPriorityHigh
+	if err := r.Update(task); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if task.Version != 2 {
+		t.Errorf("Version after Update = %d, want 2", task.Version)
+	}
+
+	stored, err := r.FindByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != 2 || stored.Priority != domain.TaskPriorityHigh {
+		t.Errorf("stored task = version %d, priority %s; want version 2, priority high", stored.Version, stored.Priority)
+	}
+}
+
+func TestMemoryRepositoryUpdateVersionConflict(t *testing.T) {
+	r := NewMemoryRepository()
+	task := newStoredTask(t, r)
+
+	first, _ := r.FindByID(task.ID)
+	second, _ := r.FindByID(task.ID)
+
+	if err := first.MarkAsProcessing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(first); err != nil {
+		t.Fatalf("first Update() error = %v", err)
+	}
+
+	if err := second.MarkAsCancelled(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(second); err != domain.ErrVersionConflict {
+		t.Fatalf("stale Update() error = %v, want ErrVersionConflict", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("stale copy Version = %d, want it left at 1", second.Version)
+	}
+
+	stored, _ := r.FindByID(task.ID)
+	if stored.Status != domain.TaskStatusProcessing || stored.Version != 2 {
+		t.Errorf("stored task = %s version %d, want processing version 2", stored.Status, stored.Version)
+	}
+
+	// Reloading and applying the change again succeeds.
+	if err := stored.MarkAsCompleted(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Update(stored); err != nil {
+		t.Errorf("Update() of the reloaded task error = %v", err)
+	}
+}
+
+func TestMemoryRepositoryUpdateUnknownTask(t *testing.T) {
+	r := NewMemoryRepository()
+	task, _ := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityLow, nil)
+
+	if err := r.Update(task); err != domain.ErrTaskNotFound {
+		t.Errorf("Update() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestMemoryRepositoryReturnsCopies(t *testing.T) {
+	r := NewMemoryRepository()
+	task := newStoredTask(t, r)
+
+	found, _ := r.FindByID(task.ID)
+	found.Status = domain.TaskStatusCompleted
+	found.Transitions = append(found.Transitions, domain.StatusTransition{})
+
+	stored, _ := r.FindByID(task.ID)
+	if stored.Status != domain.TaskStatusPending || len(stored.Transitions) != len(task.Transitions) {
+		t.Error("changing a found task changed the stored one")
+	}
+}
+
+func TestMemoryRepositoryFindByUniqueKey(t *testing.T) {
+	r := NewMemoryRepository()
+
+	task, _ := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, nil)
+	task.Tenant, task.UniqueKey = "acme", "daily-report"
+	if err := r.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, nil)
+	other.Tenant, other.UniqueKey = "globex", "daily-report"
+	if err := r.Save(other); err != nil {
+		t.Fatal(err)
+	}
+
+	found, _ := r.FindByUniqueKey("acme", "daily-report")
+	if len(found) != 1 || found[0].ID != task.ID {
+		t.Fatalf("FindByUniqueKey() = %v, want only the acme task", found)
+	}
+
+	task.UniqueKey = "weekly-report"
+	if err := r.Update(task); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := r.FindByUniqueKey("acme", "daily-report"); len(found) != 0 {
+		t.Errorf("FindByUniqueKey() after changing the key = %d tasks, want 0", len(found))
+	}
+	if found, _ := r.FindByUniqueKey("acme", "weekly-report"); len(found) != 1 {
+		t.Errorf("FindByUniqueKey() of the new key = %d tasks, want 1", len(found))
+	}
+
+	if err := r.Delete(task); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := r.FindByUniqueKey("acme", "weekly-report"); len(found) != 0 {
+		t.Errorf("FindByUniqueKey() after Delete = %d tasks, want 0", len(found))
+	}
+}
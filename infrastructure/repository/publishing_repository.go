@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"go-task-queue-system/domain"
+	"time"
+)
+
+type TaskEventPublisher interface {
+	Publish(event domain.TaskEvent)
+}
+
+// PublishingRepository wraps a repository and publishes an event for
+// every task it saves, updates or deletes.
+type PublishingRepository struct {
+	domain.TaskRepository
+	publisher TaskEventPublisher
+}
+
+func NewPublishingRepository(repository domain.TaskRepository, publisher TaskEventPublisher) *PublishingRepository {
+	return &PublishingRepository{
+		TaskRepository: repository,
+		publisher:      publisher,
+	}
+}
+
+func (r *PublishingRepository) Save(task *domain.Task) error {
+	if err := r.TaskRepository.Save(task); err != nil {
+		return err
+	}
+	r.publish(domain.TaskEventCreated, task)
+	return nil
+}
+
+func (r *PublishingRepository) Update(task *domain.Task) error {
+	if err := r.TaskRepository.Update(task); err != nil {
+		return err
+	}
+	r.publish(domain.TaskEventUpdated, task)
+	return nil
+}
+
+func (r *PublishingRepository) Delete(task *domain.Task) error {
+	if err := r.TaskRepository.Delete(task); err != nil {
+		return err
+	}
+	r.publish(domain.TaskEventDeleted, task)
+	return nil
+}
+
+// publish sends a copy, since the caller keeps changing its task.
+func (r *PublishingRepository) publish(kind domain.TaskEventKind, task *domain.Task) {
+	r.publisher.Publish(domain.TaskEvent{
+		Kind: kind,
+		Task: copyTask(task),
+		At:   time.Now(),
+	})
+}
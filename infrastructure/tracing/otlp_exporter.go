@@ -0,0 +1,204 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	otlpBatchSize     = 256
+	otlpQueueSize     = 4096
+	otlpFlushInterval = 5 * time.Second
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP over
+// HTTP, JSON encoded. Spans are batched in the background; when the
+// collector can't keep up, new spans are dropped rather than blocking
+// tasks.
+type OTLPExporter struct {
+	endpoint   string
+	httpClient *http.Client
+	spans      chan *Span
+	quit       chan struct{}
+	done       chan struct{}
+}
+
+// NewOTLPExporter exports to endpoint, e.g. http://localhost:4318. The
+// /v1/traces path is added unless endpoint already has a path.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	if parsed, err := url.Parse(endpoint); err == nil && strings.Trim(parsed.Path, "/") == "" {
+		parsed.Path = "/v1/traces"
+		endpoint = parsed.String()
+	}
+
+	e := &OTLPExporter{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		spans:      make(chan *Span, otlpQueueSize),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *OTLPExporter) ExportSpan(span *Span) {
+	select {
+	case e.spans <- span:
+	default:
+		slog.Warn("OTLP export queue full, dropping span", "span", span.Name)
+	}
+}
+
+// Shutdown sends the spans still queued, giving up when ctx is done.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	close(e.quit)
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, otlpBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			slog.Warn("failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) == otlpBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.quit:
+			for {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+					if len(batch) == otlpBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *OTLPExporter) send(spans []*Span) error {
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.httpClient.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// otlpRequest builds an ExportTraceServiceRequest in the OTLP JSON
+// encoding, grouping spans by service.
+func otlpRequest(spans []*Span) map[string]interface{} {
+	byService := map[string][]interface{}{}
+	var services []string
+	for _, span := range spans {
+		service := span.tracer.Service()
+		if _, seen := byService[service]; !seen {
+			services = append(services, service)
+		}
+		byService[service] = append(byService[service], otlpSpan(span))
+	}
+
+	resourceSpans := make([]interface{}, 0, len(services))
+	for _, service := range services {
+		resourceSpans = append(resourceSpans, map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []interface{}{otlpAttribute("service.name", service)},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "go-task-queue-system"},
+				"spans": byService[service],
+			}},
+		})
+	}
+	return map[string]interface{}{"resourceSpans": resourceSpans}
+}
+
+func otlpSpan(span *Span) map[string]interface{} {
+	attributes := make([]interface{}, 0, len(span.Attributes))
+	for key, value := range span.Attributes {
+		attributes = append(attributes, otlpAttribute(key, value))
+	}
+
+	encoded := map[string]interface{}{
+		"traceId":           span.Context.TraceID.String(),
+		"spanId":            span.Context.SpanID.String(),
+		"name":              span.Name,
+		"kind":              int(span.Kind),
+		"startTimeUnixNano": strconv.FormatInt(span.Start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(span.End.UnixNano(), 10),
+		"attributes":        attributes,
+	}
+	if span.ParentSpanID.IsValid() {
+		encoded["parentSpanId"] = span.ParentSpanID.String()
+	}
+	if span.Error != "" {
+		encoded["status"] = map[string]interface{}{"code": 2, "message": span.Error}
+	}
+	return encoded
+}
+
+func otlpAttribute(key string, value interface{}) map[string]interface{} {
+	var encoded map[string]interface{}
+	switch v := value.(type) {
+	case string:
+		encoded = map[string]interface{}{"stringValue": v}
+	case bool:
+		encoded = map[string]interface{}{"boolValue": v}
+	case int:
+		encoded = map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		encoded = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		encoded = map[string]interface{}{"doubleValue": v}
+	default:
+		encoded = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+	return map[string]interface{}{"key": key, "value": encoded}
+}
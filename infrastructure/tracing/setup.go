@@ -0,0 +1,21 @@
+package tracing
+
+// Setup returns a tracer for service that writes spans to file and sends
+// them to otlpEndpoint; either may be empty to skip that exporter.
+func Setup(service, file, otlpEndpoint string) (*Tracer, error) {
+	var exporters []Exporter
+
+	if file != "" {
+		exporter, err := NewFileExporter(file)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	if otlpEndpoint != "" {
+		exporters = append(exporters, NewOTLPExporter(otlpEndpoint))
+	}
+
+	return NewTracer(service, exporters...), nil
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"go-task-queue-system/domain"
+	"time"
+)
+
+// TaskParent returns the span the task was submitted in, or an invalid
+// SpanContext if it has none.
+func TaskParent(task *domain.Task) SpanContext {
+	sc, _ := ParseTraceParent(task.TraceParent)
+	return sc
+}
+
+// RecordQueueWait exports a span covering the time the task waited in line
+// before its current attempt started.
+func (t *Tracer) RecordQueueWait(task *domain.Task) {
+	end := time.Now()
+	if task.StartedAt != nil {
+		end = *task.StartedAt
+	}
+
+	span := t.StartSpan(TaskParent(task), "task.queue_wait", SpanKindInternal, task.PendingSince())
+	span.SetAttribute("task.id", task.ID)
+	span.SetAttribute("task.type", task.Type.String())
+	span.SetAttribute("tenant", task.Tenant)
+	span.SetAttribute("task.priority", task.Priority.String())
+	span.FinishAt(end)
+}
+
+// StartExecution starts the span for one attempt at running the task and
+// returns a context carrying it, for the processor.
+func (t *Tracer) StartExecution(ctx context.Context, task *domain.Task, workerID string, attempt int) (context.Context, *Span) {
+	span := t.StartSpan(TaskParent(task), "task.execute "+task.Type.String(), SpanKindConsumer, time.Now())
+	span.SetAttribute("task.id", task.ID)
+	span.SetAttribute("task.type", task.Type.String())
+	span.SetAttribute("tenant", task.Tenant)
+	span.SetAttribute("worker.id", workerID)
+	span.SetAttribute("task.attempt", attempt)
+	return ContextWithSpan(ctx, span), span
+}
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context header that carries the
+// caller's span.
+const TraceParentHeader = "traceparent"
+
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+type TraceID [16]byte
+
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+type SpanID [8]byte
+
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext identifies a span within a trace, as carried by the
+// traceparent header.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// TraceParent formats sc as a version 00 traceparent header value, or
+// returns "" if sc is not valid.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := 0
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceParent parses a traceparent header value. Values from future
+// versions are accepted as long as they start with the version 00 fields.
+func ParseTraceParent(value string) (SpanContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	version, err := decodeHex(value[0:2], 1)
+	if err != nil || version[0] == 0xff {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if version[0] == 0 && len(value) != 55 {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if version[0] > 0 && len(value) > 55 && value[55] != '-' {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	traceID, err := decodeHex(value[3:35], 16)
+	if err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	spanID, err := decodeHex(value[36:52], 8)
+	if err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	flags, err := decodeHex(value[53:55], 1)
+	if err != nil {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	sc := SpanContext{Sampled: flags[0]&1 == 1}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex only, as the spec requires.
+func decodeHex(s string, size int) ([]byte, error) {
+	if s != strings.ToLower(s) {
+		return nil, ErrInvalidTraceParent
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil || len(decoded) != size {
+		return nil, ErrInvalidTraceParent
+	}
+	return decoded, nil
+}
+
+// Extract reads the caller's span from request headers. ok is false when
+// there is no valid traceparent header.
+func Extract(header http.Header) (sc SpanContext, ok bool) {
+	sc, err := ParseTraceParent(header.Get(TraceParentHeader))
+	return sc, err == nil
+}
+
+// Inject sets the traceparent header for the span in ctx, so the receiver
+// of an outbound request can continue the trace.
+func Inject(ctx context.Context, header http.Header) {
+	if traceParent := SpanContextFromContext(ctx).TraceParent(); traceParent != "" {
+		header.Set(TraceParentHeader, traceParent)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+	SpanKindProducer
+	SpanKindConsumer
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	case SpanKindProducer:
+		return "producer"
+	case SpanKindConsumer:
+		return "consumer"
+	default:
+		return "internal"
+	}
+}
+
+// Exporter sends finished spans somewhere. ExportSpan must not block for
+// long; exporters that talk to the network buffer spans.
+type Exporter interface {
+	ExportSpan(span *Span)
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans and hands finished, sampled spans to its exporters.
+// Without exporters it still creates span contexts, so traces are
+// propagated even when nothing is recorded here.
+type Tracer struct {
+	service   string
+	exporters []Exporter
+}
+
+func NewTracer(service string, exporters ...Exporter) *Tracer {
+	return &Tracer{service: service, exporters: exporters}
+}
+
+func (t *Tracer) Service() string {
+	return t.service
+}
+
+// StartSpan starts a span at the given time as a child of parent, or as
+// the root of a new, sampled trace if parent is not valid.
+func (t *Tracer) StartSpan(parent SpanContext, name string, kind SpanKind, start time.Time) *Span {
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      start,
+		Attributes: map[string]interface{}{},
+		tracer:     t,
+	}
+
+	if parent.IsValid() {
+		span.Context = SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled}
+		span.ParentSpanID = parent.SpanID
+	} else {
+		span.Context = SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	}
+	return span
+}
+
+// Start starts a span now, as a child of the span in ctx, and returns a
+// context carrying the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := t.StartSpan(SpanContextFromContext(ctx), name, kind, time.Now())
+	return ContextWithSpan(ctx, span), span
+}
+
+// Shutdown flushes and stops all exporters.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range t.exporters {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (t *Tracer) export(span *Span) {
+	if !span.Context.Sampled {
+		return
+	}
+	for _, exporter := range t.exporters {
+		exporter.ExportSpan(span)
+	}
+}
+
+// Span is a timed operation within a trace. Its fields must not be changed
+// after End.
+type Span struct {
+	Name         string
+	Kind         SpanKind
+	Context      SpanContext
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	Error        string
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ended {
+		s.Attributes[key] = value
+	}
+}
+
+// SetError marks the span as failed.
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ended {
+		s.Error = err.Error()
+	}
+}
+
+// Finish ends the span now.
+func (s *Span) Finish() {
+	s.FinishAt(time.Now())
+}
+
+// FinishAt ends the span at the given time and exports it. Only the first
+// call has an effect.
+func (s *Span) FinishAt(end time.Time) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = end
+	s.mu.Unlock()
+
+	s.tracer.export(s)
+}
+
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+type spanKey struct{}
+
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span.Context)
+}
+
+// ContextWithSpanContext returns a context carrying a span from elsewhere,
+// e.g. the one a task was submitted in, so new spans become its children.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// SpanContextFromContext returns the span carried by ctx, or an invalid
+// SpanContext.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
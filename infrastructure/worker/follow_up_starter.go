@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"go-task-queue-system/usecase"
+	"log/slog"
+	"time"
+)
+
+// FollowUpStarter submits the follow-ups of tasks as they finish, from the
+// task events, and sweeps for missed ones periodically. Both run in one
+// goroutine, so a follow-up is never started twice at once.
+type FollowUpStarter struct {
+	startFollowUpsUC *usecase.StartFollowUpsUseCase
+	events           usecase.TaskEventSource
+	interval         time.Duration
+	quit             chan struct{}
+	done             chan struct{}
+}
+
+func NewFollowUpStarter(startFollowUpsUC *usecase.StartFollowUpsUseCase, events usecase.TaskEventSource, interval time.Duration) *FollowUpStarter {
+	return &FollowUpStarter{
+		startFollowUpsUC: startFollowUpsUC,
+		events:           events,
+		interval:         interval,
+		quit:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+func (s *FollowUpStarter) Start() {
+	events, stop := s.events.Subscribe()
+
+	go func() {
+		defer close(s.done)
+		defer stop()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		// Tasks may have finished before the subscription.
+		s.sweep()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// The broker closed on shutdown; the sweep carries on.
+					events = nil
+					continue
+				}
+				if _, err := s.startFollowUpsUC.HandleEvent(event); err != nil {
+					slog.Error("failed to start follow-up task", "task_id", event.Task.ID, "error", err)
+				}
+
+			case <-ticker.C:
+				s.sweep()
+
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (s *FollowUpStarter) sweep() {
+	started, err := s.startFollowUpsUC.Execute()
+	if err != nil {
+		slog.Error("failed to start follow-up tasks", "error", err)
+	} else if started > 0 {
+		slog.Debug("started follow-up tasks", "tasks", started)
+	}
+}
+
+func (s *FollowUpStarter) Stop() {
+	close(s.quit)
+	<-s.done
+}
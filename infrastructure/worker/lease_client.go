@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-task-queue-system/domain"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LeaseClient talks to the server's /worker-api endpoints on behalf of a
+// remote worker.
+type LeaseClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewLeaseClient(baseURL, apiKey string) *LeaseClient {
+	return &LeaseClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Lease asks for a task of one of the given types. It returns nil and no
+// error when there is nothing to do.
+func (c *LeaseClient) Lease(ctx context.Context, workerID string, taskTypes []domain.TaskType, leaseFor time.Duration) (*domain.Task, error) {
+	names := make([]string, len(taskTypes))
+	for i, taskType := range taskTypes {
+		names[i] = taskType.String()
+	}
+
+	var response struct {
+		Task *leasedTask `json:"task"`
+	}
+	status, err := c.post(ctx, "/worker-api/lease", map[string]interface{}{
+		"worker_id":     workerID,
+		"task_types":    names,
+		"lease_seconds": int(leaseFor / time.Second),
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNoContent || response.Task == nil {
+		return nil, nil
+	}
+
+	task := &response.Task.Task
+	task.Timeout = time.Duration(response.Task.TimeoutSeconds) * time.Second
+	return task, nil
+}
+
+// leasedTask is a task as the worker API sends it, with its time limit in
+// seconds.
+type leasedTask struct {
+	domain.Task
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// Heartbeat renews the lease on a task and reports its progress. It returns
+// domain.ErrLeaseNotHeld if the worker no longer holds the task.
+func (c *LeaseClient) Heartbeat(ctx context.Context, taskID, workerID string, leaseFor time.Duration, progress *domain.TaskProgress) error {
+	body := map[string]interface{}{
+		"worker_id":     workerID,
+		"lease_seconds": int(leaseFor / time.Second),
+	}
+	if progress != nil {
+		body["progress"] = map[string]interface{}{
+			"percent": progress.Percent,
+			"stage":   progress.Stage,
+			"message": progress.Message,
+		}
+	}
+
+	_, err := c.post(ctx, "/worker-api/tasks/"+url.PathEscape(taskID)+"/heartbeat", body, nil)
+	return err
+}
+
+func (c *LeaseClient) Complete(ctx context.Context, taskID, workerID string, result map[string]interface{}) error {
+	_, err := c.post(ctx, "/worker-api/tasks/"+url.PathEscape(taskID)+"/complete", map[string]interface{}{
+		"worker_id": workerID,
+		"result":    result,
+	}, nil)
+	return err
+}
+
+func (c *LeaseClient) Fail(ctx context.Context, taskID, workerID, message string, reason domain.FailureReason, permanent bool, result map[string]interface{}) error {
+	_, err := c.post(ctx, "/worker-api/tasks/"+url.PathEscape(taskID)+"/fail", map[string]interface{}{
+		"worker_id": workerID,
+		"error":     message,
+		"reason":    reason,
+		"permanent": permanent,
+		"result":    result,
+	}, nil)
+	return err
+}
+
+func (c *LeaseClient) UploadArtifact(ctx context.Context, taskID, workerID, name, contentType string, data []byte) error {
+	path := "/worker-api/tasks/" + url.PathEscape(taskID) + "/artifacts/" + url.PathEscape(name) +
+		"?worker_id=" + url.QueryEscape(workerID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	_, err = c.do(req, nil)
+	return err
+}
+
+func (c *LeaseClient) post(ctx context.Context, path string, body interface{}, out interface{}) (int, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(encoded))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(req, out)
+}
+
+func (c *LeaseClient) do(req *http.Request, out interface{}) (int, error) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return resp.StatusCode, domain.ErrLeaseNotHeld
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return resp.StatusCode, fmt.Errorf("%s %s: %d %s %s", req.Method, req.URL.Path, resp.StatusCode, apiErr.Error, apiErr.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s: %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+
+	return resp.StatusCode, nil
+}
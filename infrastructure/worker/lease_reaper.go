@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"go-task-queue-system/usecase"
+	"log/slog"
+	"time"
+)
+
+// LeaseReaper periodically takes back tasks whose remote worker stopped
+// sending heartbeats.
+type LeaseReaper struct {
+	expireLeasesUC *usecase.ExpireLeasesUseCase
+	interval       time.Duration
+	quit           chan struct{}
+	done           chan struct{}
+}
+
+func NewLeaseReaper(expireLeasesUC *usecase.ExpireLeasesUseCase, interval time.Duration) *LeaseReaper {
+	return &LeaseReaper{
+		expireLeasesUC: expireLeasesUC,
+		interval:       interval,
+		quit:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+func (r *LeaseReaper) Start() {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				expired, err := r.expireLeasesUC.Execute(now)
+				if err != nil {
+					slog.Error("failed to expire leases", "error", err)
+				} else if expired > 0 {
+					slog.Warn("took back tasks with expired leases", "tasks", expired)
+				}
+
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (r *LeaseReaper) Stop() {
+	close(r.quit)
+	<-r.done
+}
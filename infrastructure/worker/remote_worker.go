@@ -0,0 +1,249 @@
+package worker
+
+import (
+	"context"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/logging"
+	"go-task-queue-system/infrastructure/processor"
+	"go-task-queue-system/infrastructure/tracing"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RemoteWorker runs tasks leased from a server through the worker API,
+// using the same processors as the in-process workers.
+type RemoteWorker struct {
+	id                string
+	client            *LeaseClient
+	processorRegistry *processor.ProcessorRegistry
+	taskTypes         []domain.TaskType
+	timeout           time.Duration
+	leaseDuration     time.Duration
+	pollInterval      time.Duration
+	logger            *slog.Logger
+	tracer            *tracing.Tracer
+	quit              chan struct{}
+	done              chan struct{}
+}
+
+func NewRemoteWorker(
+	id string,
+	client *LeaseClient,
+	processorRegistry *processor.ProcessorRegistry,
+	taskTypes []domain.TaskType,
+	timeout time.Duration,
+	leaseDuration time.Duration,
+	pollInterval time.Duration,
+	tracer *tracing.Tracer,
+) *RemoteWorker {
+	return &RemoteWorker{
+		id:                id,
+		client:            client,
+		processorRegistry: processorRegistry,
+		taskTypes:         taskTypes,
+		timeout:           timeout,
+		leaseDuration:     leaseDuration,
+		pollInterval:      pollInterval,
+		logger:            slog.Default().With(logging.KeyWorkerID, id),
+		tracer:            tracer,
+		quit:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+// Start leases and runs tasks until Stop is called. It blocks.
+func (w *RemoteWorker) Start() {
+	defer close(w.done)
+	w.logger.Info("remote worker started", "types", w.taskTypes)
+
+	for {
+		select {
+		case <-w.quit:
+			w.logger.Debug("remote worker stopped")
+			return
+		default:
+		}
+
+		task, err := w.client.Lease(context.Background(), w.id, w.taskTypes, w.leaseDuration)
+		if err != nil {
+			w.logger.Warn("failed to lease a task", "error", err)
+		}
+		if task == nil {
+			select {
+			case <-time.After(w.pollInterval):
+			case <-w.quit:
+				w.logger.Debug("remote worker stopped")
+				return
+			}
+			continue
+		}
+
+		w.processTask(task)
+	}
+}
+
+// Stop lets the task in progress finish and waits for the worker to exit.
+func (w *RemoteWorker) Stop() {
+	close(w.quit)
+	<-w.done
+}
+
+func (w *RemoteWorker) processTask(task *domain.Task) {
+	attempt := task.RetryCount + 1
+	ctx, span := w.tracer.StartExecution(context.Background(), task, w.id, attempt)
+	defer span.Finish()
+
+	logger := logging.ForTask(w.logger, task).With(logging.KeyAttempt, attempt, logging.KeyTraceID, span.Context.TraceID.String())
+	logger.Info("task leased")
+	started := time.Now()
+
+	proc, exists := w.processorRegistry.GetProcessor(task.Type)
+	if !exists {
+		// Only happens if the server hands out a type we didn't ask for.
+		span.SetError(domain.ErrInvalidTaskType)
+		w.fail(logger, task, "no processor found for task type: "+task.Type.String(), domain.FailureReasonError, true, nil)
+		return
+	}
+
+	timeout := task.Timeout
+	if timeout <= 0 {
+		timeout = w.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if task.ExpiresAt != nil {
+		var cancelAtExpiry context.CancelFunc
+		ctx, cancelAtExpiry = context.WithDeadline(ctx, *task.ExpiresAt)
+		defer cancelAtExpiry()
+	}
+
+	reporter := &remoteProgressReporter{}
+	ctx = processor.WithArtifactWriter(ctx, &remoteArtifactWriter{ctx: ctx, client: w.client, workerID: w.id, task: task})
+	ctx = processor.WithProgressReporter(ctx, reporter)
+	ctx = logging.WithLogger(ctx, logger)
+
+	heartbeatDone := make(chan struct{})
+	leaseLost := make(chan struct{})
+	go w.heartbeat(ctx, task.ID, reporter, heartbeatDone, leaseLost, cancel)
+
+	result, err := proc.Process(ctx, task)
+	close(heartbeatDone)
+
+	select {
+	case <-leaseLost:
+		logger.Warn("lost the lease, dropping result")
+		span.SetError(domain.ErrLeaseNotHeld)
+		return
+	default:
+	}
+
+	if err != nil {
+		if task.Expired(time.Now()) {
+			logger.Warn("task expired while running", "error", err)
+			span.SetError(err)
+			w.fail(logger, task, "before it finished: "+err.Error(), domain.FailureReasonExpired, false, result)
+			return
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			logger.Warn("task timed out", "timeout", timeout.String(), "error", err)
+			span.SetError(err)
+			w.fail(logger, task, "after "+timeout.String()+": "+err.Error(), domain.FailureReasonTimeout, false, result)
+			return
+		}
+		logger.Warn("task failed", "error", err, "permanent", processor.IsPermanent(err))
+		span.SetError(err)
+		w.fail(logger, task, err.Error(), domain.FailureReasonError, processor.IsPermanent(err), result)
+		return
+	}
+
+	if err := w.client.Complete(context.Background(), task.ID, w.id, result); err != nil {
+		logger.Error("failed to report completion", "error", err)
+		return
+	}
+	logger.Info("task completed", "duration_ms", time.Since(started).Milliseconds())
+}
+
+// heartbeat renews the lease at a third of its duration until done is
+// closed. If the server says the lease is gone, processing is cancelled.
+func (w *RemoteWorker) heartbeat(ctx context.Context, taskID string, reporter *remoteProgressReporter, done <-chan struct{}, leaseLost chan<- struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(w.leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := w.client.Heartbeat(ctx, taskID, w.id, w.leaseDuration, reporter.latest())
+			if err == domain.ErrLeaseNotHeld {
+				close(leaseLost)
+				cancel()
+				return
+			}
+			if err != nil {
+				logging.FromContext(ctx).Warn("heartbeat failed", "error", err)
+			}
+		}
+	}
+}
+
+func (w *RemoteWorker) fail(logger *slog.Logger, task *domain.Task, message string, reason domain.FailureReason, permanent bool, result map[string]interface{}) {
+	if err := w.client.Fail(context.Background(), task.ID, w.id, message, reason, permanent, result); err != nil {
+		logger.Error("failed to report failure", "error", err)
+	}
+}
+
+// remoteProgressReporter keeps the latest progress of the running task; it
+// is sent to the server with the next heartbeat.
+type remoteProgressReporter struct {
+	mu       sync.Mutex
+	progress *domain.TaskProgress
+}
+
+func (r *remoteProgressReporter) ReportProgress(percent int, stage, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.progress = &domain.TaskProgress{
+		Percent:   percent,
+		Stage:     stage,
+		Message:   message,
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (r *remoteProgressReporter) latest() *domain.TaskProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.progress
+}
+
+// remoteArtifactWriter uploads artifacts to the server, which stores them
+// on the task.
+type remoteArtifactWriter struct {
+	ctx      context.Context
+	client   *LeaseClient
+	workerID string
+	task     *domain.Task
+}
+
+func (w *remoteArtifactWriter) WriteArtifact(name, contentType string, data []byte) (domain.Artifact, error) {
+	if err := domain.ValidateArtifactName(name); err != nil {
+		return domain.Artifact{}, err
+	}
+
+	if err := w.client.UploadArtifact(w.ctx, w.task.ID, w.workerID, name, contentType, data); err != nil {
+		return domain.Artifact{}, err
+	}
+
+	artifact := domain.Artifact{
+		Name:        name,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+	w.task.AddArtifact(artifact)
+	return artifact, nil
+}
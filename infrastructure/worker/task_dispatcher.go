@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"go-task-queue-system/usecase"
+	"log/slog"
+	"time"
+)
+
+// TaskDispatcher periodically hands pending tasks that aren't in the queue
+// yet, e.g. because it was full, from the repository to the queue.
+type TaskDispatcher struct {
+	dispatchTasksUC *usecase.DispatchTasksUseCase
+	interval        time.Duration
+	quit            chan struct{}
+	done            chan struct{}
+}
+
+func NewTaskDispatcher(dispatchTasksUC *usecase.DispatchTasksUseCase, interval time.Duration) *TaskDispatcher {
+	return &TaskDispatcher{
+		dispatchTasksUC: dispatchTasksUC,
+		interval:        interval,
+		quit:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+func (d *TaskDispatcher) Start() {
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				dispatched, err := d.dispatchTasksUC.Execute()
+				if err != nil {
+					slog.Error("failed to dispatch tasks", "error", err)
+				} else if dispatched > 0 {
+					slog.Debug("dispatched pending tasks", "tasks", dispatched)
+				}
+
+			case <-d.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (d *TaskDispatcher) Stop() {
+	close(d.quit)
+	<-d.done
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"go-task-queue-system/usecase"
+	"log/slog"
+	"time"
+)
+
+// TaskExpirer periodically expires pending tasks whose deadline has passed,
+// including ones no worker would pick up soon.
+type TaskExpirer struct {
+	expireTasksUC *usecase.ExpireTasksUseCase
+	interval      time.Duration
+	quit          chan struct{}
+	done          chan struct{}
+}
+
+func NewTaskExpirer(expireTasksUC *usecase.ExpireTasksUseCase, interval time.Duration) *TaskExpirer {
+	return &TaskExpirer{
+		expireTasksUC: expireTasksUC,
+		interval:      interval,
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+func (e *TaskExpirer) Start() {
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				expired, err := e.expireTasksUC.Execute(now)
+				if err != nil {
+					slog.Error("failed to expire tasks", "error", err)
+				} else if expired > 0 {
+					slog.Info("expired tasks past their deadline", "tasks", expired)
+				}
+
+			case <-e.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (e *TaskExpirer) Stop() {
+	close(e.quit)
+	<-e.done
+}
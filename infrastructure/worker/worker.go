@@ -2,20 +2,38 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/logging"
 	"go-task-queue-system/infrastructure/processor"
-	"log"
+	"go-task-queue-system/infrastructure/tracing"
+	"log/slog"
+	"sync/atomic"
 	"time"
 )
 
+// maxConflictRetries bounds how often the worker re-reads a task after
+// losing an update race to another writer.
+const maxConflictRetries = 3
+
+// progressSaveInterval limits how often progress within a stage is saved.
+const progressSaveInterval = time.Second
+
 type Worker struct {
 	id                int
 	taskQueue         <-chan *domain.Task
 	repository        domain.TaskRepository
 	processorRegistry *processor.ProcessorRegistry
+	blobStore         domain.BlobStore
 	quit              chan bool
-	timeout           time.Duration
+	timeout           atomic.Int64
+	logger            *slog.Logger
+	tracer            *tracing.Tracer
+	state             atomic.Pointer[WorkerState]
+
+	// remoteTaskTypes are left pending for remote workers to lease.
+	remoteTaskTypes map[domain.TaskType]bool
 }
 
 func NewWorker(
@@ -23,84 +41,318 @@ func NewWorker(
 	taskQueue <-chan *domain.Task,
 	repository domain.TaskRepository,
 	processorRegistry *processor.ProcessorRegistry,
+	blobStore domain.BlobStore,
 	timeout time.Duration,
+	remoteTaskTypes []domain.TaskType,
+	tracer *tracing.Tracer,
 ) *Worker {
-	return &Worker{
+	remote := make(map[domain.TaskType]bool, len(remoteTaskTypes))
+	for _, taskType := range remoteTaskTypes {
+		remote[taskType] = true
+	}
+
+	w := &Worker{
 		id:                id,
 		taskQueue:         taskQueue,
 		repository:        repository,
 		processorRegistry: processorRegistry,
+		blobStore:         blobStore,
 		quit:              make(chan bool),
-		timeout:           timeout,
+		remoteTaskTypes:   remote,
+		tracer:            tracer,
 	}
+	w.logger = slog.Default().With(logging.KeyWorkerID, w.workerID())
+	w.setState(workerStateIdle, nil)
+	w.SetTimeout(timeout)
+	return w
+}
+
+// SetTimeout changes the time limit for tasks started from now on that
+// don't carry their own.
+func (w *Worker) SetTimeout(timeout time.Duration) {
+	w.timeout.Store(int64(timeout))
 }
 
 func (w *Worker) Start() {
-	log.Printf("🚀 Worker %d started", w.id)
+	w.logger.Debug("worker started")
 
 	for {
 		select {
 		case task, ok := <-w.taskQueue:
 			if !ok {
-				log.Printf("⛔ Worker %d: task queue closed", w.id)
+				w.logger.Debug("task queue closed, worker exiting")
 				return
 			}
 			w.processTask(task)
 
 		case <-w.quit:
-			log.Printf("⛔ Worker %d: received quit signal", w.id)
+			w.logger.Debug("worker stopped")
 			return
 		}
 	}
 }
 
+// WorkerState is what an in-process worker is doing.
+type WorkerState struct {
+	ID       string    `json:"id"`
+	State    string    `json:"state"`
+	TaskID   string    `json:"task_id,omitempty"`
+	TaskType string    `json:"task_type,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+const (
+	workerStateIdle = "idle"
+	workerStateBusy = "busy"
+)
+
+func (w *Worker) State() WorkerState {
+	return *w.state.Load()
+}
+
+func (w *Worker) setState(state string, task *domain.Task) {
+	next := &WorkerState{ID: w.workerID(), State: state, Since: time.Now()}
+	if task != nil {
+		next.TaskID = task.ID
+		next.TaskType = task.Type.String()
+	}
+	w.state.Store(next)
+}
+
+// Stop tells the worker to exit after its current task. It doesn't wait;
+// the worker may also have exited already because the queue was closed.
 func (w *Worker) Stop() {
-	log.Printf("🛑 Stopping worker %d", w.id)
-	w.quit <- true
+	close(w.quit)
 }
 
-func (w *Worker) processTask(task *domain.Task) {
-	log.Printf("⚙️  Worker %d: picked up task %s (type: %s)", w.id, task.ID, task.Type)
+func (w *Worker) processTask(queued *domain.Task) {
+	logger := logging.ForTask(w.logger, queued)
+	logger.Debug("picked up task")
 
-	task.MarkAsProcessing()
+	// The queued task may be stale: it can have been cancelled or deleted
+	// while it was waiting, so work from the stored copy.
+	task, err := w.repository.FindByID(queued.ID)
+	if err != nil {
+		logger.Info("skipping task", "error", err)
+		return
+	}
+
+	// The dispatcher doesn't queue remote types; this is only a safeguard.
+	if w.remoteTaskTypes[task.Type] {
+		logger.Debug("leaving task to remote workers")
+		return
+	}
+
+	if task.Status == domain.TaskStatusPending && task.Expired(time.Now()) {
+		w.expire(logger, task)
+		return
+	}
+
+	if err := task.MarkAsProcessing(); err != nil {
+		logger.Info("skipping task", "status", task.Status.String(), "error", err)
+		return
+	}
 	if err := w.repository.Update(task); err != nil {
-		log.Printf("❌ Worker %d: failed to update task status: %v", w.id, err)
+		// A conflict means the task changed after we read it, e.g. it was
+		// cancelled; whoever changed it wins.
+		logger.Warn("failed to mark task as processing", "error", err)
 		return
 	}
 
+	w.setState(workerStateBusy, task)
+	defer w.setState(workerStateIdle, nil)
+
+	w.tracer.RecordQueueWait(task)
+
+	attempt := domain.NewTaskAttempt(task, w.workerID())
+	ctx, span := w.tracer.StartExecution(context.Background(), task, w.workerID(), attempt.Attempt)
+	defer span.Finish()
+
+	logger = logger.With(logging.KeyAttempt, attempt.Attempt, logging.KeyTraceID, span.Context.TraceID.String())
+	logger.Info("task started")
+
 	proc, exists := w.processorRegistry.GetProcessor(task.Type)
 	if !exists {
-		log.Printf("❌ Worker %d: no processor found for task type %s", w.id, task.Type)
-		task.MarkAsFailed(fmt.Errorf("no processor found for task type: %s", task.Type))
-		w.repository.Update(task)
+		err := fmt.Errorf("no processor found for task type: %s", task.Type)
+		logger.Error("task failed", "error", err)
+		span.SetError(err)
+		w.recordAttempt(logger, attempt, domain.AttemptOutcomeFailed, err, nil)
+		w.finish(logger, task, func(t *domain.Task) error { return t.MarkAsPermanentlyFailed(err) })
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	timeout := task.Timeout
+	if timeout <= 0 {
+		timeout = time.Duration(w.timeout.Load())
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
+	if task.ExpiresAt != nil {
+		var cancelAtExpiry context.CancelFunc
+		ctx, cancelAtExpiry = context.WithDeadline(ctx, *task.ExpiresAt)
+		defer cancelAtExpiry()
+	}
+
+	if w.blobStore != nil {
+		ctx = processor.WithArtifactWriter(ctx, processor.NewTaskArtifactWriter(w.blobStore, task))
+	}
+	ctx = processor.WithProgressReporter(ctx, &taskProgressReporter{logger: logger, task: task, repository: w.repository})
+	ctx = logging.WithLogger(ctx, logger)
 
 	result, err := proc.Process(ctx, task)
 
 	if err != nil {
-		log.Printf("❌ Worker %d: task %s failed: %v", w.id, task.ID, err)
-		task.MarkAsFailed(err)
-		task.IncrementRetry()
-
-		if task.ShouldRetry() {
-			log.Printf("🔄 Worker %d: task %s will be retried (attempt %d/%d)",
-				w.id, task.ID, task.RetryCount, task.MaxRetries)
-			// In a real system, have to re-enqueue the task here
-			// For now, just mark it as failed and it stays failed
-		} else if task.IsInDeadLetterQueue() {
-			log.Printf("☠️  Worker %d: task %s moved to dead letter queue (max retries exceeded)",
-				w.id, task.ID)
+		if task.Expired(time.Now()) {
+			err = fmt.Errorf("%w before it finished: %v", domain.ErrTaskExpired, err)
+			logger.Warn("task expired while running", "error", err)
+			span.SetError(err)
+			w.recordAttempt(logger, attempt, domain.AttemptOutcomeFailed, err, nil)
+			w.finish(logger, task, func(t *domain.Task) error { return t.MarkAsExpired(err) })
+			return
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			// A timeout is never permanent: another attempt, perhaps with
+			// a longer timeout, may well succeed.
+			err = fmt.Errorf("%w after %s: %v", domain.ErrTaskTimedOut, timeout, err)
+		}
+		span.SetError(err)
+		w.recordAttempt(logger, attempt, domain.AttemptOutcomeFailed, err, result)
+
+		if processor.IsPermanent(err) {
+			logger.Error("task failed permanently, moved to dead letter queue", "error", err)
+			w.finish(logger, task, withResult(result, func(t *domain.Task) error { return t.MarkAsPermanentlyFailed(err) }))
+			return
+		}
+
+		saved, ok := w.finish(logger, task, withResult(result, func(t *domain.Task) error { return t.MarkAttemptFailed(err) }))
+		if !ok {
+			return
+		}
+
+		// A pending task is picked up again by the dispatcher.
+		if saved.Status == domain.TaskStatusPending {
+			logger.Warn("task failed, will be retried", "error", err, "retry_count", saved.RetryCount, "max_retries", saved.MaxRetries)
+		} else if saved.IsInDeadLetterQueue() {
+			logger.Error("task failed, moved to dead letter queue (max retries exceeded)", "error", err)
 		}
+		return
+	}
+
+	logger.Info("task completed", "duration_ms", time.Since(attempt.StartedAt).Milliseconds())
+	w.recordAttempt(logger, attempt, domain.AttemptOutcomeSucceeded, nil, result)
+	w.finish(logger, task, func(t *domain.Task) error { return t.MarkAsCompleted(result) })
+}
+
+// withResult keeps what a failed attempt returned, such as the response to
+// a failed request, on the task.
+func withResult(result map[string]interface{}, mark func(*domain.Task) error) func(*domain.Task) error {
+	return func(t *domain.Task) error {
+		if err := mark(t); err != nil {
+			return err
+		}
+		t.Result = result
+		return nil
+	}
+}
 
-		w.repository.Update(task)
+// expire moves a task that waited past its deadline to the expired status
+// without running it.
+func (w *Worker) expire(logger *slog.Logger, task *domain.Task) {
+	if err := task.MarkAsExpired(nil); err != nil {
+		logger.Info("skipping task", "status", task.Status.String(), "error", err)
 		return
 	}
+	if err := w.repository.Update(task); err != nil {
+		logger.Warn("failed to mark task as expired", "error", err)
+		return
+	}
+	logger.Info("task expired before it could run")
+}
+
+// finish applies the outcome of an attempt to the task and saves it. If
+// another writer updated the task in the meantime, the outcome is applied
+// again to the latest copy as long as that copy is still processing;
+// otherwise the outcome is dropped. It returns the saved task.
+func (w *Worker) finish(logger *slog.Logger, task *domain.Task, apply func(*domain.Task) error) (*domain.Task, bool) {
+	for i := 0; i < maxConflictRetries; i++ {
+		if err := apply(task); err != nil {
+			logger.Warn("cannot finish task", "error", err)
+			return nil, false
+		}
 
-	log.Printf("✅ Worker %d: task %s completed successfully", w.id, task.ID)
-	task.MarkAsCompleted(result)
-	w.repository.Update(task)
+		err := w.repository.Update(task)
+		if err == nil {
+			return task, true
+		}
+		if err != domain.ErrVersionConflict {
+			logger.Error("failed to save task", "error", err)
+			return nil, false
+		}
+
+		latest, err := w.repository.FindByID(task.ID)
+		if err != nil {
+			logger.Error("failed to reload task", "error", err)
+			return nil, false
+		}
+		if latest.Status != domain.TaskStatusProcessing {
+			logger.Info("task changed meanwhile, dropping result", "status", latest.Status.String())
+			return nil, false
+		}
+
+		// Keep what this attempt produced on top of the other writer's changes.
+		latest.Artifacts = task.Artifacts
+		latest.Progress = task.Progress
+		task = latest
+	}
+
+	logger.Error("giving up saving task after repeated conflicts")
+	return nil, false
+}
+
+func (w *Worker) workerID() string {
+	return fmt.Sprintf("worker-%d", w.id)
+}
+
+func (w *Worker) recordAttempt(logger *slog.Logger, attempt *domain.TaskAttempt, outcome domain.AttemptOutcome, err error, result map[string]interface{}) {
+	resultSize := 0
+	if result != nil {
+		if encoded, encodeErr := json.Marshal(result); encodeErr == nil {
+			resultSize = len(encoded)
+		}
+	}
+
+	attempt.Finish(outcome, err, resultSize)
+	if err := w.repository.SaveAttempt(attempt); err != nil {
+		logger.Error("failed to record attempt", "error", err)
+	}
+}
+
+// taskProgressReporter persists progress updates from a processor on the
+// task the worker is running. Every save is a task update, which changes
+// the task's version and with it its ETag, so saves are coalesced: a new
+// stage is saved straight away, further updates within a stage at most
+// once per progressSaveInterval. Updates in between are kept on the task
+// and saved with the next save or when the task finishes.
+type taskProgressReporter struct {
+	logger     *slog.Logger
+	task       *domain.Task
+	repository domain.TaskRepository
+
+	savedAt    time.Time
+	savedStage string
+}
+
+func (r *taskProgressReporter) ReportProgress(percent int, stage, message string) {
+	r.task.UpdateProgress(percent, stage, message)
+
+	now := time.Now()
+	if stage == r.savedStage && now.Sub(r.savedAt) < progressSaveInterval {
+		return
+	}
+	if err := r.repository.Update(r.task); err != nil {
+		r.logger.Warn("failed to save progress", "error", err)
+		return
+	}
+	r.savedAt = now
+	r.savedStage = stage
 }
@@ -3,8 +3,10 @@ package worker
 import (
 	"go-task-queue-system/domain"
 	"go-task-queue-system/infrastructure/processor"
-	"log"
+	"go-task-queue-system/infrastructure/tracing"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,7 +16,10 @@ type WorkerPool struct {
 	taskQueue         <-chan *domain.Task
 	repository        domain.TaskRepository
 	processorRegistry *processor.ProcessorRegistry
-	timeout           time.Duration
+	blobStore         domain.BlobStore
+	timeout           atomic.Int64
+	remoteTaskTypes   []domain.TaskType
+	tracer            *tracing.Tracer
 	wg                sync.WaitGroup
 }
 
@@ -23,28 +28,36 @@ func NewWorkerPool(
 	taskQueue <-chan *domain.Task,
 	repository domain.TaskRepository,
 	processorRegistry *processor.ProcessorRegistry,
+	blobStore domain.BlobStore,
 	timeout time.Duration,
+	remoteTaskTypes []domain.TaskType,
+	tracer *tracing.Tracer,
 ) *WorkerPool {
-	return &WorkerPool{
+	wp := &WorkerPool{
 		workers:           make([]*Worker, 0, workerCount),
 		workerCount:       workerCount,
 		taskQueue:         taskQueue,
 		repository:        repository,
 		processorRegistry: processorRegistry,
-		timeout:           timeout,
+		blobStore:         blobStore,
+		remoteTaskTypes:   remoteTaskTypes,
+		tracer:            tracer,
 	}
+	wp.timeout.Store(int64(timeout))
+	return wp
 }
 
 func (wp *WorkerPool) Start() {
-	log.Printf("🚀 Starting worker pool with %d workers", wp.workerCount)
-
 	for i := 1; i <= wp.workerCount; i++ {
 		worker := NewWorker(
 			i,
 			wp.taskQueue,
 			wp.repository,
 			wp.processorRegistry,
-			wp.timeout,
+			wp.blobStore,
+			time.Duration(wp.timeout.Load()),
+			wp.remoteTaskTypes,
+			wp.tracer,
 		)
 
 		wp.workers = append(wp.workers, worker)
@@ -56,11 +69,11 @@ func (wp *WorkerPool) Start() {
 		}(worker)
 	}
 
-	log.Printf("✅ Worker pool started successfully")
+	slog.Info("worker pool started", "workers", wp.workerCount, "remote_types", len(wp.remoteTaskTypes))
 }
 
 func (wp *WorkerPool) Stop() {
-	log.Printf("🛑 Stopping worker pool...")
+	slog.Info("stopping worker pool")
 
 	for _, worker := range wp.workers {
 		worker.Stop()
@@ -68,7 +81,16 @@ func (wp *WorkerPool) Stop() {
 
 	wp.wg.Wait()
 
-	log.Printf("✅ Worker pool stopped")
+	slog.Info("worker pool stopped")
+}
+
+// SetTimeout changes the time limit for tasks the workers start from now
+// on.
+func (wp *WorkerPool) SetTimeout(timeout time.Duration) {
+	wp.timeout.Store(int64(timeout))
+	for _, worker := range wp.workers {
+		worker.SetTimeout(timeout)
+	}
 }
 
 func (wp *WorkerPool) GetWorkerCount() int {
@@ -76,8 +98,15 @@ func (wp *WorkerPool) GetWorkerCount() int {
 }
 
 func (wp *WorkerPool) GetStatus() map[string]interface{} {
+	states := make([]WorkerState, len(wp.workers))
+	for i, worker := range wp.workers {
+		states[i] = worker.State()
+	}
+
 	return map[string]interface{}{
 		"worker_count": wp.workerCount,
-		"timeout":      wp.timeout.String(),
+		"timeout":      time.Duration(wp.timeout.Load()).String(),
+		"remote_types": wp.remoteTaskTypes,
+		"workers":      states,
 	}
 }
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// racingRepository lets another writer update the task right before each
+// of the worker's updates.
+type racingRepository struct {
+	*repository.MemoryRepository
+	// race runs before an update; it returns false once it stops racing.
+	race    func(stored *domain.Task) bool
+	updates int
+}
+
+func (r *racingRepository) Update(task *domain.Task) error {
+	r.updates++
+	if r.race != nil {
+		stored, err := r.MemoryRepository.FindByID(task.ID)
+		if err != nil {
+			return err
+		}
+		if r.race(stored) {
+			if err := r.MemoryRepository.Update(stored); err != nil {
+				return err
+			}
+		}
+	}
+	return r.MemoryRepository.Update(task)
+}
+
+func processingTask(t *testing.T, repo *racingRepository) *domain.Task {
+	t.Helper()
+
+	task, err := domain.NewTask(domain.TaskTypeEmail, domain.TaskPriorityMedium, map[string]interface{}{"to": "ops@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := task.MarkAsProcessing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestFinishRetriesOnConflict(t *testing.T) {
+	repo := &racingRepository{MemoryRepository: repository.NewMemoryRepository()}
+	task := processingTask(t, repo)
+
+	raced := false
+	repo.race = func(stored *domain.Task) bool {
+		if raced {
+			return false
+		}
+		raced = true
+		stored.UpdateProgress(90, "sending", "")
+		return true
+	}
+
+	task.AddArtifact(domain.Artifact{Name: "receipt.txt"})
+	w := &Worker{repository: repo}
+	applied := 0
+	saved, ok := w.finish(slog.New(slog.NewTextHandler(io.Discard, nil)), task, func(task *domain.Task) error {
+		applied++
+		return task.MarkAsCompleted(map[string]interface{}{"sent": true})
+	})
+	if !ok {
+		t.Fatal("finish() gave up, want the outcome applied to the latest copy")
+	}
+	if applied != 2 {
+		t.Errorf("outcome applied %d times, want 2", applied)
+	}
+
+	stored, _ := repo.FindByID(task.ID)
+	if stored.Status != domain.TaskStatusCompleted || stored.Version != saved.Version {
+		t.Errorf("stored task = %s version %d, want completed version %d", stored.Status, stored.Version, saved.Version)
+	}
+	if _, found := stored.FindArtifact("receipt.txt"); !found {
+		t.Error("artifact of the attempt was lost on retry")
+	}
+}
+
+func TestFinishDropsOutcomeWhenTaskMovedOn(t *testing.T) {
+	repo := &racingRepository{MemoryRepository: repository.NewMemoryRepository()}
+	task := processingTask(t, repo)
+
+	repo.race = func(stored *domain.Task) bool {
+		if stored.Status != domain.TaskStatusProcessing {
+			return false
+		}
+		stored.MarkAsFailed(errors.New("lease lost"))
+		return true
+	}
+
+	w := &Worker{repository: repo}
+	_, ok := w.finish(slog.New(slog.NewTextHandler(io.Discard, nil)), task, func(task *domain.Task) error {
+		return task.MarkAsCompleted(nil)
+	})
+	if ok {
+		t.Fatal("finish() saved an outcome over a task that failed meanwhile")
+	}
+
+	stored, _ := repo.FindByID(task.ID)
+	if stored.Status != domain.TaskStatusFailed {
+		t.Errorf("stored status = %s, want failed", stored.Status)
+	}
+}
+
+func TestFinishGivesUpAfterRepeatedConflicts(t *testing.T) {
+	repo := &racingRepository{MemoryRepository: repository.NewMemoryRepository()}
+	task := processingTask(t, repo)
+
+	repo.race = func(stored *domain.Task) bool {
+		stored.UpdateProgress(50, "busy", "")
+		return true
+	}
+
+	w := &Worker{repository: repo}
+	_, ok := w.finish(slog.New(slog.NewTextHandler(io.Discard, nil)), task, func(task *domain.Task) error {
+		return task.MarkAsCompleted(nil)
+	})
+	if ok {
+		t.Fatal("finish() succeeded, want it to give up")
+	}
+	if repo.updates != maxConflictRetries {
+		t.Errorf("tried %d updates, want %d", repo.updates, maxConflictRetries)
+	}
+
+	stored, _ := repo.FindByID(task.ID)
+	if stored.Status != domain.TaskStatusProcessing {
+		t.Errorf("stored status = %s, want processing", stored.Status)
+	}
+}
+
+func TestProgressSavesAreCoalesced(t *testing.T) {
+	repo := &racingRepository{MemoryRepository: repository.NewMemoryRepository()}
+	task := processingTask(t, repo)
+	reporter := &taskProgressReporter{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), task: task, repository: repo}
+
+	for percent := 10; percent <= 40; percent += 10 {
+		reporter.ReportProgress(percent, "downloading", "")
+	}
+	if repo.updates != 1 {
+		t.Errorf("%d saves for one stage within a second, want 1", repo.updates)
+	}
+
+	reporter.ReportProgress(50, "resizing", "")
+	if repo.updates != 2 {
+		t.Errorf("%d saves after a stage change, want 2", repo.updates)
+	}
+
+	stored, _ := repo.FindByID(task.ID)
+	if stored.Progress == nil || stored.Progress.Percent != 50 || stored.Progress.Stage != "resizing" {
+		t.Errorf("saved progress = %+v, want 50%% resizing", stored.Progress)
+	}
+	if task.Progress.Percent != 50 {
+		t.Errorf("task progress = %d%%, want the latest update", task.Progress.Percent)
+	}
+}
@@ -1,9 +1,10 @@
 package usecase
 
-import (
-	"errors"
-	"go-task-queue-system/domain"
-)
+import "go-task-queue-system/domain"
+
+// maxConflictRetries bounds how often a use case re-reads a task and tries
+// again after losing an update race to another writer.
+const maxConflictRetries = 3
 
 type CancelTaskUseCase struct {
 	repository domain.TaskRepository
@@ -15,25 +16,49 @@ func NewCancelTaskUseCase(repository domain.TaskRepository) *CancelTaskUseCase {
 	}
 }
 
-func (uc *CancelTaskUseCase) Execute(taskID string) error {
+func (uc *CancelTaskUseCase) Execute(taskID string, tenant string) error {
 	if taskID == "" {
 		return domain.ErrTaskNotFound
 	}
 
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		err = uc.cancel(taskID, tenant, nil)
+		if err != domain.ErrVersionConflict {
+			return err
+		}
+	}
+
+	return err
+}
+
+// ExecuteIfVersion cancels the task only if it is still at the given
+// version, and returns domain.ErrVersionConflict without retrying otherwise.
+func (uc *CancelTaskUseCase) ExecuteIfVersion(taskID string, tenant string, version int) error {
+	if taskID == "" {
+		return domain.ErrTaskNotFound
+	}
+
+	return uc.cancel(taskID, tenant, &version)
+}
+
+func (uc *CancelTaskUseCase) cancel(taskID string, tenant string, expectedVersion *int) error {
 	task, err := uc.repository.FindByID(taskID)
 	if err != nil {
 		return err
 	}
 
-	if task.Status != domain.TaskStatusPending {
-		return errors.New("only pending tasks can be cancelled")
+	if !task.VisibleTo(tenant) {
+		return domain.ErrTaskNotFound
 	}
 
-	task.MarkAsCancelled()
+	if expectedVersion != nil && task.Version != *expectedVersion {
+		return domain.ErrVersionConflict
+	}
 
-	if err := uc.repository.Update(task); err != nil {
+	if err := task.MarkAsCancelled(); err != nil {
 		return err
 	}
 
-	return nil
+	return uc.repository.Update(task)
 }
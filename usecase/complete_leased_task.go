@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"encoding/json"
+	"go-task-queue-system/domain"
+	"log/slog"
+)
+
+type CompleteLeasedTaskUseCase struct {
+	repository domain.TaskRepository
+}
+
+func NewCompleteLeasedTaskUseCase(repository domain.TaskRepository) *CompleteLeasedTaskUseCase {
+	return &CompleteLeasedTaskUseCase{
+		repository: repository,
+	}
+}
+
+func (uc *CompleteLeasedTaskUseCase) Execute(taskID, workerID string, result map[string]interface{}) (*domain.Task, error) {
+	var attempt *domain.TaskAttempt
+
+	task, err := updateLeasedTask(uc.repository, taskID, workerID, func(task *domain.Task) error {
+		attempt = leaseAttempt(task, domain.AttemptOutcomeSucceeded, nil, resultSize(result))
+
+		return task.MarkAsCompleted(result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.repository.SaveAttempt(attempt); err != nil {
+		slog.Error("failed to record attempt", "task_id", task.ID, "attempt", attempt.Attempt, "error", err)
+	}
+
+	return task, nil
+}
+
+// resultSize returns the size of the result as JSON.
+func resultSize(result map[string]interface{}) int {
+	if result == nil {
+		return 0
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+)
+
+type DeleteTaskUseCase struct {
+	repository domain.TaskRepository
+	blobStore  domain.BlobStore
+}
+
+func NewDeleteTaskUseCase(repository domain.TaskRepository, blobStore domain.BlobStore) *DeleteTaskUseCase {
+	return &DeleteTaskUseCase{
+		repository: repository,
+		blobStore:  blobStore,
+	}
+}
+
+// Execute deletes a task that isn't being processed, with its artifacts.
+// The delete is versioned: if a worker picks the task up meanwhile, it
+// is checked again and refused.
+func (uc *DeleteTaskUseCase) Execute(taskID string, tenant string) error {
+	if taskID == "" {
+		return domain.ErrTaskNotFound
+	}
+
+	for i := 0; i < maxConflictRetries; i++ {
+		task, err := uc.repository.FindByID(taskID)
+		if err != nil {
+			return err
+		}
+
+		if !task.VisibleTo(tenant) {
+			return domain.ErrTaskNotFound
+		}
+
+		if task.Status == domain.TaskStatusProcessing {
+			return errors.New("tasks that are being processed cannot be deleted")
+		}
+
+		// Remove the artifacts first: if this fails the task is still there
+		// and the delete can be retried, instead of leaving orphaned blobs
+		// behind.
+		if err := uc.blobStore.DeletePrefix(task.ID); err != nil {
+			return errors.New("failed to delete task artifacts: " + err.Error())
+		}
+
+		err = uc.repository.Delete(task)
+		if err != domain.ErrVersionConflict {
+			return err
+		}
+	}
+	return domain.ErrVersionConflict
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"go-task-queue-system/infrastructure/storage"
+	"testing"
+)
+
+// pickedUpRepository has a worker start the task right before the first
+// delete, after the delete use case has read it.
+type pickedUpRepository struct {
+	*repository.MemoryRepository
+	pickedUp bool
+}
+
+func (r *pickedUpRepository) Delete(task *domain.Task) error {
+	if !r.pickedUp {
+		r.pickedUp = true
+		stored, err := r.FindByID(task.ID)
+		if err != nil {
+			return err
+		}
+		if err := stored.MarkAsProcessing(); err != nil {
+			return err
+		}
+		if err := r.Update(stored); err != nil {
+			return err
+		}
+	}
+	return r.MemoryRepository.Delete(task)
+}
+
+func TestDeleteTaskRefusesTaskPickedUpMeanwhile(t *testing.T) {
+	repo := &pickedUpRepository{MemoryRepository: repository.NewMemoryRepository()}
+	task := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewDeleteTaskUseCase(repo, blobs).Execute(task.ID, "acme"); err == nil {
+		t.Fatal("Execute() deleted a task a worker picked up meanwhile")
+	}
+	stored, err := repo.FindByID(task.ID)
+	if err != nil {
+		t.Fatalf("task is gone: %v", err)
+	}
+	if stored.Status != domain.TaskStatusProcessing {
+		t.Errorf("task is %s, want it left processing", stored.Status)
+	}
+}
+
+func TestDeleteTask(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	task := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteTask := NewDeleteTaskUseCase(repo, blobs)
+
+	if err := deleteTask.Execute(task.ID, "globex"); err != domain.ErrTaskNotFound {
+		t.Errorf("Execute() for another tenant = %v, want ErrTaskNotFound", err)
+	}
+	if err := deleteTask.Execute(task.ID, "acme"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, err := repo.FindByID(task.ID); err != domain.ErrTaskNotFound {
+		t.Errorf("FindByID() after delete = %v, want ErrTaskNotFound", err)
+	}
+}
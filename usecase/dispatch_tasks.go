@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DispatchTasksUseCase hands pending tasks from the repository, which is the
+// source of truth, to the queue. A task is claimed by setting its
+// DispatchedAt and DispatchedBy with a versioned update before it is queued,
+// so it is queued once however many callers try; one the queue has no room
+// for is released again and waits in the repository.
+//
+// Each dispatcher has its own instance ID, and its claims are only known to
+// be in a queue while it runs. A claim another dispatcher made more than
+// claimTTL ago is taken to be left over from one that is gone, and the task
+// is claimed again. Should that dispatcher still have the task queued, the
+// worker that gets it second finds it no longer pending and skips it.
+type DispatchTasksUseCase struct {
+	repository domain.TaskRepository
+	queue      TaskQueue
+	instanceID string
+	claimTTL   time.Duration
+
+	// remoteTaskTypes are leased by remote workers and never queued.
+	remoteTaskTypes map[domain.TaskType]bool
+
+	turns *fairTurns
+	mu    sync.Mutex
+}
+
+func NewDispatchTasksUseCase(repository domain.TaskRepository, queue TaskQueue, claimTTL time.Duration, remoteTaskTypes []domain.TaskType) *DispatchTasksUseCase {
+	remote := make(map[domain.TaskType]bool, len(remoteTaskTypes))
+	for _, taskType := range remoteTaskTypes {
+		remote[taskType] = true
+	}
+
+	return &DispatchTasksUseCase{
+		repository:      repository,
+		queue:           queue,
+		instanceID:      uuid.New().String(),
+		claimTTL:        claimTTL,
+		remoteTaskTypes: remote,
+		turns:           newFairTurns(),
+	}
+}
+
+// IsRemote reports whether tasks of the type are leased by remote workers
+// rather than queued.
+func (uc *DispatchTasksUseCase) IsRemote(taskType domain.TaskType) bool {
+	return uc.remoteTaskTypes[taskType]
+}
+
+// Execute queues the tasks awaiting dispatch, and those with an expired
+// claim, until the queue is full. The groups of a FairShareQueue take turns
+// by weight, so one group's backlog can't take all the room; within a
+// group, and with any other queue, tasks go highest priority and oldest
+// first. It returns the number of tasks it queued. Tasks of remote types
+// are left for remote workers, and tasks past their deadline to
+// ExpireTasksUseCase.
+func (uc *DispatchTasksUseCase) Execute() (int, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	pending, err := uc.repository.FindByStatus(domain.TaskStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	backlog := make([]*domain.Task, 0, len(pending))
+	for _, task := range pending {
+		if !uc.IsRemote(task.Type) && uc.claimable(task, now) {
+			backlog = append(backlog, task)
+		}
+	}
+
+	next := uc.turns.order(uc.queue, backlog)
+	dispatched := 0
+	for !uc.queue.IsFull() {
+		task := next()
+		if task == nil {
+			break
+		}
+		if task.Expired(now) {
+			continue
+		}
+
+		queued, err := uc.Dispatch(task)
+		if err != nil {
+			return dispatched, err
+		}
+		if queued {
+			dispatched++
+		}
+	}
+
+	return dispatched, nil
+}
+
+// Dispatch claims a stored pending task and queues it. It reports whether
+// the task is in the queue, whether it was queued here or by a concurrent
+// caller; a task the queue has no room for is left for Execute. Tasks of
+// remote types are left for remote workers to lease, and reported as on
+// their way.
+func (uc *DispatchTasksUseCase) Dispatch(task *domain.Task) (bool, error) {
+	if uc.IsRemote(task.Type) {
+		return task.Status == domain.TaskStatusPending, nil
+	}
+
+	claimed := false
+	for i := 0; i < maxConflictRetries && !claimed; i++ {
+		now := time.Now()
+		if !uc.claimable(task, now) {
+			return task.Status == domain.TaskStatusPending, nil
+		}
+
+		task.DispatchedAt = &now
+		task.DispatchedBy = uc.instanceID
+		err := uc.repository.Update(task)
+		if err == nil {
+			claimed = true
+			break
+		}
+		if err != domain.ErrVersionConflict {
+			return false, err
+		}
+
+		latest, err := uc.repository.FindByID(task.ID)
+		if err != nil {
+			return false, err
+		}
+		*task = *latest
+	}
+	if !claimed {
+		return false, domain.ErrVersionConflict
+	}
+
+	if err := uc.queue.Enqueue(task); err != nil {
+		slog.Debug("queue did not take task, leaving it in the backlog", "task_id", task.ID, "reason", err)
+		return false, uc.release(task)
+	}
+	return true, nil
+}
+
+// ReleaseClaims makes pending tasks that this dispatcher claimed, or whose
+// claim has expired, await dispatch again, and returns how many there
+// were. The queue lives in the server process, so the server calls it once
+// the queue is closed: the tasks still in it are then dispatched by the
+// next run straight away rather than after claimTTL.
+func (uc *DispatchTasksUseCase) ReleaseClaims() (int, error) {
+	pending, err := uc.repository.FindByStatus(domain.TaskStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	released := 0
+	for _, task := range pending {
+		if !uc.releasable(task, now) {
+			continue
+		}
+		if err := uc.release(task); err != nil {
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
+
+// claimable reports whether the task is pending and either unclaimed or
+// claimed by another dispatcher more than claimTTL ago.
+func (uc *DispatchTasksUseCase) claimable(task *domain.Task, now time.Time) bool {
+	if task.AwaitingDispatch() {
+		return true
+	}
+	return task.Status == domain.TaskStatusPending &&
+		task.DispatchedBy != uc.instanceID &&
+		now.Sub(*task.DispatchedAt) >= uc.claimTTL
+}
+
+// releasable reports whether the task is pending with a claim this
+// dispatcher made or one that has expired.
+func (uc *DispatchTasksUseCase) releasable(task *domain.Task, now time.Time) bool {
+	if task.Status != domain.TaskStatusPending || task.DispatchedAt == nil {
+		return false
+	}
+	return task.DispatchedBy == uc.instanceID || now.Sub(*task.DispatchedAt) >= uc.claimTTL
+}
+
+// release clears the claim on a task that isn't in the queue.
+func (uc *DispatchTasksUseCase) release(task *domain.Task) error {
+	for i := 0; i < maxConflictRetries; i++ {
+		task.DispatchedAt = nil
+		task.DispatchedBy = ""
+		err := uc.repository.Update(task)
+		if err != domain.ErrVersionConflict {
+			return err
+		}
+
+		latest, err := uc.repository.FindByID(task.ID)
+		if err != nil {
+			return err
+		}
+		if !uc.releasable(latest, time.Now()) {
+			// Cancelled, claimed by another dispatcher or otherwise taken
+			// care of meanwhile.
+			return nil
+		}
+		*task = *latest
+	}
+	return domain.ErrVersionConflict
+}
+
+// findBacklog returns the pending tasks awaiting dispatch.
+func findBacklog(repository domain.TaskRepository) ([]*domain.Task, error) {
+	pending, err := repository.FindByStatus(domain.TaskStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	backlog := make([]*domain.Task, 0, len(pending))
+	for _, task := range pending {
+		if task.AwaitingDispatch() {
+			backlog = append(backlog, task)
+		}
+	}
+	return backlog, nil
+}
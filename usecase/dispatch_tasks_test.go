@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"testing"
+	"time"
+)
+
+func TestDispatchClaimsTasksItQueues(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	low := storePending(t, repo, "acme", domain.TaskPriorityLow)
+	high := storePending(t, repo, "acme", domain.TaskPriorityHigh)
+	medium := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	queue := &fakeQueue{capacity: 2}
+	dispatched, err := NewDispatchTasksUseCase(repo, queue, time.Minute, nil).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if dispatched != 2 {
+		t.Fatalf("Execute() = %d, want 2", dispatched)
+	}
+	if queue.tasks[0].ID != high.ID || queue.tasks[1].ID != medium.ID {
+		t.Errorf("queued %s then %s, want high then medium priority", queue.tasks[0].Priority, queue.tasks[1].Priority)
+	}
+
+	for _, task := range []*domain.Task{high, medium} {
+		stored, _ := repo.FindByID(task.ID)
+		if stored.DispatchedAt == nil {
+			t.Errorf("queued %s task isn't claimed", stored.Priority)
+		}
+	}
+	if stored, _ := repo.FindByID(low.ID); !stored.AwaitingDispatch() {
+		t.Error("task the queue had no room for isn't awaiting dispatch")
+	}
+}
+
+func TestDispatchQueuesATaskOnce(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	task := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	queue := &fakeQueue{capacity: 10}
+	dispatcher := NewDispatchTasksUseCase(repo, queue, time.Minute, nil)
+
+	// Two callers holding copies read before either claimed the task.
+	first, _ := repo.FindByID(task.ID)
+	second, _ := repo.FindByID(task.ID)
+	for _, stale := range []*domain.Task{first, second} {
+		queued, err := dispatcher.Dispatch(stale)
+		if err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if !queued {
+			t.Error("Dispatch() = false, want the task reported as queued")
+		}
+	}
+
+	if dispatched, _ := dispatcher.Execute(); dispatched != 0 {
+		t.Errorf("Execute() queued %d claimed tasks again", dispatched)
+	}
+	if len(queue.tasks) != 1 {
+		t.Errorf("task queued %d times, want once", len(queue.tasks))
+	}
+}
+
+func TestDispatchReleasesClaimWhenQueueRefuses(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	task := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	queue := &fakeQueue{capacity: 10, reject: true}
+	queued, err := NewDispatchTasksUseCase(repo, queue, time.Minute, nil).Dispatch(task)
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if queued {
+		t.Error("Dispatch() = true for a task the queue refused")
+	}
+
+	stored, _ := repo.FindByID(task.ID)
+	if !stored.AwaitingDispatch() {
+		t.Error("refused task is still claimed")
+	}
+}
+
+func TestReleaseClaims(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	queue := &fakeQueue{capacity: 10}
+	dispatcher := NewDispatchTasksUseCase(repo, queue, time.Minute, nil)
+
+	own := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	if _, err := dispatcher.Dispatch(own); err != nil {
+		t.Fatal(err)
+	}
+	expired := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	claimAs(t, repo, expired, "gone", time.Now().Add(-2*time.Minute))
+	live := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	claimAs(t, repo, live, "other", time.Now())
+	running := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	claimAs(t, repo, running, "gone", time.Now().Add(-2*time.Minute))
+	if err := running.MarkAsProcessing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(running); err != nil {
+		t.Fatal(err)
+	}
+
+	released, err := dispatcher.ReleaseClaims()
+	if err != nil {
+		t.Fatalf("ReleaseClaims() error = %v", err)
+	}
+	if released != 2 {
+		t.Errorf("ReleaseClaims() = %d, want 2", released)
+	}
+
+	for _, task := range []*domain.Task{own, expired} {
+		if stored, _ := repo.FindByID(task.ID); !stored.AwaitingDispatch() {
+			t.Errorf("pending task %s isn't awaiting dispatch", task.ID)
+		}
+	}
+	if stored, _ := repo.FindByID(live.ID); stored.DispatchedBy != "other" {
+		t.Error("another dispatcher's live claim was released")
+	}
+	if stored, _ := repo.FindByID(running.ID); stored.DispatchedAt == nil {
+		t.Error("claim of a processing task was released")
+	}
+}
+
+func TestDispatchTakesOverExpiredClaims(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	expired := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	claimAs(t, repo, expired, "gone", time.Now().Add(-2*time.Minute))
+	live := storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	claimAs(t, repo, live, "other", time.Now())
+
+	queue := &fakeQueue{capacity: 10}
+	dispatcher := NewDispatchTasksUseCase(repo, queue, time.Minute, nil)
+	dispatched, err := dispatcher.Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if dispatched != 1 || queue.tasks[0].ID != expired.ID {
+		t.Fatalf("Execute() queued %d tasks, want only the one with an expired claim", dispatched)
+	}
+
+	stored, _ := repo.FindByID(expired.ID)
+	if stored.DispatchedBy != dispatcher.instanceID {
+		t.Errorf("DispatchedBy = %q, want this dispatcher", stored.DispatchedBy)
+	}
+	if dispatched, _ := dispatcher.Execute(); dispatched != 0 {
+		t.Errorf("Execute() queued %d tasks this dispatcher already claimed", dispatched)
+	}
+}
+
+// claimAs stores the task as claimed by another dispatcher at the given
+// time.
+func claimAs(t *testing.T, repo domain.TaskRepository, task *domain.Task, dispatcher string, at time.Time) {
+	t.Helper()
+
+	task.DispatchedAt = &at
+	task.DispatchedBy = dispatcher
+	if err := repo.Update(task); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatchTakesFairShareTurns(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	for i := 0; i < 6; i++ {
+		storePending(t, repo, "busy", domain.TaskPriorityHigh)
+	}
+	for i := 0; i < 6; i++ {
+		storePending(t, repo, "quiet", domain.TaskPriorityLow)
+	}
+
+	queue := &fakeFairQueue{fakeQueue: fakeQueue{capacity: 3}, weights: map[string]int{"quiet": 2}}
+	dispatcher := NewDispatchTasksUseCase(repo, queue, time.Minute, nil)
+
+	counts := make(map[string]int)
+	for run := 0; run < 2; run++ {
+		if _, err := dispatcher.Execute(); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		for _, task := range queue.tasks {
+			counts[task.Tenant]++
+		}
+		// Workers take everything, so the next run has room again.
+		queue.tasks = nil
+	}
+
+	if counts["busy"] != 2 || counts["quiet"] != 4 {
+		t.Errorf("dispatched %v, want 2 busy and 4 quiet tasks for weights 1:2", counts)
+	}
+}
+
+func TestDispatchLeavesRemoteTypesToRemoteWorkers(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	remote := storePending(t, repo, "acme", domain.TaskPriorityHigh)
+	local, _ := domain.NewTask(domain.TaskTypeCommand, domain.TaskPriorityLow, map[string]interface{}{"command": "true"})
+	if err := repo.Save(local); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := &fakeQueue{capacity: 10}
+	dispatcher := NewDispatchTasksUseCase(repo, queue, time.Minute, []domain.TaskType{domain.TaskTypeEmail})
+	dispatched, err := dispatcher.Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if dispatched != 1 || queue.tasks[0].ID != local.ID {
+		t.Fatalf("Execute() queued %d tasks, want only the local one", dispatched)
+	}
+
+	queued, err := dispatcher.Dispatch(remote)
+	if err != nil || !queued {
+		t.Errorf("Dispatch() of a remote task = %v, %v, want it reported as on its way", queued, err)
+	}
+	if stored, _ := repo.FindByID(remote.ID); stored.DispatchedAt != nil {
+		t.Error("remote task was claimed for the queue")
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"fmt"
+	"go-task-queue-system/domain"
+	"log/slog"
+	"time"
+)
+
+var errLeaseExpired = fmt.Errorf("%w: remote worker stopped responding", domain.ErrLeaseExpired)
+
+// ExpireLeasesUseCase takes back tasks from remote workers that stopped
+// renewing their lease. The attempt counts as a failed, retryable one,
+// unless the task is past its deadline and expires.
+type ExpireLeasesUseCase struct {
+	repository domain.TaskRepository
+}
+
+func NewExpireLeasesUseCase(repository domain.TaskRepository) *ExpireLeasesUseCase {
+	return &ExpireLeasesUseCase{
+		repository: repository,
+	}
+}
+
+// Execute returns the number of tasks whose lease had expired.
+func (uc *ExpireLeasesUseCase) Execute(now time.Time) (int, error) {
+	processing, err := uc.repository.FindByStatus(domain.TaskStatusProcessing)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, task := range processing {
+		if !task.LeaseExpired(now) {
+			continue
+		}
+
+		attempt := leaseAttempt(task, domain.AttemptOutcomeFailed, errLeaseExpired, 0)
+		var err error
+		if task.Expired(now) {
+			// No point in another attempt once the task's own deadline has
+			// passed too.
+			err = task.MarkAsExpired(errLeaseExpired)
+		} else {
+			err = task.MarkAttemptFailed(errLeaseExpired)
+		}
+		if err != nil {
+			continue
+		}
+
+		// A conflict means the worker finished or renewed just now.
+		if err := uc.repository.Update(task); err != nil {
+			continue
+		}
+
+		if err := uc.repository.SaveAttempt(attempt); err != nil {
+			slog.Error("failed to record attempt", "task_id", task.ID, "attempt", attempt.Attempt, "error", err)
+		}
+		expired++
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"time"
+)
+
+// ExpireTasksUseCase moves pending tasks whose deadline has passed to the
+// expired status, so they are never run.
+type ExpireTasksUseCase struct {
+	repository domain.TaskRepository
+}
+
+func NewExpireTasksUseCase(repository domain.TaskRepository) *ExpireTasksUseCase {
+	return &ExpireTasksUseCase{
+		repository: repository,
+	}
+}
+
+// Execute returns the number of tasks that expired.
+func (uc *ExpireTasksUseCase) Execute(now time.Time) (int, error) {
+	pending, err := uc.repository.FindByStatus(domain.TaskStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, task := range pending {
+		if !task.Expired(now) {
+			continue
+		}
+		if err := task.MarkAsExpired(nil); err != nil {
+			continue
+		}
+
+		// A conflict means a worker picked the task up just now; it will
+		// see the deadline itself.
+		if err := uc.repository.Update(task); err != nil {
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go-task-queue-system/domain"
+	"log/slog"
+	"time"
+)
+
+type FailLeasedTaskUseCase struct {
+	repository domain.TaskRepository
+}
+
+func NewFailLeasedTaskUseCase(repository domain.TaskRepository) *FailLeasedTaskUseCase {
+	return &FailLeasedTaskUseCase{
+		repository: repository,
+	}
+}
+
+// Execute records a failed attempt by a remote worker, which reports why it
+// failed with reason and may send what the attempt returned as result.
+// Permanent failures use up the task's remaining retries, and a task past
+// its deadline expires instead.
+func (uc *FailLeasedTaskUseCase) Execute(taskID, workerID, message string, reason domain.FailureReason, permanent bool, result map[string]interface{}) (*domain.Task, error) {
+	if message == "" {
+		message = "task failed on remote worker"
+	}
+	failure := errors.New(message)
+	switch reason {
+	case domain.FailureReasonTimeout:
+		failure = fmt.Errorf("%w: %s", domain.ErrTaskTimedOut, message)
+	case domain.FailureReasonExpired:
+		failure = fmt.Errorf("%w: %s", domain.ErrTaskExpired, message)
+	}
+
+	var attempt *domain.TaskAttempt
+
+	task, err := updateLeasedTask(uc.repository, taskID, workerID, func(task *domain.Task) error {
+		if task.Expired(time.Now()) && !errors.Is(failure, domain.ErrTaskExpired) {
+			failure = fmt.Errorf("%w: %v", domain.ErrTaskExpired, failure)
+		}
+		attempt = leaseAttempt(task, domain.AttemptOutcomeFailed, failure, resultSize(result))
+
+		if errors.Is(failure, domain.ErrTaskExpired) {
+			return task.MarkAsExpired(failure)
+		}
+
+		mark := task.MarkAttemptFailed
+		if permanent {
+			mark = task.MarkAsPermanentlyFailed
+		}
+		if err := mark(failure); err != nil {
+			return err
+		}
+		task.Result = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.repository.SaveAttempt(attempt); err != nil {
+		slog.Error("failed to record attempt", "task_id", task.ID, "attempt", attempt.Attempt, "error", err)
+	}
+
+	return task, nil
+}
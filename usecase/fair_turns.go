@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"sort"
+)
+
+// fairTurns is the smooth weighted round-robin state of the groups of a
+// FairShareQueue. It is kept between runs, so the groups take turns at
+// whatever frees up: room in the queue for the dispatcher, a lease request
+// for remote workers. Callers serialize access.
+type fairTurns struct {
+	credits map[string]int
+}
+
+func newFairTurns() *fairTurns {
+	return &fairTurns{credits: make(map[string]int)}
+}
+
+// order returns a function that hands out the waiting tasks one at a time,
+// and nil at the end. The groups of queue take turns by weight if it is a
+// FairShareQueue; within a group, and with any other queue, tasks go
+// highest priority and oldest first.
+func (f *fairTurns) order(queue TaskQueue, tasks []*domain.Task) func() *domain.Task {
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Priority.Rank() != tasks[j].Priority.Rank() {
+			return tasks[i].Priority.Rank() > tasks[j].Priority.Rank()
+		}
+		return tasks[i].PendingSince().Before(tasks[j].PendingSince())
+	})
+
+	fair, ok := queue.(FairShareQueue)
+	if !ok {
+		return func() *domain.Task {
+			if len(tasks) == 0 {
+				return nil
+			}
+			task := tasks[0]
+			tasks = tasks[1:]
+			return task
+		}
+	}
+
+	var order []string
+	groups := make(map[string][]*domain.Task)
+	for _, task := range tasks {
+		key := fair.GroupOf(task)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], task)
+	}
+
+	// Groups without waiting tasks start from scratch when they get some
+	// again instead of banking credit.
+	for key := range f.credits {
+		if _, ok := groups[key]; !ok {
+			delete(f.credits, key)
+		}
+	}
+
+	return func() *domain.Task {
+		picked := ""
+		found := false
+		totalWeight := 0
+		for _, key := range order {
+			if len(groups[key]) == 0 {
+				continue
+			}
+			weight := fair.WeightOf(key)
+			f.credits[key] += weight
+			totalWeight += weight
+			if !found || f.credits[key] > f.credits[picked] {
+				picked, found = key, true
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		f.credits[picked] -= totalWeight
+		task := groups[picked][0]
+		groups[picked] = groups[picked][1:]
+		return task
+	}
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"testing"
+)
+
+// fakeQueue holds what it is given, up to capacity. reject makes it refuse
+// tasks although it reports room, like a queue that filled up meanwhile.
+type fakeQueue struct {
+	capacity int
+	tasks    []*domain.Task
+	reject   bool
+}
+
+func (q *fakeQueue) Enqueue(task *domain.Task) error {
+	if q.reject || len(q.tasks) >= q.capacity {
+		return errors.New("queue is full")
+	}
+	q.tasks = append(q.tasks, task)
+	return nil
+}
+
+func (q *fakeQueue) Size() int { return len(q.tasks) }
+
+func (q *fakeQueue) IsFull() bool { return len(q.tasks) >= q.capacity }
+
+// fakeFairQueue groups tasks by tenant.
+type fakeFairQueue struct {
+	fakeQueue
+	weights map[string]int
+}
+
+func (q *fakeFairQueue) GroupOf(task *domain.Task) string { return task.Tenant }
+
+func (q *fakeFairQueue) WeightOf(group string) int {
+	if weight, ok := q.weights[group]; ok {
+		return weight
+	}
+	return 1
+}
+
+func storePending(t *testing.T, repo domain.TaskRepository, tenant string, priority domain.TaskPriority) *domain.Task {
+	t.Helper()
+
+	task, err := domain.NewTask(domain.TaskTypeEmail, priority, map[string]interface{}{"to": "ops@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.Tenant = tenant
+	if err := repo.Save(task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"io"
+)
+
+type GetArtifactUseCase struct {
+	repository domain.TaskRepository
+	blobStore  domain.BlobStore
+}
+
+func NewGetArtifactUseCase(repository domain.TaskRepository, blobStore domain.BlobStore) *GetArtifactUseCase {
+	return &GetArtifactUseCase{
+		repository: repository,
+		blobStore:  blobStore,
+	}
+}
+
+// Execute returns the artifact metadata and its content. The caller must
+// close the returned reader.
+func (uc *GetArtifactUseCase) Execute(taskID, name, tenant string) (*domain.Artifact, io.ReadCloser, error) {
+	if taskID == "" {
+		return nil, nil, domain.ErrTaskNotFound
+	}
+
+	if err := domain.ValidateArtifactName(name); err != nil {
+		return nil, nil, domain.ErrArtifactNotFound
+	}
+
+	task, err := uc.repository.FindByID(taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !task.VisibleTo(tenant) {
+		return nil, nil, domain.ErrTaskNotFound
+	}
+
+	artifact, ok := task.FindArtifact(name)
+	if !ok {
+		return nil, nil, domain.ErrArtifactNotFound
+	}
+
+	content, err := uc.blobStore.Get(domain.ArtifactKey(task.ID, name))
+	if err != nil {
+		if err == domain.ErrBlobNotFound {
+			return nil, nil, domain.ErrArtifactNotFound
+		}
+		return nil, nil, err
+	}
+
+	return artifact, content, nil
+}
@@ -9,7 +9,12 @@ type TaskStats struct {
 	CompletedTasks  int `json:"completed_tasks"`
 	FailedTasks     int `json:"failed_tasks"`
 	CancelledTasks  int `json:"cancelled_tasks"`
+	ExpiredTasks    int `json:"expired_tasks"`
 	QueueSize       int `json:"queue_size"`
+	// BacklogSize counts pending tasks not yet handed to the queue.
+	BacklogSize int `json:"backlog_size"`
+
+	QueueShares []QueueShare `json:"queue_shares,omitempty"`
 }
 
 type GetStatsUseCase struct {
@@ -24,7 +29,14 @@ func NewGetStatsUseCase(repository domain.TaskRepository, queue TaskQueue) *GetS
 	}
 }
 
-func (uc *GetStatsUseCase) Execute() (*TaskStats, error) {
+// Execute returns the stats of tenant, or of the whole system if tenant is
+// empty. For a tenant, QueueSize is the number of its tasks still waiting
+// for a worker.
+func (uc *GetStatsUseCase) Execute(tenant string) (*TaskStats, error) {
+	if tenant != "" {
+		return uc.executeForTenant(tenant)
+	}
+
 	stats := &TaskStats{}
 
 	total, err := uc.repository.Count()
@@ -48,7 +60,92 @@ func (uc *GetStatsUseCase) Execute() (*TaskStats, error) {
 	cancelled, _ := uc.repository.CountByStatus(domain.TaskStatusCancelled)
 	stats.CancelledTasks = cancelled
 
+	expired, _ := uc.repository.CountByStatus(domain.TaskStatusExpired)
+	stats.ExpiredTasks = expired
+
 	stats.QueueSize = uc.queue.Size()
 
+	backlog, err := findBacklog(uc.repository)
+	if err != nil {
+		return nil, err
+	}
+	stats.BacklogSize = len(backlog)
+
+	if reporter, ok := uc.queue.(ShareReporter); ok {
+		stats.QueueShares = reporter.Shares()
+	}
+
 	return stats, nil
 }
+
+// ExecutePerTenant returns the stats of every tenant that has tasks.
+func (uc *GetStatsUseCase) ExecutePerTenant() (map[string]*TaskStats, error) {
+	tasks, err := uc.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	perTenant := make(map[string]*TaskStats)
+	for _, task := range tasks {
+		stats, ok := perTenant[task.Tenant]
+		if !ok {
+			stats = &TaskStats{}
+			perTenant[task.Tenant] = stats
+		}
+		stats.addCount(task.Status, 1)
+		if task.AwaitingDispatch() {
+			stats.BacklogSize++
+		}
+	}
+
+	for _, stats := range perTenant {
+		stats.QueueSize = stats.PendingTasks
+	}
+
+	return perTenant, nil
+}
+
+func (uc *GetStatsUseCase) executeForTenant(tenant string) (*TaskStats, error) {
+	stats := &TaskStats{}
+
+	for _, status := range domain.TaskStatuses() {
+		count, err := uc.repository.CountByTenantAndStatus(tenant, status)
+		if err != nil {
+			return nil, err
+		}
+		stats.addCount(status, count)
+	}
+
+	stats.QueueSize = stats.PendingTasks
+
+	backlog, err := findBacklog(uc.repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range backlog {
+		if task.Tenant == tenant {
+			stats.BacklogSize++
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *TaskStats) addCount(status domain.TaskStatus, count int) {
+	s.TotalTasks += count
+
+	switch status {
+	case domain.TaskStatusPending:
+		s.PendingTasks += count
+	case domain.TaskStatusProcessing:
+		s.ProcessingTasks += count
+	case domain.TaskStatusCompleted:
+		s.CompletedTasks += count
+	case domain.TaskStatusFailed:
+		s.FailedTasks += count
+	case domain.TaskStatusCancelled:
+		s.CancelledTasks += count
+	case domain.TaskStatusExpired:
+		s.ExpiredTasks += count
+	}
+}
@@ -12,7 +12,9 @@ func NewGetTaskUseCase(repository domain.TaskRepository) *GetTaskUseCase {
 	}
 }
 
-func (uc *GetTaskUseCase) Execute(taskID string) (*domain.Task, error) {
+// Execute returns the task if it belongs to tenant. An empty tenant may see
+// tasks of every tenant.
+func (uc *GetTaskUseCase) Execute(taskID string, tenant string) (*domain.Task, error) {
 	if taskID == "" {
 		return nil, domain.ErrTaskNotFound
 	}
@@ -22,5 +24,10 @@ func (uc *GetTaskUseCase) Execute(taskID string) (*domain.Task, error) {
 		return nil, err
 	}
 
+	// Tasks of other tenants are reported as missing so their IDs don't leak.
+	if !task.VisibleTo(tenant) {
+		return nil, domain.ErrTaskNotFound
+	}
+
 	return task, nil
 }
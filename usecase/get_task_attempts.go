@@ -0,0 +1,30 @@
+package usecase
+
+import "go-task-queue-system/domain"
+
+type GetTaskAttemptsUseCase struct {
+	repository domain.TaskRepository
+}
+
+func NewGetTaskAttemptsUseCase(repository domain.TaskRepository) *GetTaskAttemptsUseCase {
+	return &GetTaskAttemptsUseCase{
+		repository: repository,
+	}
+}
+
+func (uc *GetTaskAttemptsUseCase) Execute(taskID string, tenant string) ([]*domain.TaskAttempt, error) {
+	if taskID == "" {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	task, err := uc.repository.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !task.VisibleTo(tenant) {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	return uc.repository.FindAttemptsByTaskID(taskID)
+}
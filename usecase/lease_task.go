@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"sync"
+	"time"
+)
+
+// maxLeaseDuration caps the lease a remote worker may ask for, so a worker
+// that disappears can't hold a task for long.
+const maxLeaseDuration = 10 * time.Minute
+
+// LeaseTaskUseCase hands pending tasks to remote workers. The fair-share
+// groups of the queue take turns at leases like they do at room in the
+// queue, see DispatchTasksUseCase.
+type LeaseTaskUseCase struct {
+	repository    domain.TaskRepository
+	queue         TaskQueue
+	leaseDuration time.Duration
+
+	turns *fairTurns
+	mu    sync.Mutex
+}
+
+func NewLeaseTaskUseCase(repository domain.TaskRepository, queue TaskQueue, leaseDuration time.Duration) *LeaseTaskUseCase {
+	return &LeaseTaskUseCase{
+		repository:    repository,
+		queue:         queue,
+		leaseDuration: leaseDuration,
+		turns:         newFairTurns(),
+	}
+}
+
+// Execute leases a pending task whose type is one of taskTypes. The
+// fair-share groups take turns by weight; within a group, tasks go highest
+// priority and oldest first. An empty tenant leases from every tenant. It
+// returns domain.ErrNoTaskAvailable if there is nothing to do.
+func (uc *LeaseTaskUseCase) Execute(workerID string, taskTypes []domain.TaskType, tenant string, leaseFor time.Duration) (*domain.Task, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	pending, err := uc.repository.FindByStatus(domain.TaskStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[domain.TaskType]bool, len(taskTypes))
+	for _, taskType := range taskTypes {
+		wanted[taskType] = true
+	}
+
+	// Tasks past their deadline are left for ExpireTasksUseCase.
+	now := time.Now()
+	candidates := make([]*domain.Task, 0, len(pending))
+	for _, task := range pending {
+		if wanted[task.Type] && task.VisibleTo(tenant) && !task.Expired(now) {
+			candidates = append(candidates, task)
+		}
+	}
+
+	next := uc.turns.order(uc.queue, candidates)
+	until := now.Add(clampLease(leaseFor, uc.leaseDuration))
+	for task := next(); task != nil; task = next() {
+		if err := task.Lease(workerID, until); err != nil {
+			continue
+		}
+
+		err := uc.repository.Update(task)
+		if err == nil {
+			return task, nil
+		}
+		// Someone else got to the task first; try the next one.
+		if err != domain.ErrVersionConflict && err != domain.ErrTaskNotFound {
+			return nil, err
+		}
+	}
+
+	return nil, domain.ErrNoTaskAvailable
+}
+
+func clampLease(requested, fallback time.Duration) time.Duration {
+	if requested <= 0 {
+		requested = fallback
+	}
+	return min(requested, maxLeaseDuration)
+}
+
+// updateLeasedTask applies change to the task if workerID still holds its
+// lease, re-reading the task after a conflicting update.
+func updateLeasedTask(repository domain.TaskRepository, taskID, workerID string, change func(*domain.Task) error) (*domain.Task, error) {
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		var task *domain.Task
+		task, err = repository.FindByID(taskID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !task.HoldsLease(workerID) {
+			return nil, domain.ErrLeaseNotHeld
+		}
+
+		if err = change(task); err != nil {
+			return nil, err
+		}
+
+		err = repository.Update(task)
+		if err == nil {
+			return task, nil
+		}
+		if err != domain.ErrVersionConflict {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+// leaseAttempt describes the attempt a remote worker made on a leased task.
+func leaseAttempt(task *domain.Task, outcome domain.AttemptOutcome, err error, resultSize int) *domain.TaskAttempt {
+	attempt := domain.NewTaskAttempt(task, task.LeasedBy)
+	if task.StartedAt != nil {
+		attempt.StartedAt = *task.StartedAt
+	}
+	attempt.Finish(outcome, err, resultSize)
+	return attempt
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"testing"
+	"time"
+)
+
+func TestLeaseTaskPicksHighestPriorityOfWantedTypes(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	low := storePending(t, repo, "acme", domain.TaskPriorityLow)
+	high := storePending(t, repo, "acme", domain.TaskPriorityHigh)
+
+	other, _ := domain.NewTask(domain.TaskTypeCommand, domain.TaskPriorityHigh, map[string]interface{}{"command": "true"})
+	other.Tenant = "acme"
+	if err := repo.Save(other); err != nil {
+		t.Fatal(err)
+	}
+
+	lease := NewLeaseTaskUseCase(repo, &fakeQueue{}, 30*time.Second)
+	types := []domain.TaskType{domain.TaskTypeEmail}
+
+	for _, want := range []*domain.Task{high, low} {
+		task, err := lease.Execute("worker-1", types, "", 0)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if task.ID != want.ID {
+			t.Errorf("leased %s (%s), want %s", task.ID, task.Priority, want.ID)
+		}
+		if !task.HoldsLease("worker-1") || task.Status != domain.TaskStatusProcessing {
+			t.Errorf("leased task is %s, held by %q", task.Status, task.LeasedBy)
+		}
+	}
+
+	if _, err := lease.Execute("worker-1", types, "", 0); !errors.Is(err, domain.ErrNoTaskAvailable) {
+		t.Errorf("Execute() with only other types pending = %v, want ErrNoTaskAvailable", err)
+	}
+}
+
+func TestLeaseTaskStaysWithinTenant(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	storePending(t, repo, "globex", domain.TaskPriorityMedium)
+
+	lease := NewLeaseTaskUseCase(repo, &fakeQueue{}, 30*time.Second)
+	if _, err := lease.Execute("worker-1", []domain.TaskType{domain.TaskTypeEmail}, "acme", 0); !errors.Is(err, domain.ErrNoTaskAvailable) {
+		t.Errorf("Execute() for another tenant = %v, want ErrNoTaskAvailable", err)
+	}
+}
+
+func TestLeaseTaskClampsLeaseDuration(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	lease := NewLeaseTaskUseCase(repo, &fakeQueue{}, 30*time.Second)
+	types := []domain.TaskType{domain.TaskTypeEmail}
+
+	task, err := lease.Execute("worker-1", types, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(*task.LeaseExpiresAt); until <= 25*time.Second || until > 30*time.Second {
+		t.Errorf("default lease runs for %v, want the 30s default", until)
+	}
+
+	task, err = lease.Execute("worker-1", types, "", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(*task.LeaseExpiresAt); until > maxLeaseDuration {
+		t.Errorf("lease runs for %v, want at most %v", until, maxLeaseDuration)
+	}
+}
+
+func TestRenewLeaseOnlyByHolder(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	leased, err := NewLeaseTaskUseCase(repo, &fakeQueue{}, time.Second).Execute("worker-1", []domain.TaskType{domain.TaskTypeEmail}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renew := NewRenewLeaseUseCase(repo, time.Minute)
+	progress := &domain.TaskProgress{Percent: 40, Stage: "sending"}
+	task, err := renew.Execute(leased.ID, "worker-1", 0, progress)
+	if err != nil {
+		t.Fatalf("Execute() by the holder error = %v", err)
+	}
+	if !task.LeaseExpiresAt.After(*leased.LeaseExpiresAt) {
+		t.Error("heartbeat didn't extend the lease")
+	}
+	if task.Progress == nil || task.Progress.Percent != 40 {
+		t.Errorf("heartbeat didn't record progress: %+v", task.Progress)
+	}
+
+	if _, err := renew.Execute(leased.ID, "worker-2", 0, nil); !errors.Is(err, domain.ErrLeaseNotHeld) {
+		t.Errorf("Execute() by another worker = %v, want ErrLeaseNotHeld", err)
+	}
+}
+
+func TestExpireLeasesRetriesTask(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+	leased, err := NewLeaseTaskUseCase(repo, &fakeQueue{}, time.Second).Execute("worker-1", []domain.TaskType{domain.TaskTypeEmail}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expire := NewExpireLeasesUseCase(repo)
+	if expired, err := expire.Execute(time.Now()); err != nil || expired != 0 {
+		t.Fatalf("Execute() before the lease ran out = %d, %v; want nothing expired", expired, err)
+	}
+	if expired, err := expire.Execute(time.Now().Add(time.Minute)); err != nil || expired != 1 {
+		t.Fatalf("Execute() after the lease ran out = %d, %v; want 1 expired", expired, err)
+	}
+
+	task, _ := repo.FindByID(leased.ID)
+	if task.Status != domain.TaskStatusPending || task.RetryCount != 1 || task.LeasedBy != "" {
+		t.Errorf("expired task is %s with %d retries, leased by %q; want pending for a retry", task.Status, task.RetryCount, task.LeasedBy)
+	}
+	attempts, _ := repo.FindAttemptsByTaskID(leased.ID)
+	if len(attempts) != 1 || attempts[0].Outcome != domain.AttemptOutcomeFailed {
+		t.Errorf("attempts = %+v, want one failed attempt", attempts)
+	}
+
+	// The worker that lost the task can't finish it any more.
+	if _, err := NewCompleteLeasedTaskUseCase(repo).Execute(leased.ID, "worker-1", nil); !errors.Is(err, domain.ErrLeaseNotHeld) {
+		t.Errorf("completing an expired lease = %v, want ErrLeaseNotHeld", err)
+	}
+}
+
+func TestFailLeasedTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		permanent  bool
+		wantStatus domain.TaskStatus
+	}{
+		{"retryable", false, domain.TaskStatusPending},
+		{"permanent", true, domain.TaskStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryRepository()
+			storePending(t, repo, "acme", domain.TaskPriorityMedium)
+
+			leased, err := NewLeaseTaskUseCase(repo, &fakeQueue{}, time.Minute).Execute("worker-1", []domain.TaskType{domain.TaskTypeEmail}, "", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := map[string]interface{}{"reply": "421 try again later"}
+			task, err := NewFailLeasedTaskUseCase(repo).Execute(leased.ID, "worker-1", "smtp down", domain.FailureReasonError, tt.permanent, result)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if task.Status != tt.wantStatus || task.Error != "smtp down" {
+				t.Errorf("task is %s with error %q, want %s", task.Status, task.Error, tt.wantStatus)
+			}
+			if task.Result["reply"] != "421 try again later" {
+				t.Errorf("Result = %v, want what the attempt returned", task.Result)
+			}
+			if tt.permanent && !task.IsInDeadLetterQueue() {
+				t.Error("permanently failed task isn't in the dead letter queue")
+			}
+		})
+	}
+}
+
+func TestLeaseTaskTakesFairShareTurns(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	for i := 0; i < 6; i++ {
+		storePending(t, repo, "busy", domain.TaskPriorityHigh)
+	}
+	for i := 0; i < 6; i++ {
+		storePending(t, repo, "quiet", domain.TaskPriorityLow)
+	}
+
+	queue := &fakeFairQueue{weights: map[string]int{"quiet": 2}}
+	lease := NewLeaseTaskUseCase(repo, queue, 30*time.Second)
+
+	counts := make(map[string]int)
+	for i := 0; i < 6; i++ {
+		task, err := lease.Execute("worker-1", []domain.TaskType{domain.TaskTypeEmail}, "", 0)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		counts[task.Tenant]++
+	}
+
+	if counts["busy"] != 2 || counts["quiet"] != 4 {
+		t.Errorf("leased %v, want 2 busy and 4 quiet tasks for weights 1:2", counts)
+	}
+}
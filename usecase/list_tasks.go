@@ -12,16 +12,17 @@ func NewListTasksUseCase(repository domain.TaskRepository) *ListTasksUseCase {
 	}
 }
 
-func (uc *ListTasksUseCase) Execute() ([]*domain.Task, error) {
+// Execute lists the tasks of tenant, or of every tenant if it is empty.
+func (uc *ListTasksUseCase) Execute(tenant string) ([]*domain.Task, error) {
 	tasks, err := uc.repository.FindAll()
 	if err != nil {
 		return nil, err
 	}
 
-	return tasks, nil
+	return filterByTenant(tasks, tenant), nil
 }
 
-func (uc *ListTasksUseCase) ExecuteByStatus(status domain.TaskStatus) ([]*domain.Task, error) {
+func (uc *ListTasksUseCase) ExecuteByStatus(status domain.TaskStatus, tenant string) ([]*domain.Task, error) {
 	if !status.IsValid() {
 		return nil, domain.ErrInvalidTaskStatus
 	}
@@ -31,5 +32,19 @@ func (uc *ListTasksUseCase) ExecuteByStatus(status domain.TaskStatus) ([]*domain
 		return nil, err
 	}
 
-	return tasks, nil
+	return filterByTenant(tasks, tenant), nil
+}
+
+func filterByTenant(tasks []*domain.Task, tenant string) []*domain.Task {
+	if tenant == "" {
+		return tasks
+	}
+
+	filtered := make([]*domain.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.VisibleTo(tenant) {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
 }
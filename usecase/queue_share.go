@@ -0,0 +1,28 @@
+package usecase
+
+import "go-task-queue-system/domain"
+
+// QueueShare describes how much of the dispatch capacity one group of tasks
+// (e.g. a tenant) is getting from a fair-share queue.
+type QueueShare struct {
+	Group       string  `json:"group"`
+	Weight      int     `json:"weight"`
+	Queued      int     `json:"queued"`
+	Dispatched  int     `json:"dispatched"`
+	Share       float64 `json:"share"`
+	TargetShare float64 `json:"target_share"`
+}
+
+// ShareReporter is implemented by queues that dispatch fairly between
+// groups of tasks.
+type ShareReporter interface {
+	Shares() []QueueShare
+}
+
+// FairShareQueue is implemented by queues that share out dispatch capacity
+// between groups of tasks by weight. The dispatcher uses the same groups
+// and weights to decide whose waiting tasks get room in the queue.
+type FairShareQueue interface {
+	GroupOf(task *domain.Task) string
+	WeightOf(group string) int
+}
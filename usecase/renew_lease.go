@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"time"
+)
+
+type RenewLeaseUseCase struct {
+	repository    domain.TaskRepository
+	leaseDuration time.Duration
+}
+
+func NewRenewLeaseUseCase(repository domain.TaskRepository, leaseDuration time.Duration) *RenewLeaseUseCase {
+	return &RenewLeaseUseCase{
+		repository:    repository,
+		leaseDuration: leaseDuration,
+	}
+}
+
+// Execute extends the worker's lease on the task and records its progress,
+// if any. It returns domain.ErrLeaseNotHeld once the worker has lost the
+// task, in which case the worker should stop working on it.
+func (uc *RenewLeaseUseCase) Execute(taskID, workerID string, leaseFor time.Duration, progress *domain.TaskProgress) (*domain.Task, error) {
+	until := time.Now().Add(clampLease(leaseFor, uc.leaseDuration))
+
+	return updateLeasedTask(uc.repository, taskID, workerID, func(task *domain.Task) error {
+		if progress != nil {
+			task.UpdateProgress(progress.Percent, progress.Stage, progress.Message)
+		}
+		return task.RenewLease(workerID, until)
+	})
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"log/slog"
+	"time"
+)
+
+type RetryTaskUseCase struct {
+	repository domain.TaskRepository
+	dispatcher *DispatchTasksUseCase
+	timeouts   *TaskTimeouts
+}
+
+func NewRetryTaskUseCase(repository domain.TaskRepository, dispatcher *DispatchTasksUseCase, timeouts *TaskTimeouts) *RetryTaskUseCase {
+	return &RetryTaskUseCase{
+		repository: repository,
+		dispatcher: dispatcher,
+		timeouts:   timeouts,
+	}
+}
+
+// Execute puts a failed task back in the queue, including tasks in the
+// dead letter queue. A non-zero timeout replaces the task's time limit,
+// e.g. to give a task that timed out more time.
+func (uc *RetryTaskUseCase) Execute(taskID string, tenant string, timeout time.Duration) (*domain.Task, error) {
+	if taskID == "" {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	var task *domain.Task
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		task, err = uc.retry(taskID, tenant, timeout)
+		if err != domain.ErrVersionConflict {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The task is back in line once it is saved; see SubmitTaskUseCase.
+	if _, err := uc.dispatcher.Dispatch(task); err != nil {
+		slog.Warn("failed to dispatch retried task", "task_id", task.ID, "error", err)
+	}
+
+	return task, nil
+}
+
+func (uc *RetryTaskUseCase) retry(taskID string, tenant string, timeout time.Duration) (*domain.Task, error) {
+	task, err := uc.repository.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !task.VisibleTo(tenant) {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	if err := task.RetryManually(); err != nil {
+		return nil, err
+	}
+	if timeout != 0 {
+		if task.Timeout, err = uc.timeouts.Resolve(task.Type, timeout); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.repository.Update(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
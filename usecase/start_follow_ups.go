@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"log/slog"
+)
+
+// StartFollowUpsUseCase submits the follow-ups of tasks that have finished.
+// A follow-up gets an ID derived from its parent's and the parent records
+// it afterwards, so a follow-up is submitted once even if this runs again
+// after a crash in between. HandleEvent starts a follow-up as soon as its
+// parent finishes; Execute sweeps every finished task for those whose
+// event was missed or that were held up by a quota.
+type StartFollowUpsUseCase struct {
+	repository domain.TaskRepository
+	submitUC   *SubmitTaskUseCase
+}
+
+func NewStartFollowUpsUseCase(repository domain.TaskRepository, submitUC *SubmitTaskUseCase) *StartFollowUpsUseCase {
+	return &StartFollowUpsUseCase{
+		repository: repository,
+		submitUC:   submitUC,
+	}
+}
+
+// Execute returns the number of follow-ups it started. Follow-ups held up
+// by the tenant's quota are tried again on the next run.
+func (uc *StartFollowUpsUseCase) Execute() (int, error) {
+	started := 0
+	for _, status := range []domain.TaskStatus{domain.TaskStatusCompleted, domain.TaskStatusFailed, domain.TaskStatusExpired} {
+		tasks, err := uc.repository.FindByStatus(status)
+		if err != nil {
+			return started, err
+		}
+
+		for _, task := range tasks {
+			if task.DueFollowUp() == nil {
+				continue
+			}
+			ok, err := uc.start(task)
+			if err != nil {
+				return started, err
+			}
+			if ok {
+				started++
+			}
+		}
+	}
+	return started, nil
+}
+
+// HandleEvent starts the follow-up of the task the event is about if the
+// change finished it, and reports whether it did.
+func (uc *StartFollowUpsUseCase) HandleEvent(event domain.TaskEvent) (bool, error) {
+	if event.Kind != domain.TaskEventUpdated || event.Task.DueFollowUp() == nil {
+		return false, nil
+	}
+
+	// The event carries a copy from the time of the change; the follow-up
+	// may have been started since.
+	parent, err := uc.repository.FindByID(event.Task.ID)
+	if err == domain.ErrTaskNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if parent.DueFollowUp() == nil {
+		return false, nil
+	}
+	return uc.start(parent)
+}
+
+func (uc *StartFollowUpsUseCase) start(parent *domain.Task) (bool, error) {
+	followUp := parent.DueFollowUp()
+	followUpID := parent.FollowUpTaskID()
+
+	_, err := uc.repository.FindByID(followUpID)
+	if err == nil {
+		// Submitted before, but not recorded on the parent.
+		return true, uc.record(parent, func(t *domain.Task) { t.FollowUpID = followUpID })
+	}
+	if err != domain.ErrTaskNotFound {
+		return false, err
+	}
+
+	result, err := uc.submit(parent, followUp, followUpID)
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		return false, nil
+	}
+	if err != nil {
+		slog.Warn("failed to start follow-up task", "task_id", parent.ID, "error", err)
+		return false, uc.record(parent, func(t *domain.Task) { t.FollowUpError = err.Error() })
+	}
+
+	// A follow-up merged into a duplicate is recorded as that task.
+	return true, uc.record(parent, func(t *domain.Task) { t.FollowUpID = result.Task.ID })
+}
+
+func (uc *StartFollowUpsUseCase) submit(parent *domain.Task, followUp *domain.FollowUp, followUpID string) (*SubmitTaskResult, error) {
+	payload, err := followUp.RenderPayload(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := followUp.Priority
+	if priority == "" {
+		priority = parent.Priority
+	}
+
+	return uc.submitUC.Execute(followUp.Type, priority, payload, SubmitTaskOptions{
+		SubmittedBy: parent.SubmittedBy,
+		Tenant:      parent.Tenant,
+		TraceParent: parent.TraceParent,
+		OnSuccess:   followUp.OnSuccess,
+		OnFailure:   followUp.OnFailure,
+		ParentID:    parent.ID,
+		TaskID:      followUpID,
+	})
+}
+
+// record updates the parent, trying again if it changed meanwhile.
+func (uc *StartFollowUpsUseCase) record(parent *domain.Task, apply func(*domain.Task)) error {
+	task := parent
+	for i := 0; i < maxConflictRetries; i++ {
+		apply(task)
+		err := uc.repository.Update(task)
+		if err != domain.ErrVersionConflict {
+			return err
+		}
+
+		latest, err := uc.repository.FindByID(parent.ID)
+		if err != nil {
+			return err
+		}
+		task = latest
+	}
+	return domain.ErrVersionConflict
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"testing"
+	"time"
+)
+
+func newFollowUpStarter(repo domain.TaskRepository) *StartFollowUpsUseCase {
+	queue := &fakeQueue{capacity: 10}
+	submit := NewSubmitTaskUseCase(
+		repo,
+		queue,
+		NewDispatchTasksUseCase(repo, queue, time.Minute, nil),
+		NewQuotaChecker(repo, TenantQuota{}, nil),
+		NewTaskTimeouts(TimeoutPolicy{Default: time.Minute}),
+		NewUniquenessRules(nil),
+	)
+	return NewStartFollowUpsUseCase(repo, submit)
+}
+
+// completedWithFollowUp stores a completed task that notifies its
+// submitter on success.
+func completedWithFollowUp(t *testing.T, repo domain.TaskRepository) *domain.Task {
+	t.Helper()
+
+	task := storePending(t, repo, "acme", domain.TaskPriorityHigh)
+	task.OnSuccess = &domain.FollowUp{
+		Type:    domain.TaskTypeEmail,
+		Payload: map[string]interface{}{"to": "ops@example.com", "subject": "{{ .parent.id }} done"},
+	}
+	if err := task.MarkAsProcessing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := task.MarkAsCompleted(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestStartFollowUpsSubmitsOnce(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	parent := completedWithFollowUp(t, repo)
+	starter := newFollowUpStarter(repo)
+
+	started, err := starter.Execute()
+	if err != nil || started != 1 {
+		t.Fatalf("Execute() = %d, %v; want 1 follow-up", started, err)
+	}
+
+	followUp, err := repo.FindByID(parent.FollowUpTaskID())
+	if err != nil {
+		t.Fatalf("follow-up wasn't stored under its derived ID: %v", err)
+	}
+	if followUp.ParentID != parent.ID || followUp.Tenant != "acme" || followUp.Priority != domain.TaskPriorityHigh {
+		t.Errorf("follow-up parent %q, tenant %q, priority %s; want it to inherit from its parent", followUp.ParentID, followUp.Tenant, followUp.Priority)
+	}
+	if followUp.Payload["subject"] != parent.ID+" done" {
+		t.Errorf("follow-up subject = %v", followUp.Payload["subject"])
+	}
+	if stored, _ := repo.FindByID(parent.ID); stored.FollowUpID != followUp.ID {
+		t.Errorf("parent records follow-up %q, want %s", stored.FollowUpID, followUp.ID)
+	}
+
+	if started, err := starter.Execute(); err != nil || started != 0 {
+		t.Errorf("second Execute() = %d, %v; want nothing started", started, err)
+	}
+	if count, _ := repo.Count(); count != 2 {
+		t.Errorf("%d tasks stored, want the parent and one follow-up", count)
+	}
+}
+
+func TestStartFollowUpsRecordsFollowUpStartedBeforeCrash(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	parent := completedWithFollowUp(t, repo)
+	starter := newFollowUpStarter(repo)
+
+	// The follow-up was submitted, but the parent wasn't updated.
+	if _, err := starter.submit(parent, parent.OnSuccess, parent.FollowUpTaskID()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := starter.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := repo.FindByID(parent.ID); stored.FollowUpID != parent.FollowUpTaskID() {
+		t.Errorf("parent records follow-up %q, want %s", stored.FollowUpID, parent.FollowUpTaskID())
+	}
+	if count, _ := repo.Count(); count != 2 {
+		t.Errorf("%d tasks stored, want the parent and one follow-up", count)
+	}
+}
+
+func TestStartFollowUpsRecordsRenderError(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	parent := completedWithFollowUp(t, repo)
+	parent.OnSuccess.Payload["to"] = "{{ .parent.result.owner }}"
+	if err := repo.Update(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newFollowUpStarter(repo).Execute(); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := repo.FindByID(parent.ID)
+	if stored.FollowUpError == "" || stored.FollowUpID != "" {
+		t.Errorf("parent follow-up error %q, ID %q; want the render error recorded", stored.FollowUpError, stored.FollowUpID)
+	}
+}
+
+func TestStartFollowUpsOnFinishEvent(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	parent := completedWithFollowUp(t, repo)
+	starter := newFollowUpStarter(repo)
+
+	created := domain.TaskEvent{Kind: domain.TaskEventCreated, Task: parent}
+	if started, err := starter.HandleEvent(created); err != nil || started {
+		t.Errorf("HandleEvent(created) = %v, %v; want nothing started", started, err)
+	}
+
+	finished := domain.TaskEvent{Kind: domain.TaskEventUpdated, Task: parent}
+	if started, err := starter.HandleEvent(finished); err != nil || !started {
+		t.Fatalf("HandleEvent(updated) = %v, %v; want the follow-up started", started, err)
+	}
+	if _, err := repo.FindByID(parent.FollowUpTaskID()); err != nil {
+		t.Fatalf("follow-up wasn't stored: %v", err)
+	}
+
+	// A stale copy of the same change doesn't start it again.
+	if started, err := starter.HandleEvent(finished); err != nil || started {
+		t.Errorf("HandleEvent() of a stale event = %v, %v; want nothing started", started, err)
+	}
+}
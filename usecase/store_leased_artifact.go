@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-task-queue-system/domain"
+	"io"
+	"time"
+)
+
+// StoreLeasedArtifactUseCase stores a file a remote worker produced for a
+// task it holds, as if a local processor had written it.
+type StoreLeasedArtifactUseCase struct {
+	repository domain.TaskRepository
+	blobStore  domain.BlobStore
+}
+
+func NewStoreLeasedArtifactUseCase(repository domain.TaskRepository, blobStore domain.BlobStore) *StoreLeasedArtifactUseCase {
+	return &StoreLeasedArtifactUseCase{
+		repository: repository,
+		blobStore:  blobStore,
+	}
+}
+
+func (uc *StoreLeasedArtifactUseCase) Execute(taskID, workerID, name, contentType string, content io.Reader) (*domain.Artifact, error) {
+	if err := domain.ValidateArtifactName(name); err != nil {
+		return nil, err
+	}
+
+	task, err := uc.repository.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !task.HoldsLease(workerID) {
+		return nil, domain.ErrLeaseNotHeld
+	}
+
+	hash := sha256.New()
+	size, err := uc.blobStore.Put(domain.ArtifactKey(taskID, name), io.TeeReader(content, hash))
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := domain.Artifact{
+		Name:        name,
+		Size:        size,
+		ContentType: contentType,
+		Checksum:    "sha256:" + hex.EncodeToString(hash.Sum(nil)),
+		CreatedAt:   time.Now(),
+	}
+
+	_, err = updateLeasedTask(uc.repository, taskID, workerID, func(task *domain.Task) error {
+		task.AddArtifact(artifact)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &artifact, nil
+}
@@ -1,28 +1,96 @@
 package usecase
 
 import (
-	"errors"
 	"go-task-queue-system/domain"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
 )
 
 type SubmitTaskUseCase struct {
 	repository domain.TaskRepository
 	queue      TaskQueue
+	dispatcher *DispatchTasksUseCase
+	quotas     *QuotaChecker
+	timeouts   *TaskTimeouts
+	uniqueness *UniquenessRules
+
+	// mu keeps submissions of task types with a uniqueness rule from
+	// interleaving between looking for a duplicate and saving the task.
+	mu sync.Mutex
+}
+
+type SubmitTaskOptions struct {
+	// SubmittedBy identifies the caller, e.g. the ID of its API key.
+	SubmittedBy string
+
+	// Tenant owns the task. Empty means domain.DefaultTenant.
+	Tenant string
+
+	// TraceParent is the W3C traceparent of the submitting request.
+	TraceParent string
+
+	// Timeout is the time limit per attempt asked for; zero leaves it to
+	// the server's policy.
+	Timeout time.Duration
+
+	// ExpiresAt, if not zero, is when the task stops being worth running.
+	ExpiresAt time.Time
+
+	// RejectIfQueueFull fails the submission with domain.ErrQueueFull
+	// instead of backlogging the task when the queue is full.
+	RejectIfQueueFull bool
+
+	// OnSuccess and OnFailure are submitted when the task finishes.
+	OnSuccess *domain.FollowUp
+	OnFailure *domain.FollowUp
+
+	// ParentID and TaskID are set when a follow-up is submitted: the task
+	// that started it, and the ID it gets instead of a random one.
+	ParentID string
+	TaskID   string
+}
+
+// SubmitOutcome says what became of a submission.
+type SubmitOutcome string
+
+const (
+	SubmitOutcomeCreated SubmitOutcome = "created"
+	// SubmitOutcomeMerged means the submission duplicated an existing task,
+	// which stands in for it.
+	SubmitOutcomeMerged SubmitOutcome = "merged"
+	// SubmitOutcomeReplaced means the submission was accepted in place of a
+	// pending duplicate, which was cancelled.
+	SubmitOutcomeReplaced SubmitOutcome = "replaced"
+)
+
+type SubmitTaskResult struct {
+	Task    *domain.Task
+	Outcome SubmitOutcome
+
+	// DuplicateOf is the ID of the task merged into or replaced.
+	DuplicateOf string
 }
 
 type TaskQueue interface {
 	Enqueue(task *domain.Task) error
 	Size() int
+	IsFull() bool
 }
 
-func NewSubmitTaskUseCase(repository domain.TaskRepository, queue TaskQueue) *SubmitTaskUseCase {
+func NewSubmitTaskUseCase(repository domain.TaskRepository, queue TaskQueue, dispatcher *DispatchTasksUseCase, quotas *QuotaChecker, timeouts *TaskTimeouts, uniqueness *UniquenessRules) *SubmitTaskUseCase {
 	return &SubmitTaskUseCase{
 		repository: repository,
 		queue:      queue,
+		dispatcher: dispatcher,
+		quotas:     quotas,
+		timeouts:   timeouts,
+		uniqueness: uniqueness,
 	}
 }
 
-func (uc *SubmitTaskUseCase) Execute(taskType domain.TaskType, priority domain.TaskPriority, payload map[string]interface{}) (*domain.Task, error) {
+func (uc *SubmitTaskUseCase) Execute(taskType domain.TaskType, priority domain.TaskPriority, payload map[string]interface{}, opts SubmitTaskOptions) (*SubmitTaskResult, error) {
 	if !taskType.IsValid() {
 		return nil, domain.ErrInvalidTaskType
 	}
@@ -31,20 +99,200 @@ func (uc *SubmitTaskUseCase) Execute(taskType domain.TaskType, priority domain.T
 		return nil, domain.ErrEmptyPayload
 	}
 
+	tenant := opts.Tenant
+	if tenant == "" {
+		tenant = domain.DefaultTenant
+	}
+	if err := domain.ValidateTenant(tenant); err != nil {
+		return nil, err
+	}
+
+	timeout, err := uc.timeouts.Resolve(taskType, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.ExpiresAt.IsZero() && !opts.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidExpiry
+	}
+
+	for _, followUp := range []*domain.FollowUp{opts.OnSuccess, opts.OnFailure} {
+		if followUp != nil {
+			if err := followUp.Validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.RejectIfQueueFull && uc.queue.IsFull() && !uc.dispatcher.IsRemote(taskType) {
+		return nil, domain.ErrQueueFull
+	}
+
+	result := &SubmitTaskResult{Outcome: SubmitOutcomeCreated}
+
+	var uniqueKey string
+	var replaced *domain.Task
+	if rule, ok := uc.uniqueness.Rule(taskType); ok {
+		uc.mu.Lock()
+		defer uc.mu.Unlock()
+
+		uniqueKey = rule.Key(taskType, payload)
+		duplicate, err := uc.findDuplicate(rule, tenant, uniqueKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if duplicate != nil {
+			switch {
+			case rule.OnDuplicate == domain.DuplicatePolicyMerge:
+				return &SubmitTaskResult{Task: duplicate, Outcome: SubmitOutcomeMerged, DuplicateOf: duplicate.ID}, nil
+			case rule.OnDuplicate == domain.DuplicatePolicyReplace && duplicate.Status == domain.TaskStatusPending:
+				replaced = duplicate
+			default:
+				// Rejected, or a duplicate that has started and can't be
+				// replaced anymore.
+				return nil, &domain.DuplicateTaskError{TaskID: duplicate.ID, UniqueKey: uniqueKey}
+			}
+		}
+	}
+
+	reservation, err := uc.quotas.Acquire(tenant)
+	if err != nil {
+		return nil, err
+	}
+	// A submission that fails from here on doesn't count against the quotas.
+	defer reservation.Cancel()
+
 	task, err := domain.NewTask(taskType, priority, payload)
 	if err != nil {
 		return nil, err
 	}
 
+	task.SubmittedBy = opts.SubmittedBy
+	task.Tenant = tenant
+	task.TraceParent = opts.TraceParent
+	task.Timeout = timeout
+	if !opts.ExpiresAt.IsZero() {
+		task.ExpiresAt = &opts.ExpiresAt
+	}
+	task.UniqueKey = uniqueKey
+	task.OnSuccess = opts.OnSuccess
+	task.OnFailure = opts.OnFailure
+	task.ParentID = opts.ParentID
+	if opts.TaskID != "" {
+		task.ID = opts.TaskID
+	}
+
+	if replaced != nil {
+		original, err := uc.cancelReplaced(replaced)
+		if err != nil {
+			return nil, err
+		}
+		result.Outcome = SubmitOutcomeReplaced
+		result.DuplicateOf = replaced.ID
+
+		// If the submission fails from here on, the replaced task takes
+		// its place again.
+		defer func() {
+			if result.Task == nil {
+				uc.restoreReplaced(replaced, original)
+			}
+		}()
+	}
+
 	if err := uc.repository.Save(task); err != nil {
 		return nil, err
 	}
 
-	if err := uc.queue.Enqueue(task); err != nil {
-		// If enqueue fails, later probably mark the task as failed
-		// For now, just return the error
-		return nil, errors.New("failed to enqueue task: " + err.Error())
+	// The task is accepted once it is saved. If it can't be queued now, the
+	// dispatcher loop queues it later.
+	queued, err := uc.dispatcher.Dispatch(task)
+	if err != nil {
+		slog.Warn("failed to dispatch submitted task", "task_id", task.ID, "error", err)
+	}
+
+	if opts.RejectIfQueueFull && !queued {
+		// The queue filled up since the check above. A conflict means the
+		// dispatcher got to the task meanwhile, so it is accepted after all.
+		err := uc.repository.Delete(task)
+		if err == nil {
+			return nil, domain.ErrQueueFull
+		}
+		if err != domain.ErrVersionConflict {
+			return nil, err
+		}
+	}
+
+	reservation.Commit()
+	result.Task = task
+	return result, nil
+}
+
+// findDuplicate returns the latest task of tenant with uniqueKey that rule
+// counts as a duplicate of a task submitted now, or nil.
+func (uc *SubmitTaskUseCase) findDuplicate(rule domain.UniquenessRule, tenant, uniqueKey string) (*domain.Task, error) {
+	now := time.Now()
+
+	tasks, err := uc.repository.FindByUniqueKey(tenant, uniqueKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicate *domain.Task
+	for _, task := range tasks {
+		if !rule.Duplicates(task, now) {
+			continue
+		}
+		if duplicate == nil || task.CreatedAt.After(duplicate.CreatedAt) {
+			duplicate = task
+		}
 	}
+	return duplicate, nil
+}
+
+// cancelReplaced cancels a pending duplicate the submission replaces and
+// returns the task as it was before. If a worker picks it up first, the
+// submission is a duplicate after all.
+func (uc *SubmitTaskUseCase) cancelReplaced(task *domain.Task) (*domain.Task, error) {
+	for i := 0; i < maxConflictRetries; i++ {
+		if task.Status != domain.TaskStatusPending {
+			return nil, &domain.DuplicateTaskError{TaskID: task.ID, UniqueKey: task.UniqueKey}
+		}
+		original := *task
+		original.Transitions = slices.Clone(task.Transitions)
+		if err := task.MarkAsCancelled(); err != nil {
+			return nil, err
+		}
+
+		err := uc.repository.Update(task)
+		if err == nil {
+			return &original, nil
+		}
+		if err != domain.ErrVersionConflict {
+			return nil, err
+		}
+
+		latest, err := uc.repository.FindByID(task.ID)
+		if err != nil {
+			return nil, err
+		}
+		*task = *latest
+	}
+	return nil, domain.ErrVersionConflict
+}
 
-	return task, nil
+// restoreReplaced undoes cancelReplaced for a submission that failed after
+// all, by storing the replaced task as it was before it was cancelled. A
+// task that changed again since is left alone.
+func (uc *SubmitTaskUseCase) restoreReplaced(cancelled, original *domain.Task) {
+	restored := *original
+	restored.Version = cancelled.Version
+	// Its queue entry may have been skipped while it was cancelled, so have
+	// the dispatcher queue it again.
+	restored.DispatchedAt = nil
+	restored.DispatchedBy = ""
+
+	if err := uc.repository.Update(&restored); err != nil {
+		slog.Error("failed to restore replaced task", "task_id", restored.ID, "error", err)
+	}
 }
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"testing"
+	"time"
+)
+
+func newUniqueSubmit(repo domain.TaskRepository, policy domain.DuplicatePolicy) *SubmitTaskUseCase {
+	queue := &fakeQueue{capacity: 10}
+	rules := NewUniquenessRules(map[domain.TaskType]domain.UniquenessRule{
+		domain.TaskTypeEmail: {Fields: []string{"to"}, Window: time.Hour, OnDuplicate: policy},
+	})
+	return NewSubmitTaskUseCase(
+		repo,
+		queue,
+		NewDispatchTasksUseCase(repo, queue, time.Minute, nil),
+		NewQuotaChecker(repo, TenantQuota{}, nil),
+		NewTaskTimeouts(TimeoutPolicy{Default: time.Minute}),
+		rules,
+	)
+}
+
+func submitEmail(submit *SubmitTaskUseCase, tenant, to string) (*SubmitTaskResult, error) {
+	payload := map[string]interface{}{"to": to, "subject": "hello"}
+	return submit.Execute(domain.TaskTypeEmail, domain.TaskPriorityMedium, payload, SubmitTaskOptions{Tenant: tenant})
+}
+
+func TestSubmitTaskRejectsDuplicate(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	submit := newUniqueSubmit(repo, domain.DuplicatePolicyReject)
+
+	first, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Outcome != SubmitOutcomeCreated || first.Task.UniqueKey == "" {
+		t.Fatalf("first submission = %s with key %q, want created with a unique key", first.Outcome, first.Task.UniqueKey)
+	}
+
+	_, err = submitEmail(submit, "acme", "ops@example.com")
+	var duplicateErr *domain.DuplicateTaskError
+	if !errors.As(err, &duplicateErr) || duplicateErr.TaskID != first.Task.ID {
+		t.Fatalf("duplicate submission error = %v, want a DuplicateTaskError for %s", err, first.Task.ID)
+	}
+
+	// Other payloads and other tenants aren't duplicates.
+	if _, err := submitEmail(submit, "acme", "sales@example.com"); err != nil {
+		t.Errorf("submission with another recipient error = %v", err)
+	}
+	if _, err := submitEmail(submit, "globex", "ops@example.com"); err != nil {
+		t.Errorf("submission by another tenant error = %v", err)
+	}
+}
+
+func TestSubmitTaskMergesDuplicate(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	submit := newUniqueSubmit(repo, domain.DuplicatePolicyMerge)
+
+	first, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatalf("duplicate submission error = %v", err)
+	}
+	if merged.Outcome != SubmitOutcomeMerged || merged.Task.ID != first.Task.ID || merged.DuplicateOf != first.Task.ID {
+		t.Errorf("duplicate submission = %s of %s with task %s, want merged into %s", merged.Outcome, merged.DuplicateOf, merged.Task.ID, first.Task.ID)
+	}
+	if count, _ := repo.Count(); count != 1 {
+		t.Errorf("%d tasks stored, want 1", count)
+	}
+}
+
+func TestSubmitTaskReplacesPendingDuplicate(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	submit := newUniqueSubmit(repo, domain.DuplicatePolicyReplace)
+
+	first, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatalf("duplicate submission error = %v", err)
+	}
+	if replacement.Outcome != SubmitOutcomeReplaced || replacement.DuplicateOf != first.Task.ID || replacement.Task.ID == first.Task.ID {
+		t.Errorf("duplicate submission = %s of %s, want a new task replacing %s", replacement.Outcome, replacement.DuplicateOf, first.Task.ID)
+	}
+	if stored, _ := repo.FindByID(first.Task.ID); stored.Status != domain.TaskStatusCancelled {
+		t.Errorf("replaced task is %s, want cancelled", stored.Status)
+	}
+}
+
+func TestSubmitTaskWontReplaceStartedDuplicate(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	submit := newUniqueSubmit(repo, domain.DuplicatePolicyReplace)
+
+	first, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	running, _ := repo.FindByID(first.Task.ID)
+	if err := running.MarkAsProcessing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(running); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = submitEmail(submit, "acme", "ops@example.com")
+	var duplicateErr *domain.DuplicateTaskError
+	if !errors.As(err, &duplicateErr) {
+		t.Errorf("submission duplicating a running task error = %v, want a DuplicateTaskError", err)
+	}
+}
+
+func TestSubmitTaskIgnoresFinishedAndOldDuplicates(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	submit := newUniqueSubmit(repo, domain.DuplicatePolicyReject)
+
+	first, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, _ := repo.FindByID(first.Task.ID)
+	if err := cancelled.MarkAsCancelled(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(cancelled); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submitEmail(submit, "acme", "ops@example.com"); err != nil {
+		t.Errorf("submission duplicating a cancelled task error = %v", err)
+	}
+
+	rule := domain.UniquenessRule{Window: time.Hour}
+	old := &domain.Task{Status: domain.TaskStatusCompleted, CreatedAt: time.Now().Add(-2 * time.Hour)}
+	if rule.Duplicates(old, time.Now()) {
+		t.Error("task from before the window counts as a duplicate")
+	}
+}
+
+func TestUniquenessRuleKey(t *testing.T) {
+	rule := domain.UniquenessRule{Fields: []string{"report_type", "end_date"}}
+
+	key := rule.Key(domain.TaskTypeReportGeneration, map[string]interface{}{
+		"report_type": "sales",
+		"end_date":    "2024-01-31",
+		"format":      "csv",
+	})
+	if want := `report_generation:end_date="2024-01-31",report_type="sales"`; key != want {
+		t.Errorf("Key() = %s, want %s", key, want)
+	}
+
+	missing := rule.Key(domain.TaskTypeReportGeneration, map[string]interface{}{"report_type": "sales"})
+	if want := `report_generation:end_date=null,report_type="sales"`; missing != want {
+		t.Errorf("Key() without end_date = %s, want %s", missing, want)
+	}
+}
+
+func TestSubmitTaskRestoresReplacedTaskOnFailure(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	submit := newUniqueSubmit(repo, domain.DuplicatePolicyReplace)
+
+	first, err := submitEmail(submit, "acme", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The replacement is refused after the duplicate was cancelled.
+	submit.queue.(*fakeQueue).reject = true
+	payload := map[string]interface{}{"to": "ops@example.com", "subject": "hello"}
+	opts := SubmitTaskOptions{Tenant: "acme", RejectIfQueueFull: true}
+	if _, err := submit.Execute(domain.TaskTypeEmail, domain.TaskPriorityMedium, payload, opts); !errors.Is(err, domain.ErrQueueFull) {
+		t.Fatalf("Execute() error = %v, want ErrQueueFull", err)
+	}
+
+	stored, _ := repo.FindByID(first.Task.ID)
+	if stored.Status != domain.TaskStatusPending || !stored.AwaitingDispatch() {
+		t.Errorf("replaced task is %s, awaiting dispatch %v; want it pending again", stored.Status, stored.AwaitingDispatch())
+	}
+	if count, _ := repo.Count(); count != 1 {
+		t.Errorf("%d tasks stored, want only the restored one", count)
+	}
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"sync"
+	"time"
+)
+
+// TimeoutPolicy decides how long a task may run: as long as its submitter
+// asked for, else the default for its type, else Default, and never longer
+// than Max.
+type TimeoutPolicy struct {
+	Default time.Duration
+	PerType map[domain.TaskType]time.Duration
+	Max     time.Duration
+}
+
+// TaskTimeouts resolves task timeouts with a policy that can be replaced
+// while the server runs.
+type TaskTimeouts struct {
+	policy TimeoutPolicy
+	mu     sync.RWMutex
+}
+
+func NewTaskTimeouts(policy TimeoutPolicy) *TaskTimeouts {
+	return &TaskTimeouts{
+		policy: policy,
+	}
+}
+
+// SetPolicy replaces the policy, e.g. after a configuration reload. Tasks
+// submitted earlier keep their timeout.
+func (t *TaskTimeouts) SetPolicy(policy TimeoutPolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.policy = policy
+}
+
+// Resolve returns the timeout for a task of taskType whose submitter asked
+// for requested, or zero for no preference. Requests over the maximum are
+// cut down to it.
+func (t *TaskTimeouts) Resolve(taskType domain.TaskType, requested time.Duration) (time.Duration, error) {
+	if requested < 0 {
+		return 0, domain.ErrInvalidTimeout
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	timeout := requested
+	if timeout == 0 {
+		timeout = t.policy.PerType[taskType]
+	}
+	if timeout == 0 {
+		timeout = t.policy.Default
+	}
+	if t.policy.Max > 0 && timeout > t.policy.Max {
+		timeout = t.policy.Max
+	}
+	return timeout, nil
+}
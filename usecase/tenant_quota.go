@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"sync"
+	"time"
+)
+
+// TenantQuota limits how much work a tenant can have in the system. Zero
+// values mean unlimited.
+type TenantQuota struct {
+	MaxPendingTasks      int `json:"max_pending_tasks"`
+	SubmissionsPerMinute int `json:"submissions_per_minute"`
+}
+
+type QuotaChecker struct {
+	repository   domain.TaskRepository
+	defaultQuota TenantQuota
+	quotas       map[string]TenantQuota
+	submissions  map[string][]time.Time
+	mu           sync.Mutex
+
+	// reserved counts submissions per tenant that passed the checks but
+	// whose task isn't saved yet, so concurrent submissions can't all fit
+	// under max_pending_tasks.
+	reserved map[string]int
+}
+
+func NewQuotaChecker(repository domain.TaskRepository, defaultQuota TenantQuota, quotas map[string]TenantQuota) *QuotaChecker {
+	if quotas == nil {
+		quotas = make(map[string]TenantQuota)
+	}
+
+	return &QuotaChecker{
+		repository:   repository,
+		defaultQuota: defaultQuota,
+		quotas:       quotas,
+		submissions:  make(map[string][]time.Time),
+		reserved:     make(map[string]int),
+	}
+}
+
+func (q *QuotaChecker) QuotaFor(tenant string) TenantQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.quotaFor(tenant)
+}
+
+// SetQuotas replaces the quotas, e.g. after a configuration reload.
+func (q *QuotaChecker) SetQuotas(defaultQuota TenantQuota, quotas map[string]TenantQuota) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if quotas == nil {
+		quotas = make(map[string]TenantQuota)
+	}
+	q.defaultQuota = defaultQuota
+	q.quotas = quotas
+}
+
+// QuotaReservation holds a submission's place in its tenant's quotas until
+// the task is saved or the submission fails.
+type QuotaReservation struct {
+	checker     *QuotaChecker
+	tenant      string
+	submittedAt time.Time
+	done        bool
+}
+
+// Acquire checks the tenant's quotas for one more submission and, if they
+// allow it, reserves room for it. The caller must end the reservation with
+// Commit once the task is saved, or Cancel if the submission fails.
+func (q *QuotaChecker) Acquire(tenant string) (*QuotaReservation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	quota := q.quotaFor(tenant)
+	reservation := &QuotaReservation{checker: q, tenant: tenant}
+
+	if quota.MaxPendingTasks > 0 {
+		pending, err := q.repository.CountByTenantAndStatus(tenant, domain.TaskStatusPending)
+		if err != nil {
+			return nil, err
+		}
+		if pending+q.reserved[tenant] >= quota.MaxPendingTasks {
+			return nil, &domain.QuotaExceededError{
+				Tenant:     tenant,
+				Quota:      "max_pending_tasks",
+				Limit:      quota.MaxPendingTasks,
+				RetryAfter: 5 * time.Second,
+			}
+		}
+	}
+
+	if quota.SubmissionsPerMinute > 0 {
+		now := time.Now()
+		windowStart := now.Add(-time.Minute)
+
+		recent := q.submissions[tenant]
+		for len(recent) > 0 && !recent[0].After(windowStart) {
+			recent = recent[1:]
+		}
+
+		if len(recent) >= quota.SubmissionsPerMinute {
+			q.submissions[tenant] = recent
+			return nil, &domain.QuotaExceededError{
+				Tenant:     tenant,
+				Quota:      "submissions_per_minute",
+				Limit:      quota.SubmissionsPerMinute,
+				RetryAfter: recent[0].Sub(windowStart),
+			}
+		}
+
+		q.submissions[tenant] = append(recent, now)
+		reservation.submittedAt = now
+	}
+
+	q.reserved[tenant]++
+	return reservation, nil
+}
+
+// Commit ends the reservation of a saved task, which now counts as pending
+// by itself.
+func (r *QuotaReservation) Commit() {
+	r.end(false)
+}
+
+// Cancel gives back the reservation of a submission that failed, its place
+// in the per-minute quota included. It does nothing after Commit, so it
+// can be deferred.
+func (r *QuotaReservation) Cancel() {
+	r.end(true)
+}
+
+func (r *QuotaReservation) end(cancelled bool) {
+	q := r.checker
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if r.done {
+		return
+	}
+	r.done = true
+
+	q.reserved[r.tenant]--
+	if q.reserved[r.tenant] <= 0 {
+		delete(q.reserved, r.tenant)
+	}
+
+	if cancelled && !r.submittedAt.IsZero() {
+		recent := q.submissions[r.tenant]
+		for i := len(recent) - 1; i >= 0; i-- {
+			if recent[i].Equal(r.submittedAt) {
+				q.submissions[r.tenant] = append(recent[:i], recent[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (q *QuotaChecker) quotaFor(tenant string) TenantQuota {
+	if quota, ok := q.quotas[tenant]; ok {
+		return quota
+	}
+	return q.defaultQuota
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"errors"
+	"go-task-queue-system/domain"
+	"go-task-queue-system/infrastructure/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+func quotaError(t *testing.T, err error, quota string) *domain.QuotaExceededError {
+	t.Helper()
+
+	var quotaErr *domain.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("error = %v, want a *QuotaExceededError", err)
+	}
+	if quotaErr.Quota != quota {
+		t.Errorf("Quota = %s, want %s", quotaErr.Quota, quota)
+	}
+	return quotaErr
+}
+
+func TestQuotaReservationCountsAsPending(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	quotas := NewQuotaChecker(repo, TenantQuota{MaxPendingTasks: 2}, nil)
+
+	storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	reservation, err := quotas.Acquire("acme")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// One saved task and one reservation fill the quota of 2.
+	_, err = quotas.Acquire("acme")
+	quotaError(t, err, "max_pending_tasks")
+
+	reservation.Cancel()
+	if _, err := quotas.Acquire("acme"); err != nil {
+		t.Errorf("Acquire() after Cancel error = %v", err)
+	}
+}
+
+func TestQuotaReservationCommitKeepsSubmission(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	quotas := NewQuotaChecker(repo, TenantQuota{SubmissionsPerMinute: 1}, nil)
+
+	reservation, err := quotas.Acquire("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reservation.Commit()
+	// Cancel after Commit is a no-op, so the submission still counts.
+	reservation.Cancel()
+
+	_, err = quotas.Acquire("acme")
+	quotaErr := quotaError(t, err, "submissions_per_minute")
+	if quotaErr.RetryAfter <= 0 || quotaErr.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %v, want within the minute", quotaErr.RetryAfter)
+	}
+}
+
+func TestQuotaReservationCancelGivesBackSubmission(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	quotas := NewQuotaChecker(repo, TenantQuota{SubmissionsPerMinute: 1}, nil)
+
+	reservation, err := quotas.Acquire("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reservation.Cancel()
+	reservation.Cancel()
+
+	if _, err := quotas.Acquire("acme"); err != nil {
+		t.Errorf("Acquire() after Cancel error = %v", err)
+	}
+}
+
+func TestQuotaConcurrentAcquireStaysWithinMaxPending(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	quotas := NewQuotaChecker(repo, TenantQuota{MaxPendingTasks: 5}, nil)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		admitted int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := quotas.Acquire("acme"); err == nil {
+				mu.Lock()
+				admitted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if admitted != 5 {
+		t.Errorf("admitted %d concurrent submissions, want 5", admitted)
+	}
+}
+
+func TestQuotaPerTenantOverride(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	quotas := NewQuotaChecker(repo, TenantQuota{MaxPendingTasks: 1}, map[string]TenantQuota{
+		"bulk": {},
+	})
+
+	storePending(t, repo, "acme", domain.TaskPriorityMedium)
+	storePending(t, repo, "bulk", domain.TaskPriorityMedium)
+
+	_, err := quotas.Acquire("acme")
+	quotaError(t, err, "max_pending_tasks")
+
+	// Zero limits in the override mean unlimited.
+	for i := 0; i < 3; i++ {
+		if _, err := quotas.Acquire("bulk"); err != nil {
+			t.Fatalf("Acquire(bulk) error = %v", err)
+		}
+	}
+}
+
+func TestSubmitTaskGivesBackQuotaWhenEnqueueFails(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	quotas := NewQuotaChecker(repo, TenantQuota{SubmissionsPerMinute: 1}, nil)
+	queue := &fakeQueue{capacity: 10, reject: true}
+	submit := NewSubmitTaskUseCase(repo, queue, NewDispatchTasksUseCase(repo, queue, time.Minute, nil), quotas, NewTaskTimeouts(TimeoutPolicy{Default: time.Minute}), NewUniquenessRules(nil))
+
+	payload := map[string]interface{}{"to": "ops@example.com"}
+	opts := SubmitTaskOptions{Tenant: "acme", RejectIfQueueFull: true}
+	if _, err := submit.Execute(domain.TaskTypeEmail, domain.TaskPriorityMedium, payload, opts); err == nil {
+		t.Fatal("Execute() succeeded although the queue refused the task")
+	}
+
+	queue.reject = false
+	if _, err := submit.Execute(domain.TaskTypeEmail, domain.TaskPriorityMedium, payload, opts); err != nil {
+		t.Fatalf("Execute() after a refused submission error = %v", err)
+	}
+
+	_, err := submit.Execute(domain.TaskTypeEmail, domain.TaskPriorityMedium, payload, opts)
+	quotaError(t, err, "submissions_per_minute")
+}
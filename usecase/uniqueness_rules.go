@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"go-task-queue-system/domain"
+	"sync"
+)
+
+// UniquenessRules holds the uniqueness rule of each task type, and can be
+// replaced while the server runs.
+type UniquenessRules struct {
+	rules map[domain.TaskType]domain.UniquenessRule
+	mu    sync.RWMutex
+}
+
+func NewUniquenessRules(rules map[domain.TaskType]domain.UniquenessRule) *UniquenessRules {
+	return &UniquenessRules{
+		rules: rules,
+	}
+}
+
+// SetRules replaces the rules, e.g. after a configuration reload. Tasks
+// submitted earlier keep their unique key.
+func (u *UniquenessRules) SetRules(rules map[domain.TaskType]domain.UniquenessRule) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.rules = rules
+}
+
+// Rule returns the rule of taskType, if it has one.
+func (u *UniquenessRules) Rule(taskType domain.TaskType) (domain.UniquenessRule, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	rule, ok := u.rules[taskType]
+	return rule, ok
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import "go-task-queue-system/domain"
+
+type TaskEventSource interface {
+	Subscribe() (<-chan domain.TaskEvent, func())
+}
+
+type WatchTasksUseCase struct {
+	source TaskEventSource
+}
+
+func NewWatchTasksUseCase(source TaskEventSource) *WatchTasksUseCase {
+	return &WatchTasksUseCase{
+		source: source,
+	}
+}
+
+// Execute returns the changes to tasks of tenant from now on, and a
+// function to stop watching. An empty tenant watches every tenant. The
+// channel is closed once watching stops.
+func (uc *WatchTasksUseCase) Execute(tenant string) (<-chan domain.TaskEvent, func()) {
+	events, stop := uc.source.Subscribe()
+	if tenant == "" {
+		return events, stop
+	}
+
+	visible := make(chan domain.TaskEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(visible)
+		for event := range events {
+			if !event.Task.VisibleTo(tenant) {
+				continue
+			}
+			select {
+			case visible <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return visible, func() {
+		stop()
+		close(done)
+	}
+}